@@ -8,24 +8,83 @@ package main
 
 import (
 	"context"
+	"twitch-crypto-donations/internal/app/acceptcollabinvite"
+	"twitch-crypto-donations/internal/app/builddonationtransaction"
+	"twitch-crypto-donations/internal/app/closepoll"
+	"twitch-crypto-donations/internal/app/createapikey"
+	"twitch-crypto-donations/internal/app/createchallenge"
+	"twitch-crypto-donations/internal/app/createcollabgroup"
+	"twitch-crypto-donations/internal/app/creategiveaway"
+	"twitch-crypto-donations/internal/app/creategoal"
+	"twitch-crypto-donations/internal/app/createpaymentrequest"
+	"twitch-crypto-donations/internal/app/createpoll"
+	"twitch-crypto-donations/internal/app/deletegoal"
 	"twitch-crypto-donations/internal/app/donationsanalytics"
 	"twitch-crypto-donations/internal/app/donationshistory"
+	"twitch-crypto-donations/internal/app/getchallenges"
 	"twitch-crypto-donations/internal/app/getdefaultobssettings"
+	"twitch-crypto-donations/internal/app/getdonation"
+	"twitch-crypto-donations/internal/app/getdonationrules"
+	"twitch-crypto-donations/internal/app/getgiveaway"
+	"twitch-crypto-donations/internal/app/getgoal"
+	"twitch-crypto-donations/internal/app/getjwks"
+	"twitch-crypto-donations/internal/app/getpaymentrequest"
+	"twitch-crypto-donations/internal/app/getpolls"
+	"twitch-crypto-donations/internal/app/getrevenuesplits"
 	"twitch-crypto-donations/internal/app/getstreamerinfo"
+	"twitch-crypto-donations/internal/app/getsubathon"
+	"twitch-crypto-donations/internal/app/listapikeys"
+	"twitch-crypto-donations/internal/app/listchallenges"
+	"twitch-crypto-donations/internal/app/listcollabgroups"
+	"twitch-crypto-donations/internal/app/listdonations"
+	"twitch-crypto-donations/internal/app/listevents"
+	"twitch-crypto-donations/internal/app/listgiveaways"
+	"twitch-crypto-donations/internal/app/listgoals"
+	"twitch-crypto-donations/internal/app/listpolls"
+	"twitch-crypto-donations/internal/app/logout"
+	"twitch-crypto-donations/internal/app/logoutall"
 	"twitch-crypto-donations/internal/app/noncegeneration"
 	"twitch-crypto-donations/internal/app/paymentconfirmation"
+	"twitch-crypto-donations/internal/app/refreshsession"
+	"twitch-crypto-donations/internal/app/resolvechallenge"
+	"twitch-crypto-donations/internal/app/revokeapikey"
 	"twitch-crypto-donations/internal/app/senddonate"
+	"twitch-crypto-donations/internal/app/sendtestalert"
+	"twitch-crypto-donations/internal/app/setdonationrules"
 	"twitch-crypto-donations/internal/app/setobswebhooks"
+	"twitch-crypto-donations/internal/app/setrevenuesplits"
 	"twitch-crypto-donations/internal/app/setuserinfo"
 	"twitch-crypto-donations/internal/app/signatureverification"
+	"twitch-crypto-donations/internal/app/startsubathon"
+	"twitch-crypto-donations/internal/app/stopsubathon"
 	"twitch-crypto-donations/internal/app/updatedefaultobssettings"
+	"twitch-crypto-donations/internal/app/updategoal"
+	"twitch-crypto-donations/internal/app/updatesubathon"
 	"twitch-crypto-donations/internal/config"
+	"twitch-crypto-donations/internal/pkg/apikeys"
+	"twitch-crypto-donations/internal/pkg/challenges"
+	"twitch-crypto-donations/internal/pkg/collab"
+	"twitch-crypto-donations/internal/pkg/donationrules"
 	"twitch-crypto-donations/internal/pkg/environment"
+	"twitch-crypto-donations/internal/pkg/finality"
+	"twitch-crypto-donations/internal/pkg/giveaways"
+	"twitch-crypto-donations/internal/pkg/goals"
 	"twitch-crypto-donations/internal/pkg/http"
 	"twitch-crypto-donations/internal/pkg/jwt"
+	"twitch-crypto-donations/internal/pkg/mints"
 	"twitch-crypto-donations/internal/pkg/obsservice"
+	"twitch-crypto-donations/internal/pkg/polls"
+	"twitch-crypto-donations/internal/pkg/pricing"
 	"twitch-crypto-donations/internal/pkg/router"
 	"twitch-crypto-donations/internal/pkg/server"
+	"twitch-crypto-donations/internal/pkg/sessions"
+	"twitch-crypto-donations/internal/pkg/siws"
+	"twitch-crypto-donations/internal/pkg/solanapay"
+	"twitch-crypto-donations/internal/pkg/splits"
+	"twitch-crypto-donations/internal/pkg/subathon"
+	"twitch-crypto-donations/internal/pkg/txbuilder"
+	"twitch-crypto-donations/internal/pkg/txverifier"
+	"twitch-crypto-donations/internal/pkg/walletwatcher"
 )
 
 // Injectors from wire.go:
@@ -73,15 +132,101 @@ func InitializeServer(ctx context.Context) (*server.Server, error) {
 	}
 	obsService := obsservice.New(db, httpClient, logrusAdapter, obsServiceDomain)
 	setobswebhooksHandler := setobswebhooks.New(db, obsService, obsServiceDomain)
-	senddonateHandler := senddonate.New(obsService, db)
-	noncegenerationHandler := noncegeneration.New(db)
 	rpcEndpoint, err := environment.GetRpcEndpoint()
 	if err != nil {
 		return nil, err
 	}
 	rpcClient := config.NewRpcClient(rpcEndpoint)
-	paymentconfirmationHandler := paymentconfirmation.New(rpcClient)
-	tokenExpirationHours, err := environment.GetTokenExpirationHours()
+	verifier := txverifier.New(rpcClient)
+	acceptedMints, err := environment.GetAcceptedMints()
+	if err != nil {
+		return nil, err
+	}
+	registry, err := mints.New(acceptedMints)
+	if err != nil {
+		return nil, err
+	}
+	evmRpcURLs, err := environment.GetEvmRpcURLs()
+	if err != nil {
+		return nil, err
+	}
+	evmTokens, err := environment.GetEvmTokens()
+	if err != nil {
+		return nil, err
+	}
+	chainRegistry, err := config.NewChainRegistry(verifier, registry, evmRpcURLs, evmTokens, httpClient)
+	if err != nil {
+		return nil, err
+	}
+	platformFeeBps, err := environment.GetPlatformFeeBps()
+	if err != nil {
+		return nil, err
+	}
+	platformFeeWallet, err := environment.GetPlatformFeeWallet()
+	if err != nil {
+		return nil, err
+	}
+	policy, err := splits.New(db, platformFeeBps, platformFeeWallet)
+	if err != nil {
+		return nil, err
+	}
+	collabRegistry := collab.New(db)
+	donationrulesRegistry := donationrules.New(db)
+	fiatCurrency, err := environment.GetFiatCurrency()
+	if err != nil {
+		return nil, err
+	}
+	tracker := goals.New(obsService, fiatCurrency)
+	subathonTracker := subathon.New(obsService)
+	pollsTracker := polls.New(obsService, fiatCurrency)
+	challengesTracker := challenges.New(obsService, fiatCurrency)
+	priceSource, err := environment.GetPriceSource()
+	if err != nil {
+		return nil, err
+	}
+	staticPrices, err := environment.GetStaticPrices()
+	if err != nil {
+		return nil, err
+	}
+	priceAPIURL, err := environment.GetPriceAPIURL()
+	if err != nil {
+		return nil, err
+	}
+	priceCoinIDs, err := environment.GetPriceCoinIDs()
+	if err != nil {
+		return nil, err
+	}
+	priceCacheTTLSeconds, err := environment.GetPriceCacheTTLSeconds()
+	if err != nil {
+		return nil, err
+	}
+	priceProvider, err := config.NewPriceProvider(priceSource, staticPrices, priceAPIURL, priceCoinIDs, priceCacheTTLSeconds, httpClient, logrusAdapter)
+	if err != nil {
+		return nil, err
+	}
+	valuer := pricing.NewValuer(priceProvider, fiatCurrency, logrusAdapter)
+	senddonateHandler := senddonate.New(obsService, db, chainRegistry, policy, collabRegistry, donationrulesRegistry, tracker, subathonTracker, pollsTracker, challengesTracker, valuer)
+	siwsDomain, err := environment.GetSIWSDomain()
+	if err != nil {
+		return nil, err
+	}
+	siwsuri, err := environment.GetSIWSURI()
+	if err != nil {
+		return nil, err
+	}
+	siwsChainID, err := environment.GetSIWSChainID()
+	if err != nil {
+		return nil, err
+	}
+	issuer := siws.New(siwsDomain, siwsuri, siwsChainID)
+	noncegenerationHandler := noncegeneration.New(db, issuer)
+	watcherPollIntervalSeconds, err := environment.GetWatcherPollIntervalSeconds()
+	if err != nil {
+		return nil, err
+	}
+	resolver := solanapay.NewResolver(db, rpcClient, chainRegistry, obsService, valuer, tracker, subathonTracker, donationrulesRegistry, logrusAdapter, watcherPollIntervalSeconds)
+	paymentconfirmationHandler := paymentconfirmation.New(chainRegistry, policy, resolver, db)
+	accessTokenTTLMinutes, err := environment.GetAccessTokenTTLMinutes()
 	if err != nil {
 		return nil, err
 	}
@@ -89,11 +234,82 @@ func InitializeServer(ctx context.Context) (*server.Server, error) {
 	if err != nil {
 		return nil, err
 	}
-	manager := jwt.New(tokenExpirationHours, jwtSecret)
-	signatureverificationHandler := signatureverification.New(db, manager)
+	jwtAlgorithm, err := environment.GetJwtAlgorithm()
+	if err != nil {
+		return nil, err
+	}
+	jwtSigningKeyFile, err := environment.GetJwtSigningKeyFile()
+	if err != nil {
+		return nil, err
+	}
+	jwtVerificationKeyFiles, err := environment.GetJwtVerificationKeyFiles()
+	if err != nil {
+		return nil, err
+	}
+	manager, err := jwt.New(accessTokenTTLMinutes, jwtSecret, jwtAlgorithm, jwtSigningKeyFile, jwtVerificationKeyFiles)
+	if err != nil {
+		return nil, err
+	}
+	refreshTokenTTLHours, err := environment.GetRefreshTokenTTLHours()
+	if err != nil {
+		return nil, err
+	}
+	sessionsManager := sessions.New(db, manager, refreshTokenTTLHours)
+	signatureverificationHandler := signatureverification.New(db, sessionsManager, issuer)
 	donationshistoryHandler := donationshistory.New(db)
 	getdefaultobssettingsHandler := getdefaultobssettings.New(db, obsService)
 	updatedefaultobssettingsHandler := updatedefaultobssettings.New(obsService)
+	paymentRequestTTLMinutes, err := environment.GetPaymentRequestTTLMinutes()
+	if err != nil {
+		return nil, err
+	}
+	routePrefix, err := environment.GetRoutePrefix()
+	if err != nil {
+		return nil, err
+	}
+	createpaymentrequestHandler := createpaymentrequest.New(db, registry, policy, donationrulesRegistry, paymentRequestTTLMinutes, routePrefix)
+	getpaymentrequestHandler := getpaymentrequest.New(db)
+	builder := txbuilder.New(rpcClient)
+	builddonationtransactionHandler := builddonationtransaction.New(db, registry, builder, policy, donationrulesRegistry, paymentRequestTTLMinutes)
+	listdonationsHandler := listdonations.New(db)
+	getdonationHandler := getdonation.New(db)
+	listeventsHandler := listevents.New(db)
+	getrevenuesplitsHandler := getrevenuesplits.New(policy)
+	setrevenuesplitsHandler := setrevenuesplits.New(db, policy)
+	createcollabgroupHandler := createcollabgroup.New(db)
+	listcollabgroupsHandler := listcollabgroups.New(db)
+	acceptcollabinviteHandler := acceptcollabinvite.New(db)
+	getdonationrulesHandler := getdonationrules.New(donationrulesRegistry, registry)
+	setdonationrulesHandler := setdonationrules.New(db, registry)
+	creategoalHandler := creategoal.New(db, registry, tracker)
+	listgoalsHandler := listgoals.New(db)
+	updategoalHandler := updategoal.New(db, registry)
+	deletegoalHandler := deletegoal.New(db)
+	getgoalHandler := getgoal.New(db)
+	startsubathonHandler := startsubathon.New(db, registry, subathonTracker, logrusAdapter)
+	updatesubathonHandler := updatesubathon.New(db, registry, subathonTracker, logrusAdapter)
+	stopsubathonHandler := stopsubathon.New(db, subathonTracker, logrusAdapter)
+	getsubathonHandler := getsubathon.New(db)
+	createpollHandler := createpoll.New(db, registry, pollsTracker)
+	listpollsHandler := listpolls.New(db)
+	closepollHandler := closepoll.New(db)
+	getpollsHandler := getpolls.New(db)
+	creategiveawayHandler := creategiveaway.New(db, registry)
+	listgiveawaysHandler := listgiveaways.New(db)
+	getgiveawayHandler := getgiveaway.New(db)
+	createchallengeHandler := createchallenge.New(db, registry, challengesTracker, logrusAdapter)
+	listchallengesHandler := listchallenges.New(db)
+	resolvechallengeHandler := resolvechallenge.New(db, challengesTracker, logrusAdapter)
+	getchallengesHandler := getchallenges.New(db)
+	refreshsessionHandler := refreshsession.New(sessionsManager)
+	logoutHandler := logout.New(sessionsManager)
+	logoutallHandler := logoutall.New(sessionsManager)
+	getjwksHandler := getjwks.New(manager)
+	store := apikeys.New(db)
+	createapikeyHandler := createapikey.New(store)
+	listapikeysHandler := listapikeys.New(store)
+	revokeapikeyHandler := revokeapikey.New(store)
+	sendtestalertHandler := sendtestalert.New(obsService)
 	handlers := router.Handlers{
 		DonationsAnalytics:       handler,
 		SetUserInfo:              setuserinfoHandler,
@@ -106,10 +322,47 @@ func InitializeServer(ctx context.Context) (*server.Server, error) {
 		DonationsHistory:         donationshistoryHandler,
 		GetDefaultObsSettings:    getdefaultobssettingsHandler,
 		UpdateDefaultObsSettings: updatedefaultobssettingsHandler,
-	}
-	routePrefix, err := environment.GetRoutePrefix()
-	if err != nil {
-		return nil, err
+		CreatePaymentRequest:     createpaymentrequestHandler,
+		GetPaymentRequest:        getpaymentrequestHandler,
+		BuildDonationTransaction: builddonationtransactionHandler,
+		ListDonations:            listdonationsHandler,
+		GetDonation:              getdonationHandler,
+		ListEvents:               listeventsHandler,
+		GetRevenueSplits:         getrevenuesplitsHandler,
+		SetRevenueSplits:         setrevenuesplitsHandler,
+		CreateCollabGroup:        createcollabgroupHandler,
+		ListCollabGroups:         listcollabgroupsHandler,
+		AcceptCollabInvite:       acceptcollabinviteHandler,
+		GetDonationRules:         getdonationrulesHandler,
+		SetDonationRules:         setdonationrulesHandler,
+		CreateGoal:               creategoalHandler,
+		ListGoals:                listgoalsHandler,
+		UpdateGoal:               updategoalHandler,
+		DeleteGoal:               deletegoalHandler,
+		GetGoal:                  getgoalHandler,
+		StartSubathon:            startsubathonHandler,
+		UpdateSubathon:           updatesubathonHandler,
+		StopSubathon:             stopsubathonHandler,
+		GetSubathon:              getsubathonHandler,
+		CreatePoll:               createpollHandler,
+		ListPolls:                listpollsHandler,
+		ClosePoll:                closepollHandler,
+		GetPolls:                 getpollsHandler,
+		CreateGiveaway:           creategiveawayHandler,
+		ListGiveaways:            listgiveawaysHandler,
+		GetGiveaway:              getgiveawayHandler,
+		CreateChallenge:          createchallengeHandler,
+		ListChallenges:           listchallengesHandler,
+		ResolveChallenge:         resolvechallengeHandler,
+		GetChallenges:            getchallengesHandler,
+		RefreshSession:           refreshsessionHandler,
+		Logout:                   logoutHandler,
+		LogoutAll:                logoutallHandler,
+		GetJwks:                  getjwksHandler,
+		CreateApiKey:             createapikeyHandler,
+		ListApiKeys:              listapikeysHandler,
+		RevokeApiKey:             revokeapikeyHandler,
+		SendTestAlert:            sendtestalertHandler,
 	}
 	swaggerPath, err := environment.GetSwaggerPath()
 	if err != nil {
@@ -120,11 +373,28 @@ func InitializeServer(ctx context.Context) (*server.Server, error) {
 		return nil, err
 	}
 	v := config.NewMiddlewares(appEnv, swaggerPath)
-	engine := config.NewEngine(handlers, routePrefix, swaggerPath, jwtSecret, logrusAdapter, v)
+	engine := config.NewEngine(handlers, routePrefix, swaggerPath, manager, sessionsManager, store, logrusAdapter, v)
 	httpListenPort, err := environment.GetHTTPListenPort()
 	if err != nil {
 		return nil, err
 	}
-	serverServer := config.NewServer(engine, httpListenPort)
+	runner := config.NewJobRunner(logrusAdapter, noncegenerationHandler, sessionsManager)
+	watcherMinAmounts, err := environment.GetWatcherMinAmounts()
+	if err != nil {
+		return nil, err
+	}
+	watcher, err := walletwatcher.New(db, rpcClient, verifier, registry, policy, obsService, valuer, tracker, subathonTracker, donationrulesRegistry, logrusAdapter, watcherPollIntervalSeconds, watcherMinAmounts)
+	if err != nil {
+		return nil, err
+	}
+	finalityCheckDelaySeconds, err := environment.GetFinalityCheckDelaySeconds()
+	if err != nil {
+		return nil, err
+	}
+	reconciler := finality.New(db, chainRegistry, subathonTracker, logrusAdapter, watcherPollIntervalSeconds, finalityCheckDelaySeconds)
+	closer := polls.NewCloser(db, pollsTracker, logrusAdapter, watcherPollIntervalSeconds)
+	drawer := giveaways.NewDrawer(db, rpcClient, logrusAdapter, watcherPollIntervalSeconds)
+	v2 := config.NewBackgroundTasks(runner, watcher, resolver, reconciler, closer, drawer)
+	serverServer := config.NewServer(engine, httpListenPort, v2)
 	return serverServer, nil
 }
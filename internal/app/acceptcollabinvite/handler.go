@@ -0,0 +1,57 @@
+package acceptcollabinvite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"twitch-crypto-donations/internal/pkg/collab"
+	"twitch-crypto-donations/internal/pkg/middleware"
+)
+
+type Database interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+type (
+	Request  = middleware.Request[struct{}]
+	Response = middleware.Response[struct{}]
+)
+
+type Handler struct {
+	db Database
+}
+
+func New(db Database) *Handler {
+	return &Handler{db: db}
+}
+
+func (h *Handler) Handle(_ context.Context, request Request) (*Response, error) {
+	address, exists := request.Context[middleware.AddressKey].(string)
+	if !exists || address == "" {
+		return &Response{StatusCode: http.StatusUnauthorized}, fmt.Errorf("jwt is not found or api middleware is failed")
+	}
+
+	const updateQuery = `
+		UPDATE collab_members
+		SET status = $3, joined_at = NOW()
+		WHERE group_id = $1 AND wallet = $2 AND status = $4;
+	`
+
+	result, err := h.db.Exec(updateQuery, request.PathParams["id"], address, string(collab.StatusAccepted), string(collab.StatusInvited))
+	if err != nil {
+		return nil, fmt.Errorf("failed to accept collab invitation: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to accept collab invitation: %w", err)
+	}
+
+	if affected == 0 {
+		return &Response{StatusCode: http.StatusNotFound}, errors.New("no pending collab invitation found")
+	}
+
+	return &Response{StatusCode: http.StatusOK}, nil
+}
@@ -0,0 +1,361 @@
+package builddonationtransaction
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	"twitch-crypto-donations/internal/pkg/amount"
+	"twitch-crypto-donations/internal/pkg/donationrules"
+	"twitch-crypto-donations/internal/pkg/donations"
+	"twitch-crypto-donations/internal/pkg/environment"
+	"twitch-crypto-donations/internal/pkg/middleware"
+	"twitch-crypto-donations/internal/pkg/mints"
+	"twitch-crypto-donations/internal/pkg/splits"
+	"twitch-crypto-donations/internal/pkg/txbuilder"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/google/uuid"
+)
+
+type Database interface {
+	QueryRow(query string, args ...any) *sql.Row
+	Exec(query string, args ...any) (sql.Result, error)
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+type MintRegistry interface {
+	BySymbol(symbol string) (mints.Mint, bool)
+}
+
+type TransactionBuilder interface {
+	Build(ctx context.Context, donation txbuilder.Donation) (*txbuilder.Transaction, error)
+}
+
+type RuleRegistry interface {
+	Check(receiver, currency string, units uint64, decimals uint8, features donationrules.Features) ([]donationrules.Violation, error)
+}
+
+type SplitPolicy interface {
+	Plan(receiver string, gross uint64) ([]splits.Leg, error)
+}
+
+type RequestBody struct {
+	SenderAddress  string  `json:"sender_address"`
+	Receiver       string  `json:"receiver"`
+	SenderUsername string  `json:"sender_username"`
+	Amount         string  `json:"amount"`
+	Currency       *string `json:"currency"`
+	Message        *string `json:"message"`
+	DurationMs     *int64  `json:"duration_ms"`
+
+	AlertEvent *AlertRequest `json:"alert_event"`
+	MediaEvent *MediaRequest `json:"media_event"`
+}
+
+type AlertRequest struct {
+	Enable            bool    `json:"enable"`
+	NotificationSound *string `json:"notification_sound"`
+	VoiceUrl          *string `json:"voice_url"`
+	ImageUrl          *string `json:"image_url"`
+	GifUrl            *string `json:"gif_url"`
+}
+
+type MediaRequest struct {
+	Enable     bool   `json:"enable"`
+	YoutubeUrl string `json:"youtube_url"`
+	StartTime  *int64 `json:"start_time"`
+	EndTime    *int64 `json:"end_time"`
+	AutoPlay   *bool  `json:"auto_play"`
+	Controls   *bool  `json:"controls"`
+	Mute       *bool  `json:"mute"`
+}
+
+type ResponseBody struct {
+	Transaction          string    `json:"transaction"`
+	DonationID           string    `json:"donation_id"`
+	Reference            string    `json:"reference"`
+	Amount               string    `json:"amount"`
+	FeeAmount            string    `json:"fee_amount"`
+	Currency             string    `json:"currency"`
+	Legs                 []Leg     `json:"legs"`
+	LastValidBlockHeight uint64    `json:"last_valid_block_height"`
+	ExpiresAt            time.Time `json:"expires_at"`
+}
+
+type Leg struct {
+	Recipient string `json:"recipient"`
+	Kind      string `json:"kind"`
+	Label     string `json:"label"`
+	Amount    string `json:"amount"`
+}
+
+type (
+	Request  = middleware.Request[RequestBody]
+	Response = middleware.Response[ResponseBody]
+)
+
+type Handler struct {
+	db         Database
+	mints      MintRegistry
+	builder    TransactionBuilder
+	splits     SplitPolicy
+	rules      RuleRegistry
+	expiration time.Duration
+}
+
+func New(
+	db Database,
+	mints MintRegistry,
+	builder TransactionBuilder,
+	splits SplitPolicy,
+	rules RuleRegistry,
+	ttl environment.PaymentRequestTTLMinutes,
+) *Handler {
+	return &Handler{
+		db:         db,
+		mints:      mints,
+		builder:    builder,
+		splits:     splits,
+		rules:      rules,
+		expiration: time.Duration(ttl) * time.Minute,
+	}
+}
+
+func (h *Handler) Handle(ctx context.Context, request Request) (*Response, error) {
+	sender, err := solana.PublicKeyFromBase58(request.Body.SenderAddress)
+	if err != nil {
+		return &Response{StatusCode: http.StatusBadRequest}, fmt.Errorf("invalid sender address: %w", err)
+	}
+
+	if _, err = solana.PublicKeyFromBase58(request.Body.Receiver); err != nil {
+		return &Response{StatusCode: http.StatusBadRequest}, fmt.Errorf("invalid receiver address: %w", err)
+	}
+
+	symbol := mints.NativeSymbol
+	if request.Body.Currency != nil {
+		symbol = *request.Body.Currency
+	}
+
+	mint, ok := h.mints.BySymbol(symbol)
+	if !ok {
+		return &Response{StatusCode: http.StatusBadRequest}, fmt.Errorf("unsupported currency %s", symbol)
+	}
+
+	units, err := amount.Parse(request.Body.Amount, mint.Decimals)
+	if err != nil || units == 0 {
+		return &Response{StatusCode: http.StatusBadRequest}, fmt.Errorf("invalid amount %q", request.Body.Amount)
+	}
+
+	registered, err := h.isRegistered(request.Body.Receiver)
+	if err != nil {
+		return nil, err
+	}
+
+	if !registered {
+		return &Response{StatusCode: http.StatusNotFound}, fmt.Errorf("receiver %s is not registered", request.Body.Receiver)
+	}
+
+	violations, err := h.rules.Check(request.Body.Receiver, mint.Symbol, units, mint.Decimals, features(request.Body))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(violations) > 0 {
+		return &Response{StatusCode: http.StatusBadRequest}, donationrules.Err(violations)
+	}
+
+	legs, err := h.splits.Plan(request.Body.Receiver, units)
+	if errors.Is(err, splits.ErrInvalidSplit) {
+		return &Response{StatusCode: http.StatusBadRequest}, fmt.Errorf("amount %q cannot be split: %w", request.Body.Amount, err)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	transfers, err := transferLegs(legs)
+	if err != nil {
+		return nil, err
+	}
+
+	privateKey, err := solana.NewRandomPrivateKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate reference: %w", err)
+	}
+	reference := privateKey.PublicKey()
+	donationID := uuid.NewString()
+
+	donation := txbuilder.Donation{
+		Sender:    sender,
+		Mint:      mint.Address,
+		Decimals:  mint.Decimals,
+		Legs:      transfers,
+		Memo:      donationID,
+		Reference: reference,
+	}
+
+	tx, err := h.builder.Build(ctx, donation)
+	if err != nil {
+		return nil, err
+	}
+
+	var mintAddress *string
+	if !mint.IsNative() {
+		address := mint.Address.String()
+		mintAddress = &address
+	}
+
+	response := ResponseBody{
+		Transaction:          tx.Payload,
+		DonationID:           donationID,
+		Reference:            reference.String(),
+		Amount:               amount.Format(units, mint.Decimals),
+		FeeAmount:            amount.Format(splits.Fee(legs), mint.Decimals),
+		Currency:             mint.Symbol,
+		Legs:                 make([]Leg, 0, len(legs)),
+		LastValidBlockHeight: tx.LastValidBlockHeight,
+		ExpiresAt:            time.Now().UTC().Add(h.expiration),
+	}
+
+	for _, leg := range legs {
+		response.Legs = append(response.Legs, Leg{
+			Recipient: leg.Recipient,
+			Kind:      string(leg.Kind),
+			Label:     leg.Label,
+			Amount:    amount.Format(leg.Amount, mint.Decimals),
+		})
+	}
+
+	if err = h.saveRequest(ctx, request.Body, response, units, mint, mintAddress, legs); err != nil {
+		return nil, err
+	}
+
+	return &Response{Body: response, StatusCode: http.StatusCreated}, nil
+}
+
+func transferLegs(legs []splits.Leg) ([]txbuilder.Leg, error) {
+	transfers := make([]txbuilder.Leg, 0, len(legs))
+	for _, leg := range legs {
+		recipient, err := solana.PublicKeyFromBase58(leg.Recipient)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s recipient %q: %w", leg.Kind, leg.Recipient, err)
+		}
+
+		transfers = append(transfers, txbuilder.Leg{Recipient: recipient, Amount: leg.Amount})
+	}
+
+	return transfers, nil
+}
+
+func (h *Handler) isRegistered(wallet string) (bool, error) {
+	const query = `SELECT EXISTS(SELECT 1 FROM users WHERE wallet = $1);`
+
+	var exists bool
+	if err := h.db.QueryRow(query, wallet).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to load receiver: %w", err)
+	}
+
+	return exists, nil
+}
+
+// features lists the overlay features the donation asks for, to be checked
+// against the receiver's donation rules.
+func features(body RequestBody) donationrules.Features {
+	alert := body.AlertEvent != nil && body.AlertEvent.Enable
+	features := donationrules.Features{
+		Alert: alert,
+		Voice: alert && body.AlertEvent.VoiceUrl != nil,
+		Media: body.MediaEvent != nil && body.MediaEvent.Enable,
+	}
+
+	if features.Media {
+		features.MediaSeconds = donationrules.MediaSeconds(body.MediaEvent.StartTime, body.MediaEvent.EndTime, body.DurationMs)
+	}
+
+	return features
+}
+
+func (h *Handler) saveRequest(
+	ctx context.Context,
+	body RequestBody,
+	response ResponseBody,
+	units uint64,
+	mint mints.Mint,
+	mintAddress *string,
+	legs []splits.Leg,
+) error {
+	alertEvent, err := marshalOptional(body.AlertEvent)
+	if err != nil {
+		return err
+	}
+
+	mediaEvent, err := marshalOptional(body.MediaEvent)
+	if err != nil {
+		return err
+	}
+
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	const insertQuery = `
+		INSERT INTO payment_requests
+		(reference, donation_id, receiver, sender_address, sender_username, amount, fee_amount, currency, mint, message, duration_ms, alert_event, media_event, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14);
+	`
+
+	_, err = tx.Exec(insertQuery,
+		response.Reference, response.DonationID,
+		body.Receiver, body.SenderAddress, body.SenderUsername,
+		response.Amount, response.FeeAmount, response.Currency, mintAddress,
+		body.Message, body.DurationMs,
+		alertEvent, mediaEvent,
+		response.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save donation: %w", err)
+	}
+
+	err = donations.Create(tx, donations.Donation{
+		ID:             response.DonationID,
+		Receiver:       body.Receiver,
+		SenderAddress:  &body.SenderAddress,
+		SenderUsername: body.SenderUsername,
+		Amount:         units,
+		Decimals:       mint.Decimals,
+		Currency:       response.Currency,
+	}, donations.StateIntentCreated, "unsigned donation transaction built")
+	if err != nil {
+		return err
+	}
+
+	if err = splits.Save(tx, response.DonationID, legs); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func marshalOptional[T any](value *T) (*string, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode donation options: %w", err)
+	}
+
+	encoded := string(data)
+	return &encoded, nil
+}
@@ -0,0 +1,60 @@
+package closepoll
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"twitch-crypto-donations/internal/pkg/middleware"
+	"twitch-crypto-donations/internal/pkg/polls"
+)
+
+type Database interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+type (
+	Request  = middleware.Request[struct{}]
+	Response = middleware.Response[struct{}]
+)
+
+type Handler struct {
+	db Database
+}
+
+func New(db Database) *Handler {
+	return &Handler{db: db}
+}
+
+// Handle closes an open poll ahead of its closing time. Its results are pushed
+// to the overlay like those of any poll that closed on schedule.
+func (h *Handler) Handle(_ context.Context, request Request) (*Response, error) {
+	address, exists := request.Context[middleware.AddressKey].(string)
+	if !exists || address == "" {
+		return &Response{StatusCode: http.StatusUnauthorized}, fmt.Errorf("jwt is not found or api middleware is failed")
+	}
+
+	pollID := request.PathParams["id"]
+
+	const updateQuery = `
+		UPDATE polls
+		SET closes_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND owner = $2 AND closes_at > NOW();
+	`
+
+	result, err := h.db.Exec(updateQuery, pollID, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to close poll: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to close poll: %w", err)
+	}
+
+	if affected == 0 {
+		return &Response{StatusCode: http.StatusNotFound}, fmt.Errorf("%w: no open poll %s", polls.ErrPollNotFound, pollID)
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,114 @@
+package createapikey
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"twitch-crypto-donations/internal/pkg/apikeys"
+	"twitch-crypto-donations/internal/pkg/middleware"
+)
+
+const maxNameLength = 100
+
+type KeyStore interface {
+	Create(owner, name string, scopes []string, expiresAt *time.Time) (*apikeys.Key, string, error)
+}
+
+type RequestBody struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type ResponseBody struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Key       string     `json:"key"`
+	Prefix    string     `json:"prefix"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type (
+	Request  = middleware.Request[RequestBody]
+	Response = middleware.Response[ResponseBody]
+)
+
+type Handler struct {
+	keys KeyStore
+}
+
+func New(keys KeyStore) *Handler {
+	return &Handler{keys: keys}
+}
+
+// Handle creates an API key for a bot or integration of the authenticated
+// streamer. The key itself is only returned here.
+func (h *Handler) Handle(_ context.Context, request Request) (*Response, error) {
+	address, exists := request.Context[middleware.AddressKey].(string)
+	if !exists || address == "" {
+		return &Response{StatusCode: http.StatusUnauthorized}, fmt.Errorf("jwt is not found or api middleware is failed")
+	}
+
+	name := strings.TrimSpace(request.Body.Name)
+	if name == "" || len([]rune(name)) > maxNameLength {
+		return &Response{StatusCode: http.StatusBadRequest}, fmt.Errorf("name must be between 1 and %d characters", maxNameLength)
+	}
+
+	scopes, err := parseScopes(request.Body.Scopes)
+	if err != nil {
+		return &Response{StatusCode: http.StatusBadRequest}, err
+	}
+
+	var expiresAt *time.Time
+	if request.Body.ExpiresAt != nil {
+		expiry := request.Body.ExpiresAt.UTC()
+		if !expiry.After(time.Now().UTC()) {
+			return &Response{StatusCode: http.StatusBadRequest}, fmt.Errorf("expires_at must be in the future")
+		}
+		expiresAt = &expiry
+	}
+
+	key, secret, err := h.keys.Create(address, name, scopes, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Response{
+		Body: ResponseBody{
+			ID:        key.ID,
+			Name:      key.Name,
+			Key:       secret,
+			Prefix:    key.Prefix,
+			Scopes:    key.Scopes,
+			ExpiresAt: key.ExpiresAt,
+			CreatedAt: key.CreatedAt,
+		},
+		StatusCode: http.StatusCreated,
+	}, nil
+}
+
+func parseScopes(requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return nil, fmt.Errorf("at least one scope is required, one of %s", strings.Join(apikeys.Scopes, ", "))
+	}
+
+	scopes := make([]string, 0, len(requested))
+	for _, scope := range requested {
+		if !slices.Contains(apikeys.Scopes, scope) {
+			return nil, fmt.Errorf("unknown scope %q, expected one of %s", scope, strings.Join(apikeys.Scopes, ", "))
+		}
+
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	slices.Sort(scopes)
+
+	return scopes, nil
+}
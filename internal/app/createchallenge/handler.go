@@ -0,0 +1,185 @@
+package createchallenge
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"twitch-crypto-donations/internal/pkg/amount"
+	"twitch-crypto-donations/internal/pkg/challenges"
+	"twitch-crypto-donations/internal/pkg/middleware"
+	"twitch-crypto-donations/internal/pkg/mints"
+
+	"github.com/google/uuid"
+)
+
+const (
+	maxTitleLength       = 200
+	maxDescriptionLength = 500
+)
+
+type Database interface {
+	QueryRow(query string, args ...any) *sql.Row
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+type MintRegistry interface {
+	BySymbol(symbol string) (mints.Mint, bool)
+}
+
+type ChallengeTracker interface {
+	FiatCurrencies() []string
+	Created(challenge challenges.Challenge) error
+}
+
+type Logger interface {
+	Info(msg string, ctx ...interface{})
+}
+
+type RequestBody struct {
+	Title           string  `json:"title"`
+	Description     *string `json:"description"`
+	ThresholdAmount string  `json:"threshold_amount"`
+	Currency        *string `json:"currency"`
+	FiatCurrency    *string `json:"fiat_currency"`
+}
+
+type ResponseBody struct {
+	ID              string     `json:"id"`
+	Title           string     `json:"title"`
+	Description     *string    `json:"description"`
+	Currency        *string    `json:"currency"`
+	FiatCurrency    *string    `json:"fiat_currency"`
+	ThresholdAmount string     `json:"threshold_amount"`
+	PledgedAmount   string     `json:"pledged_amount"`
+	Pledges         int64      `json:"pledges"`
+	Progress        float64    `json:"progress"`
+	Status          string     `json:"status"`
+	FundedAt        *time.Time `json:"funded_at"`
+	ResolvedAt      *time.Time `json:"resolved_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+type (
+	Request  = middleware.Request[RequestBody]
+	Response = middleware.Response[ResponseBody]
+)
+
+type Handler struct {
+	db         Database
+	mints      MintRegistry
+	challenges ChallengeTracker
+	logger     Logger
+}
+
+func New(db Database, mints MintRegistry, challenges ChallengeTracker, logger Logger) *Handler {
+	return &Handler{db: db, mints: mints, challenges: challenges, logger: logger}
+}
+
+// Handle creates a challenge viewers can pledge donations towards and
+// announces it on the streamer's overlay.
+func (h *Handler) Handle(_ context.Context, request Request) (*Response, error) {
+	address, exists := request.Context[middleware.AddressKey].(string)
+	if !exists || address == "" {
+		return &Response{StatusCode: http.StatusUnauthorized}, fmt.Errorf("jwt is not found or api middleware is failed")
+	}
+
+	challenge, err := h.parse(address, request.Body)
+	if err != nil {
+		return &Response{StatusCode: http.StatusBadRequest}, err
+	}
+
+	const insertQuery = `
+		INSERT INTO challenges (id, owner, title, description, currency, fiat_currency, threshold)
+		VALUES ($1, $2, $3, $4, $5, $6, $7);
+	`
+
+	_, err = h.db.Exec(insertQuery,
+		challenge.ID, challenge.Owner, challenge.Title, challenge.Description,
+		challenge.Currency, challenge.FiatCurrency, challenge.Threshold,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save challenge: %w", err)
+	}
+
+	saved, err := challenges.Find(h.db, challenge.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = h.challenges.Created(*saved); err != nil {
+		h.logger.Info("failed to announce challenge", "challenge", saved.ID, "error", err.Error())
+	}
+
+	return &Response{
+		Body: ResponseBody{
+			ID:              saved.ID,
+			Title:           saved.Title,
+			Description:     saved.Description,
+			Currency:        saved.Currency,
+			FiatCurrency:    saved.FiatCurrency,
+			ThresholdAmount: saved.Threshold,
+			PledgedAmount:   saved.Pledged,
+			Pledges:         saved.Pledges,
+			Progress:        saved.Percent(),
+			Status:          saved.Status,
+			FundedAt:        saved.FundedAt,
+			ResolvedAt:      saved.ResolvedAt,
+			CreatedAt:       saved.CreatedAt,
+		},
+		StatusCode: http.StatusCreated,
+	}, nil
+}
+
+func (h *Handler) parse(owner string, body RequestBody) (challenges.Challenge, error) {
+	challenge := challenges.Challenge{
+		ID:    uuid.NewString(),
+		Owner: owner,
+		Title: strings.TrimSpace(body.Title),
+	}
+
+	if challenge.Title == "" || len([]rune(challenge.Title)) > maxTitleLength {
+		return challenges.Challenge{}, fmt.Errorf("challenge title must be between 1 and %d characters", maxTitleLength)
+	}
+
+	if body.Description != nil {
+		description := strings.TrimSpace(*body.Description)
+		if len([]rune(description)) > maxDescriptionLength {
+			return challenges.Challenge{}, fmt.Errorf("challenge description must be at most %d characters", maxDescriptionLength)
+		}
+
+		if description != "" {
+			challenge.Description = &description
+		}
+	}
+
+	if (body.Currency == nil) == (body.FiatCurrency == nil) {
+		return challenges.Challenge{}, fmt.Errorf("exactly one of currency and fiat_currency is required")
+	}
+
+	decimals := uint8(challenges.FiatDecimals)
+	if body.Currency != nil {
+		mint, ok := h.mints.BySymbol(*body.Currency)
+		if !ok {
+			return challenges.Challenge{}, fmt.Errorf("unsupported currency %s", *body.Currency)
+		}
+		challenge.Currency, decimals = &mint.Symbol, mint.Decimals
+	} else {
+		fiat := strings.ToUpper(*body.FiatCurrency)
+		if !slices.Contains(h.challenges.FiatCurrencies(), fiat) {
+			return challenges.Challenge{}, fmt.Errorf("unsupported fiat currency %s", *body.FiatCurrency)
+		}
+		challenge.FiatCurrency = &fiat
+	}
+
+	threshold, err := amount.Parse(body.ThresholdAmount, decimals)
+	if err != nil || threshold == 0 {
+		return challenges.Challenge{}, fmt.Errorf("invalid threshold amount %q", body.ThresholdAmount)
+	}
+	challenge.Threshold = amount.Format(threshold, decimals)
+
+	return challenge, nil
+}
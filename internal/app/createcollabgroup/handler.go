@@ -0,0 +1,193 @@
+package createcollabgroup
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"twitch-crypto-donations/internal/pkg/collab"
+	"twitch-crypto-donations/internal/pkg/middleware"
+	"twitch-crypto-donations/internal/pkg/splits"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type Database interface {
+	QueryRow(query string, args ...any) *sql.Row
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+type RequestBody struct {
+	Name    string          `json:"name"`
+	Members []MemberRequest `json:"members"`
+}
+
+type MemberRequest struct {
+	Wallet string `json:"wallet"`
+	Bps    uint64 `json:"bps"`
+}
+
+type ResponseBody struct {
+	ID        string    `json:"id"`
+	Owner     string    `json:"owner"`
+	Name      string    `json:"name"`
+	Members   []Member  `json:"members"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Member struct {
+	Wallet string `json:"wallet"`
+	Bps    uint64 `json:"bps"`
+	Status string `json:"status"`
+}
+
+type (
+	Request  = middleware.Request[RequestBody]
+	Response = middleware.Response[ResponseBody]
+)
+
+type Handler struct {
+	db Database
+}
+
+func New(db Database) *Handler {
+	return &Handler{db: db}
+}
+
+// Handle creates a collab group owned by the authenticated streamer. The owner
+// joins immediately; every other member is invited and has to accept before the
+// group can take donations.
+func (h *Handler) Handle(ctx context.Context, request Request) (*Response, error) {
+	address, exists := request.Context[middleware.AddressKey].(string)
+	if !exists || address == "" {
+		return &Response{StatusCode: http.StatusUnauthorized}, fmt.Errorf("jwt is not found or api middleware is failed")
+	}
+
+	request.Body.Name = strings.TrimSpace(request.Body.Name)
+	if request.Body.Name == "" {
+		return &Response{StatusCode: http.StatusBadRequest}, fmt.Errorf("collab group name is required")
+	}
+
+	if err := validate(address, request.Body.Members); err != nil {
+		return &Response{StatusCode: http.StatusBadRequest}, err
+	}
+
+	wallets := make([]string, 0, len(request.Body.Members))
+	for _, m := range request.Body.Members {
+		wallets = append(wallets, m.Wallet)
+	}
+
+	registered, err := h.countRegistered(wallets)
+	if err != nil {
+		return nil, err
+	}
+
+	if registered != len(wallets) {
+		return &Response{StatusCode: http.StatusBadRequest}, fmt.Errorf("every collab group member must be a registered streamer")
+	}
+
+	response := ResponseBody{
+		ID:        uuid.NewString(),
+		Owner:     address,
+		Name:      request.Body.Name,
+		Members:   make([]Member, 0, len(request.Body.Members)),
+		CreatedAt: time.Now().UTC(),
+	}
+
+	for _, m := range request.Body.Members {
+		status := collab.StatusInvited
+		if m.Wallet == address {
+			status = collab.StatusAccepted
+		}
+		response.Members = append(response.Members, Member{Wallet: m.Wallet, Bps: m.Bps, Status: string(status)})
+	}
+
+	if err = h.save(ctx, response); err != nil {
+		return nil, err
+	}
+
+	return &Response{Body: response, StatusCode: http.StatusCreated}, nil
+}
+
+func validate(owner string, members []MemberRequest) error {
+	if len(members) < 2 || len(members) > collab.MaxMembers {
+		return fmt.Errorf("a collab group needs between 2 and %d members", collab.MaxMembers)
+	}
+
+	var total uint64
+	seen := make(map[string]struct{}, len(members))
+	for _, m := range members {
+		if _, err := solana.PublicKeyFromBase58(m.Wallet); err != nil {
+			return fmt.Errorf("invalid member wallet %q: %w", m.Wallet, err)
+		}
+
+		if _, ok := seen[m.Wallet]; ok {
+			return fmt.Errorf("member %s is listed more than once", m.Wallet)
+		}
+		seen[m.Wallet] = struct{}{}
+
+		if m.Bps == 0 || m.Bps > splits.MaxBps {
+			return fmt.Errorf("share of %d basis points for %s is out of range", m.Bps, m.Wallet)
+		}
+		total += m.Bps
+	}
+
+	if _, ok := seen[owner]; !ok {
+		return fmt.Errorf("the group owner must be one of its members")
+	}
+
+	if total != splits.MaxBps {
+		return fmt.Errorf("member shares add up to %d basis points instead of %d", total, splits.MaxBps)
+	}
+
+	return nil
+}
+
+func (h *Handler) countRegistered(wallets []string) (int, error) {
+	const query = `SELECT COUNT(*) FROM users WHERE wallet = ANY($1);`
+
+	var count int
+	if err := h.db.QueryRow(query, pq.Array(wallets)).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to load members: %w", err)
+	}
+
+	return count, nil
+}
+
+func (h *Handler) save(ctx context.Context, group ResponseBody) error {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	const groupQuery = `
+		INSERT INTO collab_groups (id, owner, name, created_at)
+		VALUES ($1, $2, $3, $4);
+	`
+
+	if _, err = tx.Exec(groupQuery, group.ID, group.Owner, group.Name, group.CreatedAt); err != nil {
+		return fmt.Errorf("failed to save collab group: %w", err)
+	}
+
+	const memberQuery = `
+		INSERT INTO collab_members (group_id, wallet, bps, status, joined_at)
+		VALUES ($1, $2, $3, $4, CASE WHEN $4 = 'accepted' THEN NOW() END);
+	`
+
+	for _, m := range group.Members {
+		if _, err = tx.Exec(memberQuery, group.ID, m.Wallet, m.Bps, m.Status); err != nil {
+			return fmt.Errorf("failed to save collab member: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
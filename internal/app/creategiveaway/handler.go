@@ -0,0 +1,169 @@
+package creategiveaway
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"twitch-crypto-donations/internal/pkg/giveaways"
+	"twitch-crypto-donations/internal/pkg/middleware"
+	"twitch-crypto-donations/internal/pkg/mints"
+
+	"github.com/google/uuid"
+)
+
+const (
+	maxTitleLength = 100
+	maxWinners     = 100
+)
+
+type Database interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+type MintRegistry interface {
+	BySymbol(symbol string) (mints.Mint, bool)
+}
+
+type RequestBody struct {
+	Title       string    `json:"title"`
+	Mode        string    `json:"mode"`
+	Currency    *string   `json:"currency"`
+	WindowStart time.Time `json:"window_start"`
+	WindowEnd   time.Time `json:"window_end"`
+	Winners     int       `json:"winners"`
+}
+
+type ResponseBody struct {
+	ID          string     `json:"id"`
+	Title       string     `json:"title"`
+	Mode        string     `json:"mode"`
+	Currency    *string    `json:"currency"`
+	WindowStart time.Time  `json:"window_start"`
+	WindowEnd   time.Time  `json:"window_end"`
+	WinnerCount int        `json:"winner_count"`
+	Status      string     `json:"status"`
+	Commitment  *string    `json:"commitment"`
+	TargetSlot  *uint64    `json:"target_slot"`
+	SeedSlot    *uint64    `json:"seed_slot"`
+	Seed        *string    `json:"seed"`
+	EntryCount  int        `json:"entry_count"`
+	Winners     []string   `json:"winners"`
+	CommittedAt *time.Time `json:"committed_at"`
+	DrawnAt     *time.Time `json:"drawn_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type (
+	Request  = middleware.Request[RequestBody]
+	Response = middleware.Response[ResponseBody]
+)
+
+type Handler struct {
+	db    Database
+	mints MintRegistry
+}
+
+func New(db Database, mints MintRegistry) *Handler {
+	return &Handler{db: db, mints: mints}
+}
+
+// Handle creates a giveaway among the wallets that donate to the authenticated
+// streamer within the window. Winners are drawn after the window ends.
+func (h *Handler) Handle(_ context.Context, request Request) (*Response, error) {
+	address, exists := request.Context[middleware.AddressKey].(string)
+	if !exists || address == "" {
+		return &Response{StatusCode: http.StatusUnauthorized}, fmt.Errorf("jwt is not found or api middleware is failed")
+	}
+
+	giveaway, err := h.parse(address, request.Body)
+	if err != nil {
+		return &Response{StatusCode: http.StatusBadRequest}, err
+	}
+
+	const insertQuery = `
+		INSERT INTO giveaways (id, owner, title, mode, currency, window_start, window_end, winner_count)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
+	`
+
+	_, err = h.db.Exec(insertQuery,
+		giveaway.ID, giveaway.Owner, giveaway.Title, giveaway.Mode, giveaway.Currency,
+		giveaway.WindowStart.UTC(), giveaway.WindowEnd.UTC(), giveaway.WinnerCount,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save giveaway: %w", err)
+	}
+
+	saved, err := giveaways.Find(h.db, giveaway.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Response{
+		Body: ResponseBody{
+			ID:          saved.ID,
+			Title:       saved.Title,
+			Mode:        saved.Mode,
+			Currency:    saved.Currency,
+			WindowStart: saved.WindowStart,
+			WindowEnd:   saved.WindowEnd,
+			WinnerCount: saved.WinnerCount,
+			Status:      saved.Status,
+			Commitment:  saved.Commitment,
+			TargetSlot:  saved.TargetSlot,
+			SeedSlot:    saved.SeedSlot,
+			Seed:        saved.Seed,
+			EntryCount:  saved.EntryCount,
+			Winners:     saved.Winners,
+			CommittedAt: saved.CommittedAt,
+			DrawnAt:     saved.DrawnAt,
+			CreatedAt:   saved.CreatedAt,
+		},
+		StatusCode: http.StatusCreated,
+	}, nil
+}
+
+func (h *Handler) parse(owner string, body RequestBody) (giveaways.Giveaway, error) {
+	giveaway := giveaways.Giveaway{
+		ID:          uuid.NewString(),
+		Owner:       owner,
+		Title:       strings.TrimSpace(body.Title),
+		Mode:        body.Mode,
+		WindowStart: body.WindowStart,
+		WindowEnd:   body.WindowEnd,
+		WinnerCount: body.Winners,
+	}
+
+	if giveaway.Title == "" || len([]rune(giveaway.Title)) > maxTitleLength {
+		return giveaways.Giveaway{}, fmt.Errorf("giveaway title must be between 1 and %d characters", maxTitleLength)
+	}
+
+	if giveaway.Mode != giveaways.ModeWeighted && giveaway.Mode != giveaways.ModeEqual {
+		return giveaways.Giveaway{}, fmt.Errorf("giveaway mode must be %s or %s", giveaways.ModeWeighted, giveaways.ModeEqual)
+	}
+
+	if body.Currency != nil {
+		mint, ok := h.mints.BySymbol(*body.Currency)
+		if !ok {
+			return giveaways.Giveaway{}, fmt.Errorf("unsupported currency %s", *body.Currency)
+		}
+		giveaway.Currency = &mint.Symbol
+	}
+
+	if giveaway.Mode == giveaways.ModeWeighted && giveaway.Currency == nil {
+		return giveaways.Giveaway{}, fmt.Errorf("weighted giveaways require a currency")
+	}
+
+	if giveaway.WindowStart.IsZero() || !giveaway.WindowEnd.After(giveaway.WindowStart) {
+		return giveaways.Giveaway{}, fmt.Errorf("giveaway window must end after it starts")
+	}
+
+	if giveaway.WinnerCount < 1 || giveaway.WinnerCount > maxWinners {
+		return giveaways.Giveaway{}, fmt.Errorf("giveaway must have between 1 and %d winners", maxWinners)
+	}
+
+	return giveaway, nil
+}
@@ -0,0 +1,189 @@
+package creategoal
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"twitch-crypto-donations/internal/pkg/amount"
+	"twitch-crypto-donations/internal/pkg/goals"
+	"twitch-crypto-donations/internal/pkg/middleware"
+	"twitch-crypto-donations/internal/pkg/mints"
+
+	"github.com/google/uuid"
+)
+
+const maxTitleLength = 100
+
+type Database interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+type MintRegistry interface {
+	BySymbol(symbol string) (mints.Mint, bool)
+}
+
+type GoalTracker interface {
+	FiatCurrencies() []string
+}
+
+type RequestBody struct {
+	Title        string     `json:"title"`
+	TargetAmount string     `json:"target_amount"`
+	Currency     *string    `json:"currency"`
+	FiatCurrency *string    `json:"fiat_currency"`
+	Deadline     *time.Time `json:"deadline"`
+	Active       bool       `json:"active"`
+}
+
+type ResponseBody struct {
+	ID           string     `json:"id"`
+	Title        string     `json:"title"`
+	Currency     *string    `json:"currency"`
+	FiatCurrency *string    `json:"fiat_currency"`
+	TargetAmount string     `json:"target_amount"`
+	RaisedAmount string     `json:"raised_amount"`
+	Progress     float64    `json:"progress"`
+	Deadline     *time.Time `json:"deadline"`
+	Active       bool       `json:"active"`
+	ReachedAt    *time.Time `json:"reached_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+type (
+	Request  = middleware.Request[RequestBody]
+	Response = middleware.Response[ResponseBody]
+)
+
+type Handler struct {
+	db    Database
+	mints MintRegistry
+	goals GoalTracker
+}
+
+func New(db Database, mints MintRegistry, goals GoalTracker) *Handler {
+	return &Handler{db: db, mints: mints, goals: goals}
+}
+
+// Handle creates a donation goal for the authenticated streamer. An active goal
+// replaces the streamer's current active goal.
+func (h *Handler) Handle(ctx context.Context, request Request) (*Response, error) {
+	address, exists := request.Context[middleware.AddressKey].(string)
+	if !exists || address == "" {
+		return &Response{StatusCode: http.StatusUnauthorized}, fmt.Errorf("jwt is not found or api middleware is failed")
+	}
+
+	goal, err := h.parse(address, request.Body)
+	if err != nil {
+		return &Response{StatusCode: http.StatusBadRequest}, err
+	}
+
+	saved, err := h.save(ctx, goal)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Response{
+		Body: ResponseBody{
+			ID:           saved.ID,
+			Title:        saved.Title,
+			Currency:     saved.Currency,
+			FiatCurrency: saved.FiatCurrency,
+			TargetAmount: saved.Target,
+			RaisedAmount: saved.Raised,
+			Progress:     saved.Percent(),
+			Deadline:     saved.Deadline,
+			Active:       saved.Active,
+			ReachedAt:    saved.ReachedAt,
+			CreatedAt:    saved.CreatedAt,
+		},
+		StatusCode: http.StatusCreated,
+	}, nil
+}
+
+func (h *Handler) parse(owner string, body RequestBody) (goals.Goal, error) {
+	goal := goals.Goal{
+		ID:       uuid.NewString(),
+		Owner:    owner,
+		Title:    strings.TrimSpace(body.Title),
+		Deadline: body.Deadline,
+		Active:   body.Active,
+	}
+
+	if goal.Title == "" || len([]rune(goal.Title)) > maxTitleLength {
+		return goals.Goal{}, fmt.Errorf("goal title must be between 1 and %d characters", maxTitleLength)
+	}
+
+	if (body.Currency == nil) == (body.FiatCurrency == nil) {
+		return goals.Goal{}, fmt.Errorf("exactly one of currency and fiat_currency is required")
+	}
+
+	decimals := uint8(goals.FiatDecimals)
+	if body.Currency != nil {
+		mint, ok := h.mints.BySymbol(*body.Currency)
+		if !ok {
+			return goals.Goal{}, fmt.Errorf("unsupported currency %s", *body.Currency)
+		}
+		goal.Currency, decimals = &mint.Symbol, mint.Decimals
+	} else {
+		fiat := strings.ToUpper(*body.FiatCurrency)
+		if !slices.Contains(h.goals.FiatCurrencies(), fiat) {
+			return goals.Goal{}, fmt.Errorf("unsupported fiat currency %s", *body.FiatCurrency)
+		}
+		goal.FiatCurrency = &fiat
+	}
+
+	target, err := amount.Parse(body.TargetAmount, decimals)
+	if err != nil || target == 0 {
+		return goals.Goal{}, fmt.Errorf("invalid target amount %q", body.TargetAmount)
+	}
+	goal.Target = amount.Format(target, decimals)
+
+	if goal.Deadline != nil && !goal.Deadline.After(time.Now()) {
+		return goals.Goal{}, fmt.Errorf("goal deadline must be in the future")
+	}
+
+	return goal, nil
+}
+
+func (h *Handler) save(ctx context.Context, goal goals.Goal) (*goals.Goal, error) {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if goal.Active {
+		const deactivateQuery = `UPDATE donation_goals SET active = FALSE, updated_at = NOW() WHERE owner = $1 AND active;`
+
+		if _, err = tx.Exec(deactivateQuery, goal.Owner); err != nil {
+			return nil, fmt.Errorf("failed to deactivate goals: %w", err)
+		}
+	}
+
+	const insertQuery = `
+		INSERT INTO donation_goals (id, owner, title, currency, fiat_currency, target, deadline, active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
+	`
+
+	_, err = tx.Exec(insertQuery,
+		goal.ID, goal.Owner, goal.Title, goal.Currency, goal.FiatCurrency, goal.Target, goal.Deadline, goal.Active,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save goal: %w", err)
+	}
+
+	saved, err := goals.Find(tx, goal.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return saved, nil
+}
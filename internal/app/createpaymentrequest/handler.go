@@ -0,0 +1,324 @@
+package createpaymentrequest
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	"twitch-crypto-donations/internal/pkg/amount"
+	"twitch-crypto-donations/internal/pkg/donationrules"
+	"twitch-crypto-donations/internal/pkg/donations"
+	"twitch-crypto-donations/internal/pkg/environment"
+	"twitch-crypto-donations/internal/pkg/middleware"
+	"twitch-crypto-donations/internal/pkg/mints"
+	"twitch-crypto-donations/internal/pkg/solanapay"
+	"twitch-crypto-donations/internal/pkg/splits"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/google/uuid"
+)
+
+type Database interface {
+	QueryRow(query string, args ...any) *sql.Row
+	Exec(query string, args ...any) (sql.Result, error)
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+type MintRegistry interface {
+	BySymbol(symbol string) (mints.Mint, bool)
+}
+
+type RuleRegistry interface {
+	Check(receiver, currency string, units uint64, decimals uint8, features donationrules.Features) ([]donationrules.Violation, error)
+}
+
+type SplitPolicy interface {
+	Plan(receiver string, gross uint64) ([]splits.Leg, error)
+}
+
+type RequestBody struct {
+	Receiver       string  `json:"receiver"`
+	SenderUsername string  `json:"sender_username"`
+	Amount         string  `json:"amount"`
+	Currency       *string `json:"currency"`
+	Message        *string `json:"message"`
+	DurationMs     *int64  `json:"duration_ms"`
+
+	AlertEvent *AlertRequest `json:"alert_event"`
+	MediaEvent *MediaRequest `json:"media_event"`
+}
+
+type AlertRequest struct {
+	Enable            bool    `json:"enable"`
+	NotificationSound *string `json:"notification_sound"`
+	VoiceUrl          *string `json:"voice_url"`
+	ImageUrl          *string `json:"image_url"`
+	GifUrl            *string `json:"gif_url"`
+}
+
+type MediaRequest struct {
+	Enable     bool   `json:"enable"`
+	YoutubeUrl string `json:"youtube_url"`
+	StartTime  *int64 `json:"start_time"`
+	EndTime    *int64 `json:"end_time"`
+	AutoPlay   *bool  `json:"auto_play"`
+	Controls   *bool  `json:"controls"`
+	Mute       *bool  `json:"mute"`
+}
+
+type ResponseBody struct {
+	Url        string    `json:"url"`
+	DonationID string    `json:"donation_id"`
+	Reference  string    `json:"reference"`
+	Recipient  string    `json:"recipient"`
+	Amount     string    `json:"amount"`
+	Currency   string    `json:"currency"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+type (
+	Request  = middleware.Request[RequestBody]
+	Response = middleware.Response[ResponseBody]
+)
+
+type Handler struct {
+	db         Database
+	mints      MintRegistry
+	splits     SplitPolicy
+	rules      RuleRegistry
+	expiration time.Duration
+	appName    string
+	splitRoute string
+}
+
+func New(
+	db Database,
+	mints MintRegistry,
+	splits SplitPolicy,
+	rules RuleRegistry,
+	ttl environment.PaymentRequestTTLMinutes,
+	routePrefix environment.RoutePrefix,
+) *Handler {
+	return &Handler{
+		db:         db,
+		mints:      mints,
+		splits:     splits,
+		rules:      rules,
+		expiration: time.Duration(ttl) * time.Minute,
+		appName:    "KapachiPay",
+		splitRoute: fmt.Sprintf("%s/donation-transactions", routePrefix),
+	}
+}
+
+func (h *Handler) Handle(ctx context.Context, request Request) (*Response, error) {
+	if _, err := solana.PublicKeyFromBase58(request.Body.Receiver); err != nil {
+		return &Response{StatusCode: http.StatusBadRequest}, fmt.Errorf("invalid receiver address: %w", err)
+	}
+
+	symbol := mints.NativeSymbol
+	if request.Body.Currency != nil {
+		symbol = *request.Body.Currency
+	}
+
+	mint, ok := h.mints.BySymbol(symbol)
+	if !ok {
+		return &Response{StatusCode: http.StatusBadRequest}, fmt.Errorf("unsupported currency %s", symbol)
+	}
+
+	units, err := amount.Parse(request.Body.Amount, mint.Decimals)
+	if err != nil || units == 0 {
+		return &Response{StatusCode: http.StatusBadRequest}, fmt.Errorf("invalid amount %q", request.Body.Amount)
+	}
+
+	displayName, err := h.getDisplayName(request.Body.Receiver)
+	if err != nil {
+		return nil, err
+	}
+
+	if displayName == "" {
+		return &Response{StatusCode: http.StatusNotFound}, fmt.Errorf("receiver %s is not registered", request.Body.Receiver)
+	}
+
+	violations, err := h.rules.Check(request.Body.Receiver, mint.Symbol, units, mint.Decimals, features(request.Body))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(violations) > 0 {
+		return &Response{StatusCode: http.StatusBadRequest}, donationrules.Err(violations)
+	}
+
+	// A Solana Pay transfer request names a single recipient, so donations that
+	// owe a platform fee or revenue splits have to go through a built transaction.
+	legs, err := h.splits.Plan(request.Body.Receiver, units)
+	if errors.Is(err, splits.ErrInvalidSplit) {
+		return &Response{StatusCode: http.StatusBadRequest}, fmt.Errorf("amount %q cannot be split: %w", request.Body.Amount, err)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(legs) > 1 {
+		return &Response{StatusCode: http.StatusBadRequest}, fmt.Errorf("receiver %s requires a split payment, use %s instead", request.Body.Receiver, h.splitRoute)
+	}
+
+	privateKey, err := solana.NewRandomPrivateKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate reference: %w", err)
+	}
+	reference := privateKey.PublicKey().String()
+
+	transferRequest := solanapay.TransferRequest{
+		Recipient: request.Body.Receiver,
+		Amount:    amount.Format(units, mint.Decimals),
+		Reference: reference,
+		Label:     h.appName,
+		Message:   fmt.Sprintf("Donation to %s", displayName),
+		Memo:      solanapay.TransferMemo,
+	}
+
+	var mintAddress *string
+	if !mint.IsNative() {
+		address := mint.Address.String()
+		mintAddress = &address
+		transferRequest.SplToken = address
+	}
+
+	donationID := uuid.NewString()
+	expiresAt := time.Now().UTC().Add(h.expiration)
+
+	err = h.saveRequest(ctx, request.Body, donationID, reference, units, mint, mintAddress, legs, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Response{
+		Body: ResponseBody{
+			Url:        transferRequest.URL(),
+			DonationID: donationID,
+			Reference:  reference,
+			Recipient:  request.Body.Receiver,
+			Amount:     transferRequest.Amount,
+			Currency:   mint.Symbol,
+			ExpiresAt:  expiresAt,
+		},
+		StatusCode: http.StatusCreated,
+	}, nil
+}
+
+func (h *Handler) getDisplayName(wallet string) (string, error) {
+	const query = `SELECT COALESCE(display_name, username, wallet) FROM users WHERE wallet = $1;`
+
+	var displayName string
+	err := h.db.QueryRow(query, wallet).Scan(&displayName)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("failed to load receiver: %w", err)
+	}
+
+	return displayName, nil
+}
+
+// features lists the overlay features the donation asks for, to be checked
+// against the receiver's donation rules.
+func features(body RequestBody) donationrules.Features {
+	alert := body.AlertEvent != nil && body.AlertEvent.Enable
+	features := donationrules.Features{
+		Alert: alert,
+		Voice: alert && body.AlertEvent.VoiceUrl != nil,
+		Media: body.MediaEvent != nil && body.MediaEvent.Enable,
+	}
+
+	if features.Media {
+		features.MediaSeconds = donationrules.MediaSeconds(body.MediaEvent.StartTime, body.MediaEvent.EndTime, body.DurationMs)
+	}
+
+	return features
+}
+
+func (h *Handler) saveRequest(
+	ctx context.Context,
+	body RequestBody,
+	donationID, reference string,
+	units uint64,
+	mint mints.Mint,
+	mintAddress *string,
+	legs []splits.Leg,
+	expiresAt time.Time,
+) error {
+	alertEvent, err := marshalOptional(body.AlertEvent)
+	if err != nil {
+		return err
+	}
+
+	mediaEvent, err := marshalOptional(body.MediaEvent)
+	if err != nil {
+		return err
+	}
+
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	const insertQuery = `
+		INSERT INTO payment_requests
+		(reference, donation_id, receiver, sender_username, amount, currency, mint, message, duration_ms, alert_event, media_event, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12);
+	`
+
+	_, err = tx.Exec(insertQuery,
+		reference, donationID, body.Receiver, body.SenderUsername,
+		amount.Format(units, mint.Decimals), mint.Symbol, mintAddress,
+		body.Message, body.DurationMs,
+		alertEvent, mediaEvent,
+		expiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save payment request: %w", err)
+	}
+
+	err = donations.Create(tx, donations.Donation{
+		ID:             donationID,
+		Receiver:       body.Receiver,
+		SenderUsername: body.SenderUsername,
+		Amount:         units,
+		Decimals:       mint.Decimals,
+		Currency:       mint.Symbol,
+	}, donations.StateIntentCreated, "solana pay transfer request created")
+	if err != nil {
+		return err
+	}
+
+	if err = splits.Save(tx, donationID, legs); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func marshalOptional[T any](value *T) (*string, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode donation options: %w", err)
+	}
+
+	encoded := string(data)
+	return &encoded, nil
+}
@@ -0,0 +1,217 @@
+package createpoll
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"twitch-crypto-donations/internal/pkg/middleware"
+	"twitch-crypto-donations/internal/pkg/mints"
+	"twitch-crypto-donations/internal/pkg/polls"
+
+	"github.com/google/uuid"
+)
+
+const (
+	maxTitleLength  = 200
+	maxLabelLength  = 100
+	minOptions      = 2
+	maxOptions      = 10
+	maxPollDuration = 7 * 24 * time.Hour
+)
+
+type Database interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+type MintRegistry interface {
+	BySymbol(symbol string) (mints.Mint, bool)
+}
+
+type PollTracker interface {
+	FiatCurrencies() []string
+}
+
+type RequestBody struct {
+	Title        string    `json:"title"`
+	Options      []string  `json:"options"`
+	Currency     *string   `json:"currency"`
+	FiatCurrency *string   `json:"fiat_currency"`
+	ClosesAt     time.Time `json:"closes_at"`
+}
+
+type ResponseBody struct {
+	ID           string    `json:"id"`
+	Title        string    `json:"title"`
+	Currency     *string   `json:"currency"`
+	FiatCurrency *string   `json:"fiat_currency"`
+	ClosesAt     time.Time `json:"closes_at"`
+	Closed       bool      `json:"closed"`
+	TotalAmount  string    `json:"total_amount"`
+	Options      []Option  `json:"options"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type Option struct {
+	ID      string  `json:"id"`
+	Label   string  `json:"label"`
+	Amount  string  `json:"amount"`
+	Votes   int64   `json:"votes"`
+	Percent float64 `json:"percent"`
+}
+
+type (
+	Request  = middleware.Request[RequestBody]
+	Response = middleware.Response[ResponseBody]
+)
+
+type Handler struct {
+	db    Database
+	mints MintRegistry
+	polls PollTracker
+}
+
+func New(db Database, mints MintRegistry, polls PollTracker) *Handler {
+	return &Handler{db: db, mints: mints, polls: polls}
+}
+
+// Handle creates a poll donors can vote in until it closes.
+func (h *Handler) Handle(ctx context.Context, request Request) (*Response, error) {
+	address, exists := request.Context[middleware.AddressKey].(string)
+	if !exists || address == "" {
+		return &Response{StatusCode: http.StatusUnauthorized}, fmt.Errorf("jwt is not found or api middleware is failed")
+	}
+
+	poll, err := h.parse(address, request.Body)
+	if err != nil {
+		return &Response{StatusCode: http.StatusBadRequest}, err
+	}
+
+	saved, err := h.save(ctx, poll)
+	if err != nil {
+		return nil, err
+	}
+
+	response := ResponseBody{
+		ID:           saved.ID,
+		Title:        saved.Title,
+		Currency:     saved.Currency,
+		FiatCurrency: saved.FiatCurrency,
+		ClosesAt:     saved.ClosesAt,
+		Closed:       saved.Closed(time.Now()),
+		TotalAmount:  saved.Total(),
+		Options:      make([]Option, 0, len(saved.Options)),
+		CreatedAt:    saved.CreatedAt,
+	}
+
+	for _, option := range saved.Options {
+		response.Options = append(response.Options, Option{
+			ID:      option.ID,
+			Label:   option.Label,
+			Amount:  option.Amount,
+			Votes:   option.Votes,
+			Percent: saved.Percent(option),
+		})
+	}
+
+	return &Response{Body: response, StatusCode: http.StatusCreated}, nil
+}
+
+func (h *Handler) parse(owner string, body RequestBody) (polls.Poll, error) {
+	poll := polls.Poll{
+		ID:       uuid.NewString(),
+		Owner:    owner,
+		Title:    strings.TrimSpace(body.Title),
+		ClosesAt: body.ClosesAt,
+		Options:  make([]polls.Option, 0, len(body.Options)),
+	}
+
+	if poll.Title == "" || len([]rune(poll.Title)) > maxTitleLength {
+		return polls.Poll{}, fmt.Errorf("poll title must be between 1 and %d characters", maxTitleLength)
+	}
+
+	if len(body.Options) < minOptions || len(body.Options) > maxOptions {
+		return polls.Poll{}, fmt.Errorf("poll must have between %d and %d options", minOptions, maxOptions)
+	}
+
+	seen := make(map[string]struct{}, len(body.Options))
+	for _, label := range body.Options {
+		label = strings.TrimSpace(label)
+		if label == "" || len([]rune(label)) > maxLabelLength {
+			return polls.Poll{}, fmt.Errorf("poll option must be between 1 and %d characters", maxLabelLength)
+		}
+
+		key := strings.ToLower(label)
+		if _, ok := seen[key]; ok {
+			return polls.Poll{}, fmt.Errorf("poll option %q is listed more than once", label)
+		}
+		seen[key] = struct{}{}
+
+		poll.Options = append(poll.Options, polls.Option{ID: uuid.NewString(), Label: label})
+	}
+
+	if (body.Currency == nil) == (body.FiatCurrency == nil) {
+		return polls.Poll{}, fmt.Errorf("exactly one of currency and fiat_currency is required")
+	}
+
+	if body.Currency != nil {
+		mint, ok := h.mints.BySymbol(*body.Currency)
+		if !ok {
+			return polls.Poll{}, fmt.Errorf("unsupported currency %s", *body.Currency)
+		}
+		poll.Currency = &mint.Symbol
+	} else {
+		fiat := strings.ToUpper(*body.FiatCurrency)
+		if !slices.Contains(h.polls.FiatCurrencies(), fiat) {
+			return polls.Poll{}, fmt.Errorf("unsupported fiat currency %s", *body.FiatCurrency)
+		}
+		poll.FiatCurrency = &fiat
+	}
+
+	now := time.Now()
+	if !poll.ClosesAt.After(now) || poll.ClosesAt.After(now.Add(maxPollDuration)) {
+		return polls.Poll{}, fmt.Errorf("poll must close in the future and within %s", maxPollDuration)
+	}
+
+	return poll, nil
+}
+
+func (h *Handler) save(ctx context.Context, poll polls.Poll) (*polls.Poll, error) {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	const insertQuery = `
+		INSERT INTO polls (id, owner, title, currency, fiat_currency, closes_at)
+		VALUES ($1, $2, $3, $4, $5, $6);
+	`
+
+	_, err = tx.Exec(insertQuery, poll.ID, poll.Owner, poll.Title, poll.Currency, poll.FiatCurrency, poll.ClosesAt.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to save poll: %w", err)
+	}
+
+	const optionQuery = `INSERT INTO poll_options (id, poll_id, position, label) VALUES ($1, $2, $3, $4);`
+
+	for position, option := range poll.Options {
+		if _, err = tx.Exec(optionQuery, option.ID, poll.ID, position, option.Label); err != nil {
+			return nil, fmt.Errorf("failed to save poll option: %w", err)
+		}
+	}
+
+	saved, err := polls.Find(tx, poll.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return saved, nil
+}
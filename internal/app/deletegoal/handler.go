@@ -0,0 +1,53 @@
+package deletegoal
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"twitch-crypto-donations/internal/pkg/middleware"
+)
+
+type Database interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+type (
+	Request  = middleware.Request[struct{}]
+	Response = middleware.Response[struct{}]
+)
+
+type Handler struct {
+	db Database
+}
+
+func New(db Database) *Handler {
+	return &Handler{db: db}
+}
+
+// Handle deletes one of the authenticated streamer's donation goals together
+// with its contributions. The donations themselves are kept.
+func (h *Handler) Handle(_ context.Context, request Request) (*Response, error) {
+	address, exists := request.Context[middleware.AddressKey].(string)
+	if !exists || address == "" {
+		return &Response{StatusCode: http.StatusUnauthorized}, fmt.Errorf("jwt is not found or api middleware is failed")
+	}
+
+	goalID := request.PathParams["id"]
+
+	result, err := h.db.Exec(`DELETE FROM donation_goals WHERE id = $1 AND owner = $2;`, goalID, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete goal: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete goal: %w", err)
+	}
+
+	if affected == 0 {
+		return &Response{StatusCode: http.StatusNotFound}, fmt.Errorf("goal %s not found", goalID)
+	}
+
+	return nil, nil
+}
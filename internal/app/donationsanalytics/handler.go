@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"time"
+	"twitch-crypto-donations/internal/pkg/amount"
 	"twitch-crypto-donations/internal/pkg/middleware"
 )
 
@@ -19,8 +20,13 @@ type Database interface {
 type Donation struct {
 	ReceiverAddress string    `json:"receiver_address"`
 	DonationAmount  string    `json:"donation_amount"`
+	AmountBaseUnits string    `json:"amount_base_units"`
+	Decimals        uint8     `json:"decimals"`
 	SenderUsername  string    `json:"sender_username"`
 	Currency        string    `json:"currency"`
+	UsdValue        *string   `json:"usd_value"`
+	FiatValue       *string   `json:"fiat_value"`
+	FiatCurrency    *string   `json:"fiat_currency"`
 	Text            *string   `json:"text"`
 	AudioUrl        *string   `json:"audio_url"`
 	ImageUrl        *string   `json:"image_url"`
@@ -30,10 +36,43 @@ type Donation struct {
 	CreatedAt       time.Time `json:"created_at"`
 }
 
+type Donor struct {
+	SenderUsername string `json:"sender_username"`
+	UsdValue       string `json:"usd_value"`
+	DonationCount  int64  `json:"donation_count"`
+}
+
+// Revenue splits the finalized donations in one currency into what donors sent,
+// what the platform kept, and what remained for the streamer and split recipients.
+type Revenue struct {
+	Currency      string `json:"currency"`
+	Decimals      uint8  `json:"decimals"`
+	Gross         string `json:"gross"`
+	PlatformFee   string `json:"platform_fee"`
+	Net           string `json:"net"`
+	Splits        string `json:"splits"`
+	Streamer      string `json:"streamer"`
+	DonationCount int64  `json:"donation_count"`
+}
+
+type RecipientRevenue struct {
+	Recipient       string `json:"recipient"`
+	Kind            string `json:"kind"`
+	Label           string `json:"label"`
+	Currency        string `json:"currency"`
+	Amount          string `json:"amount"`
+	AmountBaseUnits string `json:"amount_base_units"`
+	Decimals        uint8  `json:"decimals"`
+	DonationCount   int64  `json:"donation_count"`
+}
+
 type ResponseBody struct {
-	TopSingleDonations   []Donation `json:"top_single_donations"`
-	TopVolumeDonations   []Donation `json:"top_volume_donations"`
-	TopFrequentDonations []Donation `json:"top_frequent_donations"`
+	TopSingleDonations   []Donation         `json:"top_single_donations"`
+	TopVolumeDonations   []Donation         `json:"top_volume_donations"`
+	TopFrequentDonations []Donation         `json:"top_frequent_donations"`
+	TopDonorsByUsd       []Donor            `json:"top_donors_by_usd"`
+	Revenue              []Revenue          `json:"revenue"`
+	RevenueByRecipient   []RecipientRevenue `json:"revenue_by_recipient"`
 }
 
 type (
@@ -58,6 +97,9 @@ func (h *Handler) Handle(_ context.Context, request Request) (*Response, error)
 				TopSingleDonations:   []Donation{},
 				TopVolumeDonations:   []Donation{},
 				TopFrequentDonations: []Donation{},
+				TopDonorsByUsd:       []Donor{},
+				Revenue:              []Revenue{},
+				RevenueByRecipient:   []RecipientRevenue{},
 			},
 		}, fmt.Errorf("jwt is not found or api middleware is failed")
 	}
@@ -77,11 +119,29 @@ func (h *Handler) Handle(_ context.Context, request Request) (*Response, error)
 		return nil, err
 	}
 
+	topDonorsByUsd, err := h.getTopDonorsByUsd(address)
+	if err != nil {
+		return nil, err
+	}
+
+	revenue, err := h.getRevenue(address)
+	if err != nil {
+		return nil, err
+	}
+
+	revenueByRecipient, err := h.getRevenueByRecipient(address)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Response{
 		Body: ResponseBody{
 			TopSingleDonations:   topSingleDonations,
 			TopVolumeDonations:   topVolumeDonations,
 			TopFrequentDonations: topFrequentDonations,
+			TopDonorsByUsd:       topDonorsByUsd,
+			Revenue:              revenue,
+			RevenueByRecipient:   revenueByRecipient,
 		},
 		StatusCode: http.StatusOK,
 	}, nil
@@ -89,12 +149,15 @@ func (h *Handler) Handle(_ context.Context, request Request) (*Response, error)
 
 func (h *Handler) getTopSingleDonations(receiver string) ([]Donation, error) {
 	query := `
-        SELECT receiver, donation_amount, sender_username, 
-               currency, text, audio_url, image_url, duration_ms, 
-               layout, channel, created_at
-        FROM donations_history
-        WHERE receiver = $1
-        ORDER BY CAST(donation_amount AS DECIMAL) DESC
+        SELECT h.receiver, h.amount, h.decimals, h.sender_username, 
+               h.currency, d.usd_value, d.fiat_value, d.fiat_currency,
+               h.text, h.audio_url, h.image_url, h.duration_ms, 
+               h.layout, h.channel, h.created_at
+        FROM donations_history h
+        LEFT JOIN donations d ON d.id = h.donation_id
+        WHERE h.receiver = $1
+          AND (h.donation_id IS NULL OR d.finalized_at IS NOT NULL)
+        ORDER BY d.usd_value DESC NULLS LAST, h.amount / POWER(10::NUMERIC, h.decimals) DESC
         LIMIT 10
     `
 
@@ -109,21 +172,27 @@ func (h *Handler) getTopSingleDonations(receiver string) ([]Donation, error) {
 
 func (h *Handler) getTopVolumeDonations(receiver string) ([]Donation, error) {
 	query := `
-        SELECT receiver, 
-               SUM(CAST(donation_amount AS DECIMAL))::TEXT as donation_amount,
-               sender_username,
-               currency,
-               MAX(text) as text,
-               MAX(audio_url) as audio_url,
-               MAX(image_url) as image_url,
-               MAX(duration_ms) as duration_ms,
-               MAX(layout) as layout,
-               MAX(channel) as channel,
-               MAX(created_at) as created_at
-        FROM donations_history
-        WHERE receiver = $1
-        GROUP BY receiver, sender_username, currency
-        ORDER BY SUM(CAST(donation_amount AS DECIMAL)) DESC
+        SELECT h.receiver, 
+               SUM(h.amount) as amount,
+               h.decimals,
+               h.sender_username,
+               h.currency,
+               SUM(d.usd_value) as usd_value,
+               CASE WHEN COUNT(DISTINCT d.fiat_currency) = 1 THEN SUM(d.fiat_value) END as fiat_value,
+               CASE WHEN COUNT(DISTINCT d.fiat_currency) = 1 THEN MAX(d.fiat_currency) END as fiat_currency,
+               MAX(h.text) as text,
+               MAX(h.audio_url) as audio_url,
+               MAX(h.image_url) as image_url,
+               MAX(h.duration_ms) as duration_ms,
+               MAX(h.layout) as layout,
+               MAX(h.channel) as channel,
+               MAX(h.created_at) as created_at
+        FROM donations_history h
+        LEFT JOIN donations d ON d.id = h.donation_id
+        WHERE h.receiver = $1
+          AND (h.donation_id IS NULL OR d.finalized_at IS NOT NULL)
+        GROUP BY h.receiver, h.sender_username, h.currency, h.decimals
+        ORDER BY SUM(h.amount) / POWER(10::NUMERIC, h.decimals) DESC
         LIMIT 10
     `
 
@@ -138,20 +207,26 @@ func (h *Handler) getTopVolumeDonations(receiver string) ([]Donation, error) {
 
 func (h *Handler) getTopFrequentDonations(receiver string) ([]Donation, error) {
 	query := `
-        SELECT receiver,
-               SUM(CAST(donation_amount AS DECIMAL))::TEXT as donation_amount,
-               sender_username,
-               currency,
-               MAX(text) as text,
-               MAX(audio_url) as audio_url,
-               MAX(image_url) as image_url,
-               MAX(duration_ms) as duration_ms,
-               MAX(layout) as layout,
-               MAX(channel) as channel,
-               MAX(created_at) as created_at
-        FROM donations_history
-        WHERE receiver = $1
-        GROUP BY receiver, sender_username, currency
+        SELECT h.receiver,
+               SUM(h.amount) as amount,
+               h.decimals,
+               h.sender_username,
+               h.currency,
+               SUM(d.usd_value) as usd_value,
+               CASE WHEN COUNT(DISTINCT d.fiat_currency) = 1 THEN SUM(d.fiat_value) END as fiat_value,
+               CASE WHEN COUNT(DISTINCT d.fiat_currency) = 1 THEN MAX(d.fiat_currency) END as fiat_currency,
+               MAX(h.text) as text,
+               MAX(h.audio_url) as audio_url,
+               MAX(h.image_url) as image_url,
+               MAX(h.duration_ms) as duration_ms,
+               MAX(h.layout) as layout,
+               MAX(h.channel) as channel,
+               MAX(h.created_at) as created_at
+        FROM donations_history h
+        LEFT JOIN donations d ON d.id = h.donation_id
+        WHERE h.receiver = $1
+          AND (h.donation_id IS NULL OR d.finalized_at IS NOT NULL)
+        GROUP BY h.receiver, h.sender_username, h.currency, h.decimals
         ORDER BY COUNT(*) DESC
         LIMIT 10
     `
@@ -165,15 +240,138 @@ func (h *Handler) getTopFrequentDonations(receiver string) ([]Donation, error) {
 	return scanDonations(rows)
 }
 
+// getTopDonorsByUsd ranks senders across every currency by the USD value the
+// donations were stamped with when they were confirmed. Like the other rankings
+// it only counts donations whose transaction has been finalized.
+func (h *Handler) getTopDonorsByUsd(receiver string) ([]Donor, error) {
+	query := `
+        SELECT sender_username, SUM(usd_value)::TEXT, COUNT(*)
+        FROM donations
+        WHERE receiver = $1 AND usd_value IS NOT NULL AND finalized_at IS NOT NULL
+        GROUP BY sender_username
+        ORDER BY SUM(usd_value) DESC
+        LIMIT 10
+    `
+
+	rows, err := h.db.Query(query, receiver)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query top donors by usd: %w", err)
+	}
+	defer rows.Close()
+
+	donors := make([]Donor, 0, 10)
+	for rows.Next() {
+		var d Donor
+		if err = rows.Scan(&d.SenderUsername, &d.UsdValue, &d.DonationCount); err != nil {
+			return nil, fmt.Errorf("failed to scan donor: %w", err)
+		}
+		donors = append(donors, d)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return donors, nil
+}
+
+// getRevenue totals the recorded transfer legs of finalized donations per currency.
+// Net is the gross amount less the platform fee, before revenue splits.
+func (h *Handler) getRevenue(receiver string) ([]Revenue, error) {
+	query := `
+        SELECT d.currency, d.decimals,
+               SUM(l.amount)::TEXT,
+               COALESCE(SUM(l.amount) FILTER (WHERE l.kind = 'platform_fee'), 0)::TEXT,
+               COALESCE(SUM(l.amount) FILTER (WHERE l.kind <> 'platform_fee'), 0)::TEXT,
+               COALESCE(SUM(l.amount) FILTER (WHERE l.kind = 'split'), 0)::TEXT,
+               COALESCE(SUM(l.amount) FILTER (WHERE l.kind = 'streamer'), 0)::TEXT,
+               COUNT(DISTINCT d.id)
+        FROM donation_legs l
+        JOIN donations d ON d.id = l.donation_id
+        WHERE d.receiver = $1 AND d.finalized_at IS NOT NULL
+        GROUP BY d.currency, d.decimals
+        ORDER BY d.currency
+    `
+
+	rows, err := h.db.Query(query, receiver)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query revenue: %w", err)
+	}
+	defer rows.Close()
+
+	revenue := make([]Revenue, 0, 2)
+	for rows.Next() {
+		var r Revenue
+		err = rows.Scan(&r.Currency, &r.Decimals, &r.Gross, &r.PlatformFee, &r.Net, &r.Splits, &r.Streamer, &r.DonationCount)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan revenue: %w", err)
+		}
+
+		for _, value := range []*string{&r.Gross, &r.PlatformFee, &r.Net, &r.Splits, &r.Streamer} {
+			if *value, err = amount.FormatNumeric(*value, r.Decimals); err != nil {
+				return nil, err
+			}
+		}
+
+		revenue = append(revenue, r)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return revenue, nil
+}
+
+func (h *Handler) getRevenueByRecipient(receiver string) ([]RecipientRevenue, error) {
+	query := `
+        SELECT l.recipient, l.kind, MAX(l.label), d.currency, d.decimals,
+               SUM(l.amount)::TEXT, COUNT(DISTINCT d.id)
+        FROM donation_legs l
+        JOIN donations d ON d.id = l.donation_id
+        WHERE d.receiver = $1 AND d.finalized_at IS NOT NULL
+        GROUP BY l.recipient, l.kind, d.currency, d.decimals
+        ORDER BY d.currency, SUM(l.amount) DESC
+    `
+
+	rows, err := h.db.Query(query, receiver)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query revenue by recipient: %w", err)
+	}
+	defer rows.Close()
+
+	recipients := make([]RecipientRevenue, 0, 4)
+	for rows.Next() {
+		var r RecipientRevenue
+		err = rows.Scan(&r.Recipient, &r.Kind, &r.Label, &r.Currency, &r.Decimals, &r.AmountBaseUnits, &r.DonationCount)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan recipient revenue: %w", err)
+		}
+
+		if r.Amount, err = amount.FormatNumeric(r.AmountBaseUnits, r.Decimals); err != nil {
+			return nil, err
+		}
+
+		recipients = append(recipients, r)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return recipients, nil
+}
+
 func scanDonations(rows *sql.Rows) ([]Donation, error) {
 	var donations []Donation
 
 	for rows.Next() {
 		var d Donation
 		err := rows.Scan(
-			&d.ReceiverAddress, &d.DonationAmount,
-			&d.SenderUsername,
-			&d.Currency, &d.Text,
+			&d.ReceiverAddress, &d.AmountBaseUnits, &d.Decimals,
+			&d.SenderUsername, &d.Currency,
+			&d.UsdValue, &d.FiatValue, &d.FiatCurrency,
+			&d.Text,
 			&d.AudioUrl, &d.ImageUrl,
 			&d.DurationMs, &d.Layout,
 			&d.Channel, &d.CreatedAt,
@@ -181,6 +379,12 @@ func scanDonations(rows *sql.Rows) ([]Donation, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan donation: %w", err)
 		}
+
+		d.DonationAmount, err = amount.FormatNumeric(d.AmountBaseUnits, d.Decimals)
+		if err != nil {
+			return nil, err
+		}
+
 		donations = append(donations, d)
 	}
 
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"time"
+	"twitch-crypto-donations/internal/pkg/amount"
 	"twitch-crypto-donations/internal/pkg/middleware"
 )
 
@@ -17,15 +18,22 @@ type Database interface {
 }
 
 type ResponseBody struct {
-	Donations   []Donation `json:"donations"`
-	TotalAmount int64      `json:"amount"`
+	Donations     []Donation `json:"donations"`
+	TotalAmount   int64      `json:"amount"`
+	TotalUsdValue string     `json:"total_usd_value"`
 }
 
 type Donation struct {
 	ReceiverAddress string    `json:"receiver_address"`
 	DonationAmount  string    `json:"donation_amount"`
+	AmountBaseUnits string    `json:"amount_base_units"`
+	Decimals        uint8     `json:"decimals"`
 	SenderUsername  string    `json:"sender_username"`
 	Currency        string    `json:"currency"`
+	UsdValue        *string   `json:"usd_value"`
+	FiatValue       *string   `json:"fiat_value"`
+	FiatCurrency    *string   `json:"fiat_currency"`
+	Finalized       bool      `json:"finalized"`
 	Text            *string   `json:"text"`
 	AudioUrl        *string   `json:"audio_url"`
 	ImageUrl        *string   `json:"image_url"`
@@ -53,7 +61,7 @@ func (h *Handler) Handle(_ context.Context, request Request) (*Response, error)
 	if !exists || address == "" {
 		return &Response{
 			StatusCode: http.StatusUnauthorized,
-			Body:       ResponseBody{Donations: []Donation{}, TotalAmount: 0},
+			Body:       ResponseBody{Donations: []Donation{}, TotalAmount: 0, TotalUsdValue: "0"},
 		}, fmt.Errorf("jwt is not found or api middleware is failed")
 	}
 
@@ -62,8 +70,13 @@ func (h *Handler) Handle(_ context.Context, request Request) (*Response, error)
 		return nil, err
 	}
 
+	totalUsdValue, err := h.getTotalUsdValue(address)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Response{
-		Body:       ResponseBody{Donations: donationsHistory, TotalAmount: totalAmount},
+		Body:       ResponseBody{Donations: donationsHistory, TotalAmount: totalAmount, TotalUsdValue: totalUsdValue},
 		StatusCode: http.StatusOK,
 	}, nil
 }
@@ -71,11 +84,14 @@ func (h *Handler) Handle(_ context.Context, request Request) (*Response, error)
 func (h *Handler) getDonationsHistory(address string) ([]Donation, int64, error) {
 	query := `
         SELECT 
-            receiver, donation_amount, sender_username, currency, 
-            text, audio_url, image_url, duration_ms, layout, channel, created_at
-        FROM donations_history
-        WHERE receiver = $1
-        ORDER BY created_at DESC`
+            h.receiver, h.amount, h.decimals, h.sender_username, h.currency, 
+            d.usd_value, d.fiat_value, d.fiat_currency,
+            (h.donation_id IS NULL OR d.finalized_at IS NOT NULL) AS finalized,
+            h.text, h.audio_url, h.image_url, h.duration_ms, h.layout, h.channel, h.created_at
+        FROM donations_history h
+        LEFT JOIN donations d ON d.id = h.donation_id
+        WHERE h.receiver = $1
+        ORDER BY h.created_at DESC`
 
 	rows, err := h.db.Query(query, address)
 	if err != nil {
@@ -91,9 +107,10 @@ func (h *Handler) getDonationsHistory(address string) ([]Donation, int64, error)
 		var d Donation
 
 		err = rows.Scan(
-			&d.ReceiverAddress, &d.DonationAmount,
+			&d.ReceiverAddress, &d.AmountBaseUnits, &d.Decimals,
 			&d.SenderUsername, &d.Currency,
-			&d.Text, &d.AudioUrl, &d.ImageUrl,
+			&d.UsdValue, &d.FiatValue, &d.FiatCurrency,
+			&d.Finalized, &d.Text, &d.AudioUrl, &d.ImageUrl,
 			&d.DurationMs, &d.Layout,
 			&d.Channel, &d.CreatedAt,
 		)
@@ -101,6 +118,11 @@ func (h *Handler) getDonationsHistory(address string) ([]Donation, int64, error)
 			return nil, 0, fmt.Errorf("failed to scan row: %w", err)
 		}
 
+		d.DonationAmount, err = amount.FormatNumeric(d.AmountBaseUnits, d.Decimals)
+		if err != nil {
+			return nil, 0, err
+		}
+
 		donations = append(donations, d)
 	}
 
@@ -110,3 +132,19 @@ func (h *Handler) getDonationsHistory(address string) ([]Donation, int64, error)
 
 	return donations, totalAmount, nil
 }
+
+func (h *Handler) getTotalUsdValue(address string) (string, error) {
+	query := `
+        SELECT COALESCE(SUM(usd_value), 0)::TEXT
+        FROM donations
+        WHERE receiver = $1
+          AND finalized_at IS NOT NULL
+          AND id IN (SELECT donation_id FROM donations_history WHERE receiver = $1)`
+
+	var total string
+	if err := h.db.QueryRow(query, address).Scan(&total); err != nil {
+		return "", fmt.Errorf("failed to load total usd value: %w", err)
+	}
+
+	return total, nil
+}
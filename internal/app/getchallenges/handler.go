@@ -0,0 +1,85 @@
+package getchallenges
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+	"twitch-crypto-donations/internal/pkg/challenges"
+	"twitch-crypto-donations/internal/pkg/middleware"
+)
+
+const maxChallenges = 20
+
+type Database interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+type ResponseBody struct {
+	Challenges []Challenge `json:"challenges"`
+}
+
+type Challenge struct {
+	ID              string     `json:"id"`
+	Title           string     `json:"title"`
+	Description     *string    `json:"description"`
+	Currency        *string    `json:"currency"`
+	FiatCurrency    *string    `json:"fiat_currency"`
+	ThresholdAmount string     `json:"threshold_amount"`
+	PledgedAmount   string     `json:"pledged_amount"`
+	Pledges         int64      `json:"pledges"`
+	Progress        float64    `json:"progress"`
+	Status          string     `json:"status"`
+	FundedAt        *time.Time `json:"funded_at"`
+	ResolvedAt      *time.Time `json:"resolved_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+type (
+	Request  = middleware.Request[struct{}]
+	Response = middleware.Response[ResponseBody]
+)
+
+type Handler struct {
+	db Database
+}
+
+func New(db Database) *Handler {
+	return &Handler{db: db}
+}
+
+// Handle returns the streamer's open challenges followed by the most recently
+// resolved ones.
+func (h *Handler) Handle(_ context.Context, request Request) (*Response, error) {
+	username, ok := request.PathParams["username"]
+	if !ok {
+		return nil, errors.New("username is required")
+	}
+
+	list, err := challenges.ListByUsername(h.db, username, maxChallenges)
+	if err != nil {
+		return nil, err
+	}
+
+	response := ResponseBody{Challenges: make([]Challenge, 0, len(list))}
+	for _, challenge := range list {
+		response.Challenges = append(response.Challenges, Challenge{
+			ID:              challenge.ID,
+			Title:           challenge.Title,
+			Description:     challenge.Description,
+			Currency:        challenge.Currency,
+			FiatCurrency:    challenge.FiatCurrency,
+			ThresholdAmount: challenge.Threshold,
+			PledgedAmount:   challenge.Pledged,
+			Pledges:         challenge.Pledges,
+			Progress:        challenge.Percent(),
+			Status:          challenge.Status,
+			FundedAt:        challenge.FundedAt,
+			ResolvedAt:      challenge.ResolvedAt,
+			CreatedAt:       challenge.CreatedAt,
+		})
+	}
+
+	return &Response{Body: response, StatusCode: http.StatusOK}, nil
+}
@@ -0,0 +1,126 @@
+package getdonation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	"twitch-crypto-donations/internal/pkg/amount"
+	"twitch-crypto-donations/internal/pkg/middleware"
+)
+
+type Database interface {
+	QueryRow(query string, args ...any) *sql.Row
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+type ResponseBody struct {
+	ID              string       `json:"id"`
+	SenderAddress   *string      `json:"sender_address"`
+	SenderUsername  string       `json:"sender_username"`
+	Amount          string       `json:"amount"`
+	AmountBaseUnits string       `json:"amount_base_units"`
+	Decimals        uint8        `json:"decimals"`
+	Currency        string       `json:"currency"`
+	UsdValue        *string      `json:"usd_value"`
+	FiatValue       *string      `json:"fiat_value"`
+	FiatCurrency    *string      `json:"fiat_currency"`
+	TxSignature     *string      `json:"tx_signature"`
+	State           string       `json:"state"`
+	FinalizedAt     *time.Time   `json:"finalized_at"`
+	CreatedAt       time.Time    `json:"created_at"`
+	UpdatedAt       time.Time    `json:"updated_at"`
+	Transitions     []Transition `json:"transitions"`
+}
+
+type Transition struct {
+	FromState *string   `json:"from_state"`
+	ToState   string    `json:"to_state"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type (
+	Request  = middleware.Request[struct{}]
+	Response = middleware.Response[ResponseBody]
+)
+
+type Handler struct {
+	db Database
+}
+
+func New(db Database) *Handler {
+	return &Handler{db: db}
+}
+
+func (h *Handler) Handle(_ context.Context, request Request) (*Response, error) {
+	address, exists := request.Context[middleware.AddressKey].(string)
+	if !exists || address == "" {
+		return &Response{StatusCode: http.StatusUnauthorized}, fmt.Errorf("jwt is not found or api middleware is failed")
+	}
+
+	const query = `
+		SELECT id, sender_address, sender_username, amount, decimals, currency, usd_value, fiat_value, fiat_currency, tx_signature, state, finalized_at, created_at, updated_at
+		FROM donations
+		WHERE id = $1 AND receiver = $2;
+	`
+
+	var body ResponseBody
+	err := h.db.QueryRow(query, request.PathParams["id"], address).Scan(
+		&body.ID, &body.SenderAddress, &body.SenderUsername,
+		&body.AmountBaseUnits, &body.Decimals, &body.Currency,
+		&body.UsdValue, &body.FiatValue, &body.FiatCurrency, &body.TxSignature,
+		&body.State, &body.FinalizedAt, &body.CreatedAt, &body.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &Response{StatusCode: http.StatusNotFound}, errors.New("donation not found")
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to load donation: %w", err)
+	}
+
+	body.Amount, err = amount.FormatNumeric(body.AmountBaseUnits, body.Decimals)
+	if err != nil {
+		return nil, err
+	}
+
+	body.Transitions, err = h.getTransitions(body.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Response{Body: body, StatusCode: http.StatusOK}, nil
+}
+
+func (h *Handler) getTransitions(donationID string) ([]Transition, error) {
+	const query = `
+		SELECT from_state, to_state, reason, created_at
+		FROM donation_transitions
+		WHERE donation_id = $1
+		ORDER BY id;
+	`
+
+	rows, err := h.db.Query(query, donationID)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	transitions := make([]Transition, 0, 4)
+	for rows.Next() {
+		var t Transition
+		if err = rows.Scan(&t.FromState, &t.ToState, &t.Reason, &t.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		transitions = append(transitions, t)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return transitions, nil
+}
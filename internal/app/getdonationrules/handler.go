@@ -0,0 +1,78 @@
+package getdonationrules
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"twitch-crypto-donations/internal/pkg/amount"
+	"twitch-crypto-donations/internal/pkg/donationrules"
+	"twitch-crypto-donations/internal/pkg/middleware"
+	"twitch-crypto-donations/internal/pkg/mints"
+)
+
+type RuleRegistry interface {
+	Rules(receiver string) ([]donationrules.Rules, error)
+}
+
+type MintRegistry interface {
+	BySymbol(symbol string) (mints.Mint, bool)
+}
+
+type ResponseBody struct {
+	Rules []Rule `json:"rules"`
+}
+
+type Rule struct {
+	Currency            string `json:"currency"`
+	MinAlert            string `json:"min_alert"`
+	MinVoice            string `json:"min_voice"`
+	MinMedia            string `json:"min_media"`
+	MediaPricePerSecond string `json:"media_price_per_second"`
+}
+
+type (
+	Request  = middleware.Request[struct{}]
+	Response = middleware.Response[ResponseBody]
+)
+
+type Handler struct {
+	rules RuleRegistry
+	mints MintRegistry
+}
+
+func New(rules RuleRegistry, mints MintRegistry) *Handler {
+	return &Handler{rules: rules, mints: mints}
+}
+
+// Handle returns the streamer's donation rules so the donation page can show
+// what each overlay feature costs. Rules of currencies no longer accepted are
+// left out.
+func (h *Handler) Handle(_ context.Context, request Request) (*Response, error) {
+	address, ok := request.PathParams["address"]
+	if !ok {
+		return nil, errors.New("address is required")
+	}
+
+	rules, err := h.rules.Rules(address)
+	if err != nil {
+		return nil, err
+	}
+
+	response := ResponseBody{Rules: make([]Rule, 0, len(rules))}
+	for _, rule := range rules {
+		mint, ok := h.mints.BySymbol(rule.Currency)
+		if !ok {
+			continue
+		}
+
+		response.Rules = append(response.Rules, Rule{
+			Currency:            rule.Currency,
+			MinAlert:            amount.Format(rule.MinAlert, mint.Decimals),
+			MinVoice:            amount.Format(rule.MinVoice, mint.Decimals),
+			MinMedia:            amount.Format(rule.MinMedia, mint.Decimals),
+			MediaPricePerSecond: amount.Format(rule.MediaPricePerSecond, mint.Decimals),
+		})
+	}
+
+	return &Response{Body: response, StatusCode: http.StatusOK}, nil
+}
@@ -0,0 +1,104 @@
+package getgiveaway
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+	"twitch-crypto-donations/internal/pkg/giveaways"
+	"twitch-crypto-donations/internal/pkg/middleware"
+)
+
+type Database interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// ResponseBody carries everything needed to re-run the draw: the committed
+// entries, their commitment, the seed and the algorithm name.
+type ResponseBody struct {
+	ID          string     `json:"id"`
+	Title       string     `json:"title"`
+	Mode        string     `json:"mode"`
+	Currency    *string    `json:"currency"`
+	WindowStart time.Time  `json:"window_start"`
+	WindowEnd   time.Time  `json:"window_end"`
+	WinnerCount int        `json:"winner_count"`
+	Status      string     `json:"status"`
+	Algorithm   string     `json:"algorithm"`
+	Commitment  *string    `json:"commitment"`
+	TargetSlot  *uint64    `json:"target_slot"`
+	SeedSlot    *uint64    `json:"seed_slot"`
+	Seed        *string    `json:"seed"`
+	Entries     []Entry    `json:"entries"`
+	Winners     []string   `json:"winners"`
+	CommittedAt *time.Time `json:"committed_at"`
+	DrawnAt     *time.Time `json:"drawn_at"`
+}
+
+type Entry struct {
+	Address string `json:"address"`
+	Weight  string `json:"weight"`
+}
+
+type (
+	Request  = middleware.Request[struct{}]
+	Response = middleware.Response[ResponseBody]
+)
+
+type Handler struct {
+	db Database
+}
+
+func New(db Database) *Handler {
+	return &Handler{db: db}
+}
+
+// Handle returns a giveaway with its committed entries and draw, so anyone can
+// verify the winners.
+func (h *Handler) Handle(_ context.Context, request Request) (*Response, error) {
+	giveawayID, ok := request.PathParams["id"]
+	if !ok {
+		return nil, errors.New("giveaway id is required")
+	}
+
+	giveaway, err := giveaways.Find(h.db, giveawayID)
+	if errors.Is(err, giveaways.ErrGiveawayNotFound) {
+		return &Response{StatusCode: http.StatusNotFound}, err
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := giveaways.Entries(h.db, giveaway.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := ResponseBody{
+		ID:          giveaway.ID,
+		Title:       giveaway.Title,
+		Mode:        giveaway.Mode,
+		Currency:    giveaway.Currency,
+		WindowStart: giveaway.WindowStart,
+		WindowEnd:   giveaway.WindowEnd,
+		WinnerCount: giveaway.WinnerCount,
+		Status:      giveaway.Status,
+		Algorithm:   giveaways.Algorithm,
+		Commitment:  giveaway.Commitment,
+		TargetSlot:  giveaway.TargetSlot,
+		SeedSlot:    giveaway.SeedSlot,
+		Seed:        giveaway.Seed,
+		Entries:     make([]Entry, 0, len(entries)),
+		Winners:     giveaway.Winners,
+		CommittedAt: giveaway.CommittedAt,
+		DrawnAt:     giveaway.DrawnAt,
+	}
+
+	for _, entry := range entries {
+		response.Entries = append(response.Entries, Entry{Address: entry.Address, Weight: entry.Weight.String()})
+	}
+
+	return &Response{Body: response, StatusCode: http.StatusOK}, nil
+}
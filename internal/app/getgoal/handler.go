@@ -0,0 +1,72 @@
+package getgoal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+	"twitch-crypto-donations/internal/pkg/goals"
+	"twitch-crypto-donations/internal/pkg/middleware"
+)
+
+type Database interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+type ResponseBody struct {
+	ID           string     `json:"id"`
+	Title        string     `json:"title"`
+	Currency     *string    `json:"currency"`
+	FiatCurrency *string    `json:"fiat_currency"`
+	TargetAmount string     `json:"target_amount"`
+	RaisedAmount string     `json:"raised_amount"`
+	Progress     float64    `json:"progress"`
+	Deadline     *time.Time `json:"deadline"`
+	ReachedAt    *time.Time `json:"reached_at"`
+}
+
+type (
+	Request  = middleware.Request[struct{}]
+	Response = middleware.Response[ResponseBody]
+)
+
+type Handler struct {
+	db Database
+}
+
+func New(db Database) *Handler {
+	return &Handler{db: db}
+}
+
+// Handle returns the progress of the streamer's active donation goal.
+func (h *Handler) Handle(_ context.Context, request Request) (*Response, error) {
+	username, ok := request.PathParams["username"]
+	if !ok {
+		return nil, errors.New("username is required")
+	}
+
+	goal, err := goals.Active(h.db, username)
+	if errors.Is(err, goals.ErrGoalNotFound) {
+		return &Response{StatusCode: http.StatusNotFound}, err
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Response{
+		Body: ResponseBody{
+			ID:           goal.ID,
+			Title:        goal.Title,
+			Currency:     goal.Currency,
+			FiatCurrency: goal.FiatCurrency,
+			TargetAmount: goal.Target,
+			RaisedAmount: goal.Raised,
+			Progress:     goal.Percent(),
+			Deadline:     goal.Deadline,
+			ReachedAt:    goal.ReachedAt,
+		},
+		StatusCode: http.StatusOK,
+	}, nil
+}
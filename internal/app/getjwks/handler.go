@@ -0,0 +1,35 @@
+package getjwks
+
+import (
+	"context"
+	"net/http"
+	"twitch-crypto-donations/internal/pkg/jwt"
+	"twitch-crypto-donations/internal/pkg/middleware"
+)
+
+type KeySet interface {
+	JWKS() []jwt.JWK
+}
+
+type ResponseBody struct {
+	Keys []jwt.JWK `json:"keys"`
+}
+
+type (
+	Request  = middleware.Request[struct{}]
+	Response = middleware.Response[ResponseBody]
+)
+
+type Handler struct {
+	keys KeySet
+}
+
+func New(keys KeySet) *Handler {
+	return &Handler{keys: keys}
+}
+
+// Handle publishes the public keys access tokens verify against, so other
+// services can check our tokens without the signing secret.
+func (h *Handler) Handle(_ context.Context, _ Request) (*Response, error) {
+	return &Response{Body: ResponseBody{Keys: h.keys.JWKS()}, StatusCode: http.StatusOK}, nil
+}
@@ -0,0 +1,64 @@
+package getpaymentrequest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	"twitch-crypto-donations/internal/pkg/middleware"
+)
+
+type Database interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+type ResponseBody struct {
+	Reference   string     `json:"reference"`
+	Receiver    string     `json:"receiver"`
+	Amount      string     `json:"amount"`
+	Currency    string     `json:"currency"`
+	Status      string     `json:"status"`
+	TxSignature *string    `json:"tx_signature"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	SettledAt   *time.Time `json:"settled_at"`
+}
+
+type (
+	Request  = middleware.Request[struct{}]
+	Response = middleware.Response[ResponseBody]
+)
+
+type Handler struct {
+	db Database
+}
+
+func New(db Database) *Handler {
+	return &Handler{
+		db: db,
+	}
+}
+
+func (h *Handler) Handle(_ context.Context, request Request) (*Response, error) {
+	const query = `
+		SELECT reference, receiver, amount, currency, status, tx_signature, expires_at, settled_at
+		FROM payment_requests
+		WHERE reference = $1;
+	`
+
+	var body ResponseBody
+	err := h.db.QueryRow(query, request.PathParams["reference"]).Scan(
+		&body.Reference, &body.Receiver, &body.Amount, &body.Currency,
+		&body.Status, &body.TxSignature, &body.ExpiresAt, &body.SettledAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &Response{StatusCode: http.StatusNotFound}, errors.New("payment request not found")
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to load payment request: %w", err)
+	}
+
+	return &Response{Body: body, StatusCode: http.StatusOK}, nil
+}
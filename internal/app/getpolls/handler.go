@@ -0,0 +1,100 @@
+package getpolls
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+	"twitch-crypto-donations/internal/pkg/middleware"
+	"twitch-crypto-donations/internal/pkg/polls"
+)
+
+const maxPolls = 10
+
+type Database interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+type ResponseBody struct {
+	Polls []Poll `json:"polls"`
+}
+
+type Poll struct {
+	ID           string    `json:"id"`
+	Title        string    `json:"title"`
+	Currency     *string   `json:"currency"`
+	FiatCurrency *string   `json:"fiat_currency"`
+	ClosesAt     time.Time `json:"closes_at"`
+	Closed       bool      `json:"closed"`
+	TotalAmount  string    `json:"total_amount"`
+	Options      []Option  `json:"options"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type Option struct {
+	ID      string  `json:"id"`
+	Label   string  `json:"label"`
+	Amount  string  `json:"amount"`
+	Votes   int64   `json:"votes"`
+	Percent float64 `json:"percent"`
+}
+
+type (
+	Request  = middleware.Request[struct{}]
+	Response = middleware.Response[ResponseBody]
+)
+
+type Handler struct {
+	db Database
+}
+
+func New(db Database) *Handler {
+	return &Handler{db: db}
+}
+
+// Handle returns the streamer's open polls with live results, followed by the
+// most recently closed ones.
+func (h *Handler) Handle(_ context.Context, request Request) (*Response, error) {
+	username, ok := request.PathParams["username"]
+	if !ok {
+		return nil, errors.New("username is required")
+	}
+
+	list, err := polls.ListByUsername(h.db, username, maxPolls)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	response := ResponseBody{Polls: make([]Poll, 0, len(list))}
+	for _, poll := range list {
+		item := Poll{
+			ID:           poll.ID,
+			Title:        poll.Title,
+			Currency:     poll.Currency,
+			FiatCurrency: poll.FiatCurrency,
+			ClosesAt:     poll.ClosesAt,
+			Closed:       poll.Closed(now),
+			TotalAmount:  poll.Total(),
+			Options:      make([]Option, 0, len(poll.Options)),
+			CreatedAt:    poll.CreatedAt,
+		}
+
+		for _, option := range poll.Options {
+			item.Options = append(item.Options, Option{
+				ID:      option.ID,
+				Label:   option.Label,
+				Amount:  option.Amount,
+				Votes:   option.Votes,
+				Percent: poll.Percent(option),
+			})
+		}
+
+		response.Polls = append(response.Polls, item)
+	}
+
+	return &Response{Body: response, StatusCode: http.StatusOK}, nil
+}
@@ -0,0 +1,67 @@
+package getrevenuesplits
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"twitch-crypto-donations/internal/pkg/middleware"
+	"twitch-crypto-donations/internal/pkg/splits"
+)
+
+type SplitPolicy interface {
+	Shares(receiver string) ([]splits.Share, error)
+	FeeBps() uint64
+	FeeWallet() string
+}
+
+type ResponseBody struct {
+	Splits            []Split `json:"splits"`
+	PlatformFeeBps    uint64  `json:"platform_fee_bps"`
+	PlatformFeeWallet *string `json:"platform_fee_wallet"`
+}
+
+type Split struct {
+	Recipient string `json:"recipient"`
+	Bps       uint64 `json:"bps"`
+	Label     string `json:"label"`
+}
+
+type (
+	Request  = middleware.Request[struct{}]
+	Response = middleware.Response[ResponseBody]
+)
+
+type Handler struct {
+	splits SplitPolicy
+}
+
+func New(splits SplitPolicy) *Handler {
+	return &Handler{splits: splits}
+}
+
+func (h *Handler) Handle(_ context.Context, request Request) (*Response, error) {
+	address, exists := request.Context[middleware.AddressKey].(string)
+	if !exists || address == "" {
+		return &Response{StatusCode: http.StatusUnauthorized}, fmt.Errorf("jwt is not found or api middleware is failed")
+	}
+
+	shares, err := h.splits.Shares(address)
+	if err != nil {
+		return nil, err
+	}
+
+	response := ResponseBody{
+		Splits:         make([]Split, 0, len(shares)),
+		PlatformFeeBps: h.splits.FeeBps(),
+	}
+
+	if wallet := h.splits.FeeWallet(); wallet != "" {
+		response.PlatformFeeWallet = &wallet
+	}
+
+	for _, share := range shares {
+		response.Splits = append(response.Splits, Split{Recipient: share.Recipient, Bps: share.Bps, Label: share.Label})
+	}
+
+	return &Response{Body: response, StatusCode: http.StatusOK}, nil
+}
@@ -23,6 +23,7 @@ type UserInfo struct {
 	DisplayName *string    `json:"display_name"`
 	Bio         *string    `json:"bio"`
 	AvatarUrl   *string    `json:"avatar_url"`
+	EvmAddress  *string    `json:"evm_address"`
 	CreatedAt   *time.Time `json:"created_at"`
 
 	AlertsWidgetUrl *string `json:"alerts_widget_url"`
@@ -79,7 +80,7 @@ func (h *Handler) getUserInfo(wallet string) (*UserInfo, error) {
 	query := `
         SELECT wallet, username, email,
             display_name, bio,
-            avatar_url, created_at,
+            avatar_url, evm_address, created_at,
             alerts_widget_url, media_widget_url
         FROM users
         WHERE wallet = $1
@@ -90,7 +91,7 @@ func (h *Handler) getUserInfo(wallet string) (*UserInfo, error) {
 		&userInfo.Wallet, &userInfo.Username,
 		&userInfo.Email, &userInfo.DisplayName,
 		&userInfo.Bio, &userInfo.AvatarUrl,
-		&userInfo.CreatedAt, &userInfo.AlertsWidgetUrl,
+		&userInfo.EvmAddress, &userInfo.CreatedAt, &userInfo.AlertsWidgetUrl,
 		&userInfo.MediaWidgetUrl,
 	)
 
@@ -109,7 +110,7 @@ func (h *Handler) getStreamerInfo(username string) (*UserInfo, error) {
 	query := `
         SELECT wallet, username, email,
             display_name, bio,
-            avatar_url, created_at
+            avatar_url, evm_address, created_at
         FROM users
         WHERE username = $1
     `
@@ -122,6 +123,7 @@ func (h *Handler) getStreamerInfo(username string) (*UserInfo, error) {
 		&userInfo.DisplayName,
 		&userInfo.Bio,
 		&userInfo.AvatarUrl,
+		&userInfo.EvmAddress,
 		&userInfo.CreatedAt,
 	)
 
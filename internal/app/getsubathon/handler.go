@@ -0,0 +1,80 @@
+package getsubathon
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+	"twitch-crypto-donations/internal/pkg/middleware"
+	"twitch-crypto-donations/internal/pkg/subathon"
+)
+
+type Database interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+type ResponseBody struct {
+	Paused           bool       `json:"paused"`
+	EndsAt           *time.Time `json:"ends_at"`
+	RemainingSeconds int64      `json:"remaining_seconds"`
+	InitialSeconds   int64      `json:"initial_seconds"`
+	AddedSeconds     int64      `json:"added_seconds"`
+	CapSeconds       *int64     `json:"cap_seconds"`
+	StartedAt        time.Time  `json:"started_at"`
+	Rates            []Rate     `json:"rates"`
+}
+
+type Rate struct {
+	Currency       string `json:"currency"`
+	SecondsPerUnit string `json:"seconds_per_unit"`
+}
+
+type (
+	Request  = middleware.Request[struct{}]
+	Response = middleware.Response[ResponseBody]
+)
+
+type Handler struct {
+	db Database
+}
+
+func New(db Database) *Handler {
+	return &Handler{db: db}
+}
+
+// Handle returns the streamer's subathon timer and the time each currency adds.
+func (h *Handler) Handle(_ context.Context, request Request) (*Response, error) {
+	username, ok := request.PathParams["username"]
+	if !ok {
+		return nil, errors.New("username is required")
+	}
+
+	timer, err := subathon.FindByUsername(h.db, username)
+	if errors.Is(err, subathon.ErrTimerNotFound) {
+		return &Response{StatusCode: http.StatusNotFound}, err
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	response := ResponseBody{
+		Paused:           timer.Paused(),
+		EndsAt:           timer.EndsAt,
+		RemainingSeconds: timer.Remaining(time.Now()),
+		InitialSeconds:   timer.InitialSeconds,
+		AddedSeconds:     timer.AddedSeconds,
+		CapSeconds:       timer.CapSeconds,
+		StartedAt:        timer.StartedAt,
+		Rates:            make([]Rate, 0, len(timer.Rates)),
+	}
+
+	for _, rate := range timer.Rates {
+		response.Rates = append(response.Rates, Rate{Currency: rate.Currency, SecondsPerUnit: rate.SecondsPerUnit})
+	}
+
+	return &Response{Body: response, StatusCode: http.StatusOK}, nil
+}
@@ -0,0 +1,72 @@
+package listapikeys
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+	"twitch-crypto-donations/internal/pkg/apikeys"
+	"twitch-crypto-donations/internal/pkg/middleware"
+)
+
+type KeyStore interface {
+	List(owner string) ([]apikeys.Key, error)
+}
+
+type ResponseBody struct {
+	ApiKeys []ApiKey `json:"api_keys"`
+}
+
+type ApiKey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type (
+	Request  = middleware.Request[struct{}]
+	Response = middleware.Response[ResponseBody]
+)
+
+type Handler struct {
+	keys KeyStore
+}
+
+func New(keys KeyStore) *Handler {
+	return &Handler{keys: keys}
+}
+
+// Handle returns the authenticated streamer's API keys, newest first. Only
+// their prefixes are shown.
+func (h *Handler) Handle(_ context.Context, request Request) (*Response, error) {
+	address, exists := request.Context[middleware.AddressKey].(string)
+	if !exists || address == "" {
+		return &Response{StatusCode: http.StatusUnauthorized}, fmt.Errorf("jwt is not found or api middleware is failed")
+	}
+
+	keys, err := h.keys.List(address)
+	if err != nil {
+		return nil, err
+	}
+
+	response := ResponseBody{ApiKeys: make([]ApiKey, 0, len(keys))}
+	for _, key := range keys {
+		response.ApiKeys = append(response.ApiKeys, ApiKey{
+			ID:         key.ID,
+			Name:       key.Name,
+			Prefix:     key.Prefix,
+			Scopes:     key.Scopes,
+			ExpiresAt:  key.ExpiresAt,
+			LastUsedAt: key.LastUsedAt,
+			RevokedAt:  key.RevokedAt,
+			CreatedAt:  key.CreatedAt,
+		})
+	}
+
+	return &Response{Body: response, StatusCode: http.StatusOK}, nil
+}
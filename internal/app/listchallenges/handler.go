@@ -0,0 +1,82 @@
+package listchallenges
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+	"twitch-crypto-donations/internal/pkg/challenges"
+	"twitch-crypto-donations/internal/pkg/middleware"
+)
+
+type Database interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+type ResponseBody struct {
+	Challenges []Challenge `json:"challenges"`
+}
+
+type Challenge struct {
+	ID              string     `json:"id"`
+	Title           string     `json:"title"`
+	Description     *string    `json:"description"`
+	Currency        *string    `json:"currency"`
+	FiatCurrency    *string    `json:"fiat_currency"`
+	ThresholdAmount string     `json:"threshold_amount"`
+	PledgedAmount   string     `json:"pledged_amount"`
+	Pledges         int64      `json:"pledges"`
+	Progress        float64    `json:"progress"`
+	Status          string     `json:"status"`
+	FundedAt        *time.Time `json:"funded_at"`
+	ResolvedAt      *time.Time `json:"resolved_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+type (
+	Request  = middleware.Request[struct{}]
+	Response = middleware.Response[ResponseBody]
+)
+
+type Handler struct {
+	db Database
+}
+
+func New(db Database) *Handler {
+	return &Handler{db: db}
+}
+
+// Handle returns the authenticated streamer's challenges, open ones first.
+func (h *Handler) Handle(_ context.Context, request Request) (*Response, error) {
+	address, exists := request.Context[middleware.AddressKey].(string)
+	if !exists || address == "" {
+		return &Response{StatusCode: http.StatusUnauthorized}, fmt.Errorf("jwt is not found or api middleware is failed")
+	}
+
+	list, err := challenges.List(h.db, address)
+	if err != nil {
+		return nil, err
+	}
+
+	response := ResponseBody{Challenges: make([]Challenge, 0, len(list))}
+	for _, challenge := range list {
+		response.Challenges = append(response.Challenges, Challenge{
+			ID:              challenge.ID,
+			Title:           challenge.Title,
+			Description:     challenge.Description,
+			Currency:        challenge.Currency,
+			FiatCurrency:    challenge.FiatCurrency,
+			ThresholdAmount: challenge.Threshold,
+			PledgedAmount:   challenge.Pledged,
+			Pledges:         challenge.Pledges,
+			Progress:        challenge.Percent(),
+			Status:          challenge.Status,
+			FundedAt:        challenge.FundedAt,
+			ResolvedAt:      challenge.ResolvedAt,
+			CreatedAt:       challenge.CreatedAt,
+		})
+	}
+
+	return &Response{Body: response, StatusCode: http.StatusOK}, nil
+}
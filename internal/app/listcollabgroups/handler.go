@@ -0,0 +1,92 @@
+package listcollabgroups
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+	"twitch-crypto-donations/internal/pkg/middleware"
+)
+
+type Database interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+type ResponseBody struct {
+	Groups []Group `json:"groups"`
+}
+
+type Group struct {
+	ID        string    `json:"id"`
+	Owner     string    `json:"owner"`
+	Name      string    `json:"name"`
+	Members   []Member  `json:"members"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Member struct {
+	Wallet string `json:"wallet"`
+	Bps    uint64 `json:"bps"`
+	Status string `json:"status"`
+}
+
+type (
+	Request  = middleware.Request[struct{}]
+	Response = middleware.Response[ResponseBody]
+)
+
+type Handler struct {
+	db Database
+}
+
+func New(db Database) *Handler {
+	return &Handler{db: db}
+}
+
+// Handle lists the collab groups the authenticated streamer owns or was invited to.
+func (h *Handler) Handle(_ context.Context, request Request) (*Response, error) {
+	address, exists := request.Context[middleware.AddressKey].(string)
+	if !exists || address == "" {
+		return &Response{StatusCode: http.StatusUnauthorized}, fmt.Errorf("jwt is not found or api middleware is failed")
+	}
+
+	const query = `
+		SELECT g.id, g.owner, g.name, g.created_at, m.wallet, m.bps, m.status
+		FROM collab_groups g
+		JOIN collab_members m ON m.group_id = g.id
+		WHERE g.id IN (SELECT group_id FROM collab_members WHERE wallet = $1)
+		ORDER BY g.created_at DESC, g.id, (m.wallet = g.owner) DESC, m.id;
+	`
+
+	rows, err := h.db.Query(query, address)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	groups := make([]Group, 0, 4)
+	for rows.Next() {
+		var (
+			g Group
+			m Member
+		)
+		if err = rows.Scan(&g.ID, &g.Owner, &g.Name, &g.CreatedAt, &m.Wallet, &m.Bps, &m.Status); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		if len(groups) == 0 || groups[len(groups)-1].ID != g.ID {
+			g.Members = make([]Member, 0, 2)
+			groups = append(groups, g)
+		}
+
+		last := &groups[len(groups)-1]
+		last.Members = append(last.Members, m)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return &Response{Body: ResponseBody{Groups: groups}, StatusCode: http.StatusOK}, nil
+}
@@ -0,0 +1,140 @@
+package listdonations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+	"twitch-crypto-donations/internal/pkg/amount"
+	"twitch-crypto-donations/internal/pkg/donations"
+	"twitch-crypto-donations/internal/pkg/middleware"
+)
+
+type Database interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+type ResponseBody struct {
+	Donations []Donation `json:"donations"`
+}
+
+type Donation struct {
+	ID              string     `json:"id"`
+	SenderAddress   *string    `json:"sender_address"`
+	SenderUsername  string     `json:"sender_username"`
+	Amount          string     `json:"amount"`
+	AmountBaseUnits string     `json:"amount_base_units"`
+	Decimals        uint8      `json:"decimals"`
+	Currency        string     `json:"currency"`
+	UsdValue        *string    `json:"usd_value"`
+	FiatValue       *string    `json:"fiat_value"`
+	FiatCurrency    *string    `json:"fiat_currency"`
+	TxSignature     *string    `json:"tx_signature"`
+	State           string     `json:"state"`
+	FinalizedAt     *time.Time `json:"finalized_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+type (
+	Request  = middleware.Request[struct{}]
+	Response = middleware.Response[ResponseBody]
+)
+
+var states = []donations.State{
+	donations.StateIntentCreated,
+	donations.StatePaymentSeen,
+	donations.StateConfirmed,
+	donations.StateFinalized,
+	donations.StateAlertDelivered,
+	donations.StateAlertFailed,
+	donations.StateExpired,
+	donations.StateReverted,
+}
+
+type Handler struct {
+	db Database
+}
+
+func New(db Database) *Handler {
+	return &Handler{db: db}
+}
+
+func (h *Handler) Handle(_ context.Context, request Request) (*Response, error) {
+	address, exists := request.Context[middleware.AddressKey].(string)
+	if !exists || address == "" {
+		return &Response{StatusCode: http.StatusUnauthorized}, fmt.Errorf("jwt is not found or api middleware is failed")
+	}
+
+	var state *string
+	if value, ok := request.Queries["state"]; ok && value != "" {
+		if !slices.Contains(states, donations.State(value)) {
+			return &Response{StatusCode: http.StatusBadRequest}, fmt.Errorf("unknown donation state %s", value)
+		}
+		state = &value
+	}
+
+	var finalized *bool
+	if value, ok := request.Queries["finalized"]; ok && value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return &Response{StatusCode: http.StatusBadRequest}, fmt.Errorf("invalid finalized filter %s", value)
+		}
+		finalized = &parsed
+	}
+
+	result, err := h.getDonations(address, state, finalized)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Response{Body: ResponseBody{Donations: result}, StatusCode: http.StatusOK}, nil
+}
+
+func (h *Handler) getDonations(address string, state *string, finalized *bool) ([]Donation, error) {
+	const query = `
+		SELECT id, sender_address, sender_username, amount, decimals, currency, usd_value, fiat_value, fiat_currency, tx_signature, state, finalized_at, created_at, updated_at
+		FROM donations
+		WHERE receiver = $1
+		  AND ($2::TEXT IS NULL OR state = $2)
+		  AND ($3::BOOLEAN IS NULL OR (finalized_at IS NOT NULL) = $3)
+		ORDER BY created_at DESC;
+	`
+
+	rows, err := h.db.Query(query, address, state, finalized)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]Donation, 0, 10)
+	for rows.Next() {
+		var d Donation
+
+		err = rows.Scan(
+			&d.ID, &d.SenderAddress, &d.SenderUsername,
+			&d.AmountBaseUnits, &d.Decimals, &d.Currency,
+			&d.UsdValue, &d.FiatValue, &d.FiatCurrency, &d.TxSignature,
+			&d.State, &d.FinalizedAt, &d.CreatedAt, &d.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		d.Amount, err = amount.FormatNumeric(d.AmountBaseUnits, d.Decimals)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, d)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,75 @@
+package listevents
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+	"twitch-crypto-donations/internal/pkg/middleware"
+)
+
+type Database interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+type ResponseBody struct {
+	Events []Event `json:"events"`
+}
+
+type Event struct {
+	ID         int64     `json:"id"`
+	Type       string    `json:"type"`
+	DonationID *string   `json:"donation_id"`
+	Message    string    `json:"message"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type (
+	Request  = middleware.Request[struct{}]
+	Response = middleware.Response[ResponseBody]
+)
+
+type Handler struct {
+	db Database
+}
+
+func New(db Database) *Handler {
+	return &Handler{db: db}
+}
+
+func (h *Handler) Handle(_ context.Context, request Request) (*Response, error) {
+	address, exists := request.Context[middleware.AddressKey].(string)
+	if !exists || address == "" {
+		return &Response{StatusCode: http.StatusUnauthorized}, fmt.Errorf("jwt is not found or api middleware is failed")
+	}
+
+	const query = `
+		SELECT id, type, donation_id, message, created_at
+		FROM streamer_events
+		WHERE receiver = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT 100;
+	`
+
+	rows, err := h.db.Query(query, address)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]Event, 0, 10)
+	for rows.Next() {
+		var e Event
+		if err = rows.Scan(&e.ID, &e.Type, &e.DonationID, &e.Message, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		result = append(result, e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return &Response{Body: ResponseBody{Events: result}, StatusCode: http.StatusOK}, nil
+}
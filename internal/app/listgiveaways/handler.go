@@ -0,0 +1,90 @@
+package listgiveaways
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+	"twitch-crypto-donations/internal/pkg/giveaways"
+	"twitch-crypto-donations/internal/pkg/middleware"
+)
+
+type Database interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+type ResponseBody struct {
+	Giveaways []Giveaway `json:"giveaways"`
+}
+
+type Giveaway struct {
+	ID          string     `json:"id"`
+	Title       string     `json:"title"`
+	Mode        string     `json:"mode"`
+	Currency    *string    `json:"currency"`
+	WindowStart time.Time  `json:"window_start"`
+	WindowEnd   time.Time  `json:"window_end"`
+	WinnerCount int        `json:"winner_count"`
+	Status      string     `json:"status"`
+	Commitment  *string    `json:"commitment"`
+	TargetSlot  *uint64    `json:"target_slot"`
+	SeedSlot    *uint64    `json:"seed_slot"`
+	Seed        *string    `json:"seed"`
+	EntryCount  int        `json:"entry_count"`
+	Winners     []string   `json:"winners"`
+	CommittedAt *time.Time `json:"committed_at"`
+	DrawnAt     *time.Time `json:"drawn_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type (
+	Request  = middleware.Request[struct{}]
+	Response = middleware.Response[ResponseBody]
+)
+
+type Handler struct {
+	db Database
+}
+
+func New(db Database) *Handler {
+	return &Handler{db: db}
+}
+
+// Handle returns the authenticated streamer's giveaways with their winners.
+func (h *Handler) Handle(_ context.Context, request Request) (*Response, error) {
+	address, exists := request.Context[middleware.AddressKey].(string)
+	if !exists || address == "" {
+		return &Response{StatusCode: http.StatusUnauthorized}, fmt.Errorf("jwt is not found or api middleware is failed")
+	}
+
+	list, err := giveaways.List(h.db, address)
+	if err != nil {
+		return nil, err
+	}
+
+	response := ResponseBody{Giveaways: make([]Giveaway, 0, len(list))}
+	for _, giveaway := range list {
+		response.Giveaways = append(response.Giveaways, Giveaway{
+			ID:          giveaway.ID,
+			Title:       giveaway.Title,
+			Mode:        giveaway.Mode,
+			Currency:    giveaway.Currency,
+			WindowStart: giveaway.WindowStart,
+			WindowEnd:   giveaway.WindowEnd,
+			WinnerCount: giveaway.WinnerCount,
+			Status:      giveaway.Status,
+			Commitment:  giveaway.Commitment,
+			TargetSlot:  giveaway.TargetSlot,
+			SeedSlot:    giveaway.SeedSlot,
+			Seed:        giveaway.Seed,
+			EntryCount:  giveaway.EntryCount,
+			Winners:     giveaway.Winners,
+			CommittedAt: giveaway.CommittedAt,
+			DrawnAt:     giveaway.DrawnAt,
+			CreatedAt:   giveaway.CreatedAt,
+		})
+	}
+
+	return &Response{Body: response, StatusCode: http.StatusOK}, nil
+}
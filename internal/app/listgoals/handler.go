@@ -0,0 +1,79 @@
+package listgoals
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+	"twitch-crypto-donations/internal/pkg/goals"
+	"twitch-crypto-donations/internal/pkg/middleware"
+)
+
+type Database interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+type ResponseBody struct {
+	Goals []Goal `json:"goals"`
+}
+
+type Goal struct {
+	ID           string     `json:"id"`
+	Title        string     `json:"title"`
+	Currency     *string    `json:"currency"`
+	FiatCurrency *string    `json:"fiat_currency"`
+	TargetAmount string     `json:"target_amount"`
+	RaisedAmount string     `json:"raised_amount"`
+	Progress     float64    `json:"progress"`
+	Deadline     *time.Time `json:"deadline"`
+	Active       bool       `json:"active"`
+	ReachedAt    *time.Time `json:"reached_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+type (
+	Request  = middleware.Request[struct{}]
+	Response = middleware.Response[ResponseBody]
+)
+
+type Handler struct {
+	db Database
+}
+
+func New(db Database) *Handler {
+	return &Handler{db: db}
+}
+
+// Handle lists the authenticated streamer's donation goals with their progress,
+// the active goal first.
+func (h *Handler) Handle(_ context.Context, request Request) (*Response, error) {
+	address, exists := request.Context[middleware.AddressKey].(string)
+	if !exists || address == "" {
+		return &Response{StatusCode: http.StatusUnauthorized}, fmt.Errorf("jwt is not found or api middleware is failed")
+	}
+
+	list, err := goals.List(h.db, address)
+	if err != nil {
+		return nil, err
+	}
+
+	response := ResponseBody{Goals: make([]Goal, 0, len(list))}
+	for _, goal := range list {
+		response.Goals = append(response.Goals, Goal{
+			ID:           goal.ID,
+			Title:        goal.Title,
+			Currency:     goal.Currency,
+			FiatCurrency: goal.FiatCurrency,
+			TargetAmount: goal.Target,
+			RaisedAmount: goal.Raised,
+			Progress:     goal.Percent(),
+			Deadline:     goal.Deadline,
+			Active:       goal.Active,
+			ReachedAt:    goal.ReachedAt,
+			CreatedAt:    goal.CreatedAt,
+		})
+	}
+
+	return &Response{Body: response, StatusCode: http.StatusOK}, nil
+}
@@ -0,0 +1,97 @@
+package listpolls
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+	"twitch-crypto-donations/internal/pkg/middleware"
+	"twitch-crypto-donations/internal/pkg/polls"
+)
+
+type Database interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+type ResponseBody struct {
+	Polls []Poll `json:"polls"`
+}
+
+type Poll struct {
+	ID           string    `json:"id"`
+	Title        string    `json:"title"`
+	Currency     *string   `json:"currency"`
+	FiatCurrency *string   `json:"fiat_currency"`
+	ClosesAt     time.Time `json:"closes_at"`
+	Closed       bool      `json:"closed"`
+	TotalAmount  string    `json:"total_amount"`
+	Options      []Option  `json:"options"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type Option struct {
+	ID      string  `json:"id"`
+	Label   string  `json:"label"`
+	Amount  string  `json:"amount"`
+	Votes   int64   `json:"votes"`
+	Percent float64 `json:"percent"`
+}
+
+type (
+	Request  = middleware.Request[struct{}]
+	Response = middleware.Response[ResponseBody]
+)
+
+type Handler struct {
+	db Database
+}
+
+func New(db Database) *Handler {
+	return &Handler{db: db}
+}
+
+// Handle returns the authenticated streamer's polls with their results.
+func (h *Handler) Handle(_ context.Context, request Request) (*Response, error) {
+	address, exists := request.Context[middleware.AddressKey].(string)
+	if !exists || address == "" {
+		return &Response{StatusCode: http.StatusUnauthorized}, fmt.Errorf("jwt is not found or api middleware is failed")
+	}
+
+	list, err := polls.List(h.db, address)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	response := ResponseBody{Polls: make([]Poll, 0, len(list))}
+	for _, poll := range list {
+		item := Poll{
+			ID:           poll.ID,
+			Title:        poll.Title,
+			Currency:     poll.Currency,
+			FiatCurrency: poll.FiatCurrency,
+			ClosesAt:     poll.ClosesAt,
+			Closed:       poll.Closed(now),
+			TotalAmount:  poll.Total(),
+			Options:      make([]Option, 0, len(poll.Options)),
+			CreatedAt:    poll.CreatedAt,
+		}
+
+		for _, option := range poll.Options {
+			item.Options = append(item.Options, Option{
+				ID:      option.ID,
+				Label:   option.Label,
+				Amount:  option.Amount,
+				Votes:   option.Votes,
+				Percent: poll.Percent(option),
+			})
+		}
+
+		response.Polls = append(response.Polls, item)
+	}
+
+	return &Response{Body: response, StatusCode: http.StatusOK}, nil
+}
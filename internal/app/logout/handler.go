@@ -0,0 +1,50 @@
+package logout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"twitch-crypto-donations/internal/pkg/middleware"
+	"twitch-crypto-donations/internal/pkg/sessions"
+)
+
+type SessionManager interface {
+	Revoke(refreshToken string) error
+}
+
+type RequestBody struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type (
+	Request  = middleware.Request[RequestBody]
+	Response = middleware.Response[struct{}]
+)
+
+type Handler struct {
+	sessions SessionManager
+}
+
+func New(sessions SessionManager) *Handler {
+	return &Handler{sessions: sessions}
+}
+
+// Handle revokes the session of the refresh token. Its access tokens are
+// rejected from then on, even before they expire.
+func (h *Handler) Handle(_ context.Context, request Request) (*Response, error) {
+	if request.Body.RefreshToken == "" {
+		return &Response{StatusCode: http.StatusBadRequest}, errors.New("refresh_token is required")
+	}
+
+	err := h.sessions.Revoke(request.Body.RefreshToken)
+	if errors.Is(err, sessions.ErrSessionNotFound) {
+		return &Response{StatusCode: http.StatusUnauthorized}, err
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to log out: %w", err)
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,45 @@
+package logoutall
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"twitch-crypto-donations/internal/pkg/middleware"
+)
+
+type SessionManager interface {
+	RevokeAll(address string) (int64, error)
+}
+
+type ResponseBody struct {
+	RevokedSessions int64 `json:"revoked_sessions"`
+}
+
+type (
+	Request  = middleware.Request[struct{}]
+	Response = middleware.Response[ResponseBody]
+)
+
+type Handler struct {
+	sessions SessionManager
+}
+
+func New(sessions SessionManager) *Handler {
+	return &Handler{sessions: sessions}
+}
+
+// Handle revokes every session of the authenticated wallet, including the one
+// making the request, logging it out on all devices.
+func (h *Handler) Handle(_ context.Context, request Request) (*Response, error) {
+	address, exists := request.Context[middleware.AddressKey].(string)
+	if !exists || address == "" {
+		return &Response{StatusCode: http.StatusUnauthorized}, fmt.Errorf("jwt is not found or api middleware is failed")
+	}
+
+	revoked, err := h.sessions.RevokeAll(address)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Response{Body: ResponseBody{RevokedSessions: revoked}, StatusCode: http.StatusOK}, nil
+}
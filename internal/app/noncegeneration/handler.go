@@ -4,12 +4,14 @@ import (
 	"context"
 	"crypto/rand"
 	"database/sql"
-	"encoding/base64"
 	"errors"
 	"fmt"
 	"net/http"
 	"time"
 	"twitch-crypto-donations/internal/pkg/middleware"
+	"twitch-crypto-donations/internal/pkg/siws"
+
+	"github.com/mr-tron/base58"
 )
 
 type Database interface {
@@ -17,6 +19,10 @@ type Database interface {
 	Exec(query string, args ...any) (sql.Result, error)
 }
 
+type MessageIssuer interface {
+	Message(address, statement, nonce string, issuedAt, expiresAt time.Time) siws.Message
+}
+
 type RequestBody struct {
 	Address string `json:"address"`
 }
@@ -32,13 +38,15 @@ type (
 
 type Handler struct {
 	db              Database
+	issuer          MessageIssuer
 	nonceExpiration time.Duration
 	appName         string
 }
 
-func New(db Database) *Handler {
+func New(db Database, issuer MessageIssuer) *Handler {
 	return &Handler{
 		db:              db,
+		issuer:          issuer,
 		nonceExpiration: 5 * time.Minute,
 		appName:         "KapachiPay",
 	}
@@ -54,9 +62,10 @@ func (h *Handler) Handle(_ context.Context, request Request) (*Response, error)
 		return nil, fmt.Errorf("failed to generate nonce: %w", err)
 	}
 
-	message := h.createSignMessage(nonce)
+	now := time.Now().UTC()
+	message := h.createSignMessage(request.Body.Address, nonce, now)
 
-	if err = h.saveNonce(nonce, request.Body.Address); err != nil {
+	if err = h.saveNonce(nonce, request.Body.Address, now); err != nil {
 		return nil, fmt.Errorf("failed to save nonce: %w", err)
 	}
 
@@ -74,16 +83,18 @@ func (h *Handler) generateSecureNonce() (string, error) {
 		return "", err
 	}
 
-	nonce := base64.URLEncoding.EncodeToString(bytes)
+	nonce := base58.Encode(bytes)
 	return nonce, nil
 }
 
-func (h *Handler) createSignMessage(nonce string) string {
-	return fmt.Sprintf("Sign in to %s Nonce: %s", h.appName, nonce)
+// createSignMessage builds the Sign-In With Solana message for the address. It
+// expires together with its nonce.
+func (h *Handler) createSignMessage(address, nonce string, now time.Time) string {
+	statement := fmt.Sprintf("Sign in to %s", h.appName)
+	return h.issuer.Message(address, statement, nonce, now, now.Add(h.nonceExpiration)).String()
 }
 
-func (h *Handler) saveNonce(nonce, address string) error {
-	now := time.Now().UTC()
+func (h *Handler) saveNonce(nonce, address string, now time.Time) error {
 	expiresAt := now.Add(h.nonceExpiration)
 
 	const insertQuery = `
@@ -104,7 +115,8 @@ func (h *Handler) saveNonce(nonce, address string) error {
 	return nil
 }
 
-func (h *Handler) CleanupExpiredNonces() error {
+// CleanupExpiredNonces deletes the nonces whose sign-in window has passed.
+func (h *Handler) CleanupExpiredNonces(_ context.Context) error {
 	const deleteQuery = `DELETE FROM nonces WHERE expires_at < $1;`
 
 	_, err := h.db.Exec(deleteQuery, time.Now().UTC())
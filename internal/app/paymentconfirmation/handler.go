@@ -2,31 +2,65 @@ package paymentconfirmation
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"net/http"
-	"time"
+	"slices"
+	"twitch-crypto-donations/internal/pkg/amount"
+	"twitch-crypto-donations/internal/pkg/chain"
+	"twitch-crypto-donations/internal/pkg/donations"
 	"twitch-crypto-donations/internal/pkg/middleware"
+	"twitch-crypto-donations/internal/pkg/solanapay"
+	"twitch-crypto-donations/internal/pkg/splits"
+)
 
-	"github.com/AlekSi/pointer"
-	"github.com/gagliardetto/solana-go"
-	"github.com/gagliardetto/solana-go/rpc"
+const (
+	ReasonMemoMismatch       = "memo_mismatch"
+	ReasonDonationNotPending = "donation_not_pending"
+	ReasonPaymentAlreadyUsed = "payment_already_used"
 )
 
-type RpcClient interface {
-	GetTransaction(ctx context.Context, txSig solana.Signature, opts *rpc.GetTransactionOpts) (out *rpc.GetTransactionResult, err error)
+type Database interface {
+	QueryRow(query string, args ...any) *sql.Row
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+type ChainRegistry interface {
+	Verifier(id chain.ID) (chain.PaymentVerifier, error)
+	Asset(id chain.ID, symbol string) (chain.Asset, bool)
+	Native(id chain.ID) (chain.Asset, bool)
+}
+
+type SplitPolicy interface {
+	Plan(receiver string, gross uint64) ([]splits.Leg, error)
+}
+
+type DonationSettler interface {
+	Confirm(ctx context.Context, donationID, signature string, result *chain.Result) error
 }
 
 type RequestBody struct {
-	Signature string `json:"signature"`
-	Recipient string `json:"recipient"`
-	SolAmount string `json:"sol_amount"`
+	Signature string  `json:"signature"`
+	Recipient string  `json:"recipient"`
+	SolAmount string  `json:"sol_amount"`
+	Currency  *string `json:"currency"`
+	Amount    *string `json:"amount"`
+	Chain     *string `json:"chain"`
+
+	DonationID *string `json:"donation_id"`
 }
 
 type ResponseBody struct {
-	Confirmed bool   `json:"confirmed"`
-	Message   string `json:"message"`
-	Slot      uint64 `json:"slot,omitempty"`
+	Confirmed      bool   `json:"confirmed"`
+	Message        string `json:"message"`
+	Slot           uint64 `json:"slot,omitempty"`
+	Chain          string `json:"chain,omitempty"`
+	Currency       string `json:"currency,omitempty"`
+	Reason         string `json:"reason,omitempty"`
+	ExpectedAmount uint64 `json:"expected_amount,omitempty"`
+	ReceivedAmount uint64 `json:"received_amount,omitempty"`
+	DonationID     string `json:"donation_id,omitempty"`
 }
 
 type (
@@ -35,63 +69,229 @@ type (
 )
 
 type Handler struct {
-	rpcClient RpcClient
+	chains  ChainRegistry
+	splits  SplitPolicy
+	settler DonationSettler
+	db      Database
+}
+
+type pendingDonation struct {
+	receiver  string
+	amount    string
+	feeAmount *string
+	currency  string
 }
 
-func New(rpcClient RpcClient) *Handler {
+func New(chains ChainRegistry, splits SplitPolicy, settler DonationSettler, db Database) *Handler {
 	return &Handler{
-		rpcClient: rpcClient,
+		chains:  chains,
+		splits:  splits,
+		settler: settler,
+		db:      db,
 	}
 }
 
 func (h *Handler) Handle(ctx context.Context, request Request) (*Response, error) {
-	sig, err := solana.SignatureFromBase58(request.Body.Signature)
+	chainID := chain.Solana
+	if request.Body.Chain != nil {
+		chainID = chain.ID(*request.Body.Chain)
+	}
+
+	request.Body.Signature = chain.Reference(chainID, request.Body.Signature)
+
+	verifier, err := h.chains.Verifier(chainID)
+	if err != nil {
+		return &Response{StatusCode: http.StatusBadRequest}, err
+	}
+
+	var donation *pendingDonation
+	if request.Body.DonationID != nil {
+		if chainID != chain.Solana {
+			return &Response{StatusCode: http.StatusBadRequest}, fmt.Errorf("donation %s is paid on %s", *request.Body.DonationID, chain.Solana)
+		}
+
+		donation, err = h.getPendingDonation(*request.Body.DonationID)
+		if err != nil {
+			return nil, err
+		}
+
+		if donation == nil {
+			return &Response{StatusCode: http.StatusNotFound}, fmt.Errorf("donation %s not found", *request.Body.DonationID)
+		}
+
+		request.Body.Recipient = donation.receiver
+		request.Body.Currency = &donation.currency
+		request.Body.Amount = &donation.amount
+	}
+
+	asset, ok := h.chains.Native(chainID)
+	if request.Body.Currency != nil {
+		asset, ok = h.chains.Asset(chainID, *request.Body.Currency)
+	}
+
+	if !ok {
+		return &Response{StatusCode: http.StatusBadRequest}, fmt.Errorf("unsupported currency on %s", chainID)
+	}
+
+	value := request.Body.SolAmount
+	if request.Body.Amount != nil {
+		value = *request.Body.Amount
+	}
+
+	expected, err := amount.Parse(value, asset.Decimals)
+	if err != nil {
+		return &Response{StatusCode: http.StatusBadRequest}, fmt.Errorf("invalid amount: %w", err)
+	}
+
+	// Revenue splits and the platform fee are paid to Solana wallets, so other
+	// chains only check the transfer to the recipient.
+	var legs []splits.Leg
+	switch {
+	case donation != nil:
+		legs, err = h.donationLegs(*request.Body.DonationID, donation, asset, expected)
+	case chainID == chain.Solana:
+		legs, err = h.splits.Plan(request.Body.Recipient, expected)
+	default:
+		legs = []splits.Leg{{Recipient: request.Body.Recipient, Kind: splits.KindStreamer, Amount: expected}}
+	}
+
+	if errors.Is(err, splits.ErrInvalidSplit) {
+		return &Response{StatusCode: http.StatusBadRequest}, fmt.Errorf("amount %q cannot be split: %w", value, err)
+	}
+
 	if err != nil {
 		return nil, err
 	}
 
-	timeoutCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
-	defer cancel()
+	transfers := make([]chain.Transfer, 0, len(legs))
+	for _, leg := range legs {
+		transfers = append(transfers, chain.Transfer{
+			Recipient: leg.Recipient,
+			Token:     asset.Token,
+			Amount:    leg.Amount,
+		})
+	}
 
-	ticker := time.NewTicker(time.Second)
-	defer ticker.Stop()
+	result, err := verifier.Verify(ctx, request.Body.Signature, transfers)
 
-	var tx *rpc.GetTransactionResult
+	if mismatch, ok := verifier.Explain(err); ok {
+		return &Response{
+			StatusCode: http.StatusConflict,
+			Body: ResponseBody{
+				Confirmed:      false,
+				Message:        mismatch.Message,
+				Chain:          string(chainID),
+				Currency:       asset.Symbol,
+				Reason:         mismatch.Reason,
+				ExpectedAmount: mismatch.Expected,
+				ReceivedAmount: mismatch.Actual,
+			},
+		}, nil
+	}
 
-	for {
-		select {
-		case <-timeoutCtx.Done():
-			return nil, errors.New("transaction confirmation timeout after 15 seconds")
-		case <-ticker.C:
-			tx, err = h.rpcClient.GetTransaction(
-				timeoutCtx, sig,
-				&rpc.GetTransactionOpts{
-					Encoding:                       solana.EncodingBase64,
-					Commitment:                     rpc.CommitmentConfirmed,
-					MaxSupportedTransactionVersion: pointer.ToUint64(0),
-				},
-			)
+	if err != nil {
+		return nil, err
+	}
+
+	var donationID string
+	if request.Body.DonationID != nil {
+		donationID = *request.Body.DonationID
 
-			if err != nil {
-				continue
-			}
+		if !slices.Contains(result.Memos, donationID) {
+			return &Response{
+				StatusCode: http.StatusConflict,
+				Body: ResponseBody{
+					Confirmed:  false,
+					Message:    fmt.Sprintf("transaction memo does not reference donation %s", donationID),
+					Chain:      string(chainID),
+					Currency:   asset.Symbol,
+					Reason:     ReasonMemoMismatch,
+					DonationID: donationID,
+				},
+			}, nil
+		}
 
-			if tx == nil {
-				continue
-			}
+		err = h.settler.Confirm(ctx, donationID, request.Body.Signature, result)
 
-			if tx.Meta.Err != nil {
-				return nil, fmt.Errorf("transaction failed: %+v", tx.Meta.Err)
-			}
+		reason, message := "", ""
+		switch {
+		case errors.Is(err, solanapay.ErrRequestNotPending), errors.Is(err, donations.ErrInvalidTransition):
+			reason, message = ReasonDonationNotPending, fmt.Sprintf("donation %s is no longer pending", donationID)
+		case errors.Is(err, solanapay.ErrSignatureUsed):
+			reason, message = ReasonPaymentAlreadyUsed, "transaction signature was already used by another donation"
+		case err != nil:
+			return nil, err
+		}
 
+		if reason != "" {
 			return &Response{
-				StatusCode: http.StatusOK,
+				StatusCode: http.StatusConflict,
 				Body: ResponseBody{
-					Confirmed: true,
-					Message:   "Transaction successfully confirmed on Solana.",
-					Slot:      tx.Slot,
+					Confirmed:  false,
+					Message:    message,
+					Chain:      string(chainID),
+					Currency:   asset.Symbol,
+					Reason:     reason,
+					DonationID: donationID,
 				},
 			}, nil
 		}
 	}
+
+	return &Response{
+		StatusCode: http.StatusOK,
+		Body: ResponseBody{
+			Confirmed:      true,
+			Message:        "Transaction successfully confirmed on Solana.",
+			Slot:           result.Height,
+			Chain:          string(chainID),
+			Currency:       asset.Symbol,
+			ExpectedAmount: splits.Gross(legs),
+			ReceivedAmount: result.Amount,
+			DonationID:     donationID,
+		},
+	}, nil
+}
+
+// donationLegs returns the legs recorded when the donation was built. Requests
+// created before legs were recorded only pay the streamer the amount net of fee.
+func (h *Handler) donationLegs(donationID string, donation *pendingDonation, asset chain.Asset, expected uint64) ([]splits.Leg, error) {
+	legs, err := splits.Load(h.db, donationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(legs) > 0 {
+		return legs, nil
+	}
+
+	if donation.feeAmount != nil {
+		fee, err := amount.Parse(*donation.feeAmount, asset.Decimals)
+		if err != nil || fee > expected {
+			return nil, fmt.Errorf("invalid fee amount %q for donation %s", *donation.feeAmount, donationID)
+		}
+		expected -= fee
+	}
+
+	return []splits.Leg{{Recipient: donation.receiver, Kind: splits.KindStreamer, Amount: expected}}, nil
+}
+
+func (h *Handler) getPendingDonation(donationID string) (*pendingDonation, error) {
+	const query = `
+		SELECT receiver, amount, fee_amount, currency
+		FROM payment_requests
+		WHERE donation_id = $1;
+	`
+
+	var donation pendingDonation
+	err := h.db.QueryRow(query, donationID).Scan(&donation.receiver, &donation.amount, &donation.feeAmount, &donation.currency)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to load donation: %w", err)
+	}
+
+	return &donation, nil
 }
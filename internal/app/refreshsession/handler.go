@@ -0,0 +1,67 @@
+package refreshsession
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	"twitch-crypto-donations/internal/pkg/middleware"
+	"twitch-crypto-donations/internal/pkg/sessions"
+)
+
+type SessionManager interface {
+	Refresh(refreshToken string) (*sessions.Tokens, error)
+}
+
+type RequestBody struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type ResponseBody struct {
+	JwtToken              string    `json:"jwt_token"`
+	ExpiresAt             time.Time `json:"expires_at"`
+	RefreshToken          string    `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
+}
+
+type (
+	Request  = middleware.Request[RequestBody]
+	Response = middleware.Response[ResponseBody]
+)
+
+type Handler struct {
+	sessions SessionManager
+}
+
+func New(sessions SessionManager) *Handler {
+	return &Handler{sessions: sessions}
+}
+
+// Handle trades a refresh token for a new access token and a new refresh
+// token. The presented refresh token stops working.
+func (h *Handler) Handle(_ context.Context, request Request) (*Response, error) {
+	if request.Body.RefreshToken == "" {
+		return &Response{StatusCode: http.StatusBadRequest}, errors.New("refresh_token is required")
+	}
+
+	tokens, err := h.sessions.Refresh(request.Body.RefreshToken)
+	if errors.Is(err, sessions.ErrSessionNotFound) || errors.Is(err, sessions.ErrSessionRevoked) ||
+		errors.Is(err, sessions.ErrSessionExpired) || errors.Is(err, sessions.ErrRefreshTokenReused) {
+		return &Response{StatusCode: http.StatusUnauthorized}, err
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh session: %w", err)
+	}
+
+	return &Response{
+		Body: ResponseBody{
+			JwtToken:              tokens.AccessToken,
+			ExpiresAt:             tokens.AccessTokenExpiresAt,
+			RefreshToken:          tokens.RefreshToken,
+			RefreshTokenExpiresAt: tokens.RefreshTokenExpiresAt,
+		},
+		StatusCode: http.StatusOK,
+	}, nil
+}
@@ -0,0 +1,137 @@
+package resolvechallenge
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	"twitch-crypto-donations/internal/pkg/challenges"
+	"twitch-crypto-donations/internal/pkg/middleware"
+)
+
+type Database interface {
+	QueryRow(query string, args ...any) *sql.Row
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+type ChallengeTracker interface {
+	Resolved(challenge challenges.Challenge) error
+}
+
+type Logger interface {
+	Info(msg string, ctx ...interface{})
+}
+
+type RequestBody struct {
+	Outcome string `json:"outcome"`
+}
+
+type ResponseBody struct {
+	ID              string     `json:"id"`
+	Title           string     `json:"title"`
+	Description     *string    `json:"description"`
+	Currency        *string    `json:"currency"`
+	FiatCurrency    *string    `json:"fiat_currency"`
+	ThresholdAmount string     `json:"threshold_amount"`
+	PledgedAmount   string     `json:"pledged_amount"`
+	Pledges         int64      `json:"pledges"`
+	Progress        float64    `json:"progress"`
+	Status          string     `json:"status"`
+	FundedAt        *time.Time `json:"funded_at"`
+	ResolvedAt      *time.Time `json:"resolved_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+type (
+	Request  = middleware.Request[RequestBody]
+	Response = middleware.Response[ResponseBody]
+)
+
+type Handler struct {
+	db         Database
+	challenges ChallengeTracker
+	logger     Logger
+}
+
+func New(db Database, challenges ChallengeTracker, logger Logger) *Handler {
+	return &Handler{db: db, challenges: challenges, logger: logger}
+}
+
+// Handle marks an open challenge completed or failed and announces the outcome
+// on the streamer's overlay. Pledges are kept either way.
+func (h *Handler) Handle(_ context.Context, request Request) (*Response, error) {
+	address, exists := request.Context[middleware.AddressKey].(string)
+	if !exists || address == "" {
+		return &Response{StatusCode: http.StatusUnauthorized}, fmt.Errorf("jwt is not found or api middleware is failed")
+	}
+
+	outcome := request.Body.Outcome
+	if outcome != challenges.StatusCompleted && outcome != challenges.StatusFailed {
+		return &Response{StatusCode: http.StatusBadRequest},
+			fmt.Errorf("outcome must be %s or %s", challenges.StatusCompleted, challenges.StatusFailed)
+	}
+
+	challengeID := request.PathParams["id"]
+
+	const currentQuery = `SELECT status FROM challenges WHERE id = $1 AND owner = $2;`
+
+	var status string
+	err := h.db.QueryRow(currentQuery, challengeID, address).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &Response{StatusCode: http.StatusNotFound}, fmt.Errorf("%w: %s", challenges.ErrChallengeNotFound, challengeID)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to load challenge: %w", err)
+	}
+
+	const updateQuery = `
+		UPDATE challenges
+		SET status = $3, resolved_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND owner = $2 AND status = $4;
+	`
+
+	result, err := h.db.Exec(updateQuery, challengeID, address, outcome, challenges.StatusOpen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve challenge: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve challenge: %w", err)
+	}
+
+	if affected == 0 {
+		return &Response{StatusCode: http.StatusConflict}, challenges.ErrChallengeResolved
+	}
+
+	challenge, err := challenges.Find(h.db, challengeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = h.challenges.Resolved(*challenge); err != nil {
+		h.logger.Info("failed to announce challenge outcome", "challenge", challenge.ID, "error", err.Error())
+	}
+
+	return &Response{
+		Body: ResponseBody{
+			ID:              challenge.ID,
+			Title:           challenge.Title,
+			Description:     challenge.Description,
+			Currency:        challenge.Currency,
+			FiatCurrency:    challenge.FiatCurrency,
+			ThresholdAmount: challenge.Threshold,
+			PledgedAmount:   challenge.Pledged,
+			Pledges:         challenge.Pledges,
+			Progress:        challenge.Percent(),
+			Status:          challenge.Status,
+			FundedAt:        challenge.FundedAt,
+			ResolvedAt:      challenge.ResolvedAt,
+			CreatedAt:       challenge.CreatedAt,
+		},
+		StatusCode: http.StatusOK,
+	}, nil
+}
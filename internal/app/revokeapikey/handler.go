@@ -0,0 +1,47 @@
+package revokeapikey
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"twitch-crypto-donations/internal/pkg/apikeys"
+	"twitch-crypto-donations/internal/pkg/middleware"
+)
+
+type KeyStore interface {
+	Revoke(owner, keyID string) error
+}
+
+type (
+	Request  = middleware.Request[struct{}]
+	Response = middleware.Response[struct{}]
+)
+
+type Handler struct {
+	keys KeyStore
+}
+
+func New(keys KeyStore) *Handler {
+	return &Handler{keys: keys}
+}
+
+// Handle revokes one of the authenticated streamer's API keys. Requests with
+// it are rejected from then on.
+func (h *Handler) Handle(_ context.Context, request Request) (*Response, error) {
+	address, exists := request.Context[middleware.AddressKey].(string)
+	if !exists || address == "" {
+		return &Response{StatusCode: http.StatusUnauthorized}, fmt.Errorf("jwt is not found or api middleware is failed")
+	}
+
+	err := h.keys.Revoke(address, request.PathParams["id"])
+	if errors.Is(err, apikeys.ErrKeyNotFound) {
+		return &Response{StatusCode: http.StatusNotFound}, err
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
@@ -3,15 +3,38 @@ package senddonate
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"twitch-crypto-donations/internal/pkg/amount"
+	"twitch-crypto-donations/internal/pkg/chain"
+	"twitch-crypto-donations/internal/pkg/challenges"
+	"twitch-crypto-donations/internal/pkg/collab"
+	"twitch-crypto-donations/internal/pkg/donationrules"
+	"twitch-crypto-donations/internal/pkg/donations"
+	"twitch-crypto-donations/internal/pkg/goals"
 	"twitch-crypto-donations/internal/pkg/middleware"
 	"twitch-crypto-donations/internal/pkg/obsservice"
+	"twitch-crypto-donations/internal/pkg/polls"
+	"twitch-crypto-donations/internal/pkg/pricing"
+	"twitch-crypto-donations/internal/pkg/solanapay"
+	"twitch-crypto-donations/internal/pkg/splits"
+	"twitch-crypto-donations/internal/pkg/subathon"
+	"twitch-crypto-donations/internal/pkg/txverifier"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type Database interface {
+	Query(query string, args ...any) (*sql.Rows, error)
 	QueryRow(query string, args ...any) *sql.Row
 	Exec(query string, args ...any) (sql.Result, error)
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
 }
 
 type ObsService interface {
@@ -19,18 +42,76 @@ type ObsService interface {
 	WebhookMedia(wallet string, request obsservice.MediaEvent) (any, string, error)
 }
 
+type ChainRegistry interface {
+	Verifier(id chain.ID) (chain.PaymentVerifier, error)
+	Asset(id chain.ID, symbol string) (chain.Asset, bool)
+	Native(id chain.ID) (chain.Asset, bool)
+}
+
+type SplitPolicy interface {
+	Plan(receiver string, gross uint64) ([]splits.Leg, error)
+}
+
+type CollabRegistry interface {
+	Members(groupID string) ([]collab.Member, error)
+}
+
+type RuleRegistry interface {
+	Check(receiver, currency string, units uint64, decimals uint8, features donationrules.Features) ([]donationrules.Violation, error)
+}
+
+type GoalTracker interface {
+	Contribute(db goals.Executor, contribution goals.Contribution) (*goals.Progress, error)
+	Notify(progress goals.Progress, username *string) error
+}
+
+type SubathonTimer interface {
+	Extend(db subathon.Executor, donation subathon.Donation) (*subathon.Extension, error)
+	Notify(owner string, timer *subathon.Timer, added int64, username *string) error
+}
+
+type PollTracker interface {
+	Validate(db polls.Executor, receiver, optionID, currency string) error
+	Record(db polls.Executor, vote polls.Vote) error
+}
+
+type ChallengeTracker interface {
+	Validate(db challenges.Executor, receiver, challengeID, currency string) error
+	Pledge(db challenges.Executor, pledge challenges.Pledge) (*challenges.Progress, error)
+	Notify(progress challenges.Progress, username *string) error
+}
+
+type Valuer interface {
+	Value(ctx context.Context, symbol string, units uint64, decimals uint8) *pricing.Valuation
+}
+
 type RequestBody struct {
-	Receiver       string   `json:"receiver"`
-	SenderUsername *string  `json:"sender_username"`
-	Amount         *float64 `json:"amount"`
-	Currency       *string  `json:"currency"`
-	Message        *string  `json:"message"`
-	DurationMs     *int64   `json:"duration_ms"`
+	Signature      string       `json:"signature"`
+	SenderAddress  string       `json:"sender_address"`
+	Receiver       string       `json:"receiver"`
+	SenderUsername *string      `json:"sender_username"`
+	Amount         *json.Number `json:"amount"`
+	Currency       *string      `json:"currency"`
+	Chain          *string      `json:"chain"`
+	Message        *string      `json:"message"`
+	DurationMs     *int64       `json:"duration_ms"`
+	CollabGroupID  *string      `json:"collab_group_id"`
+	PollOptionID   *string      `json:"poll_option_id"`
+	ChallengeID    *string      `json:"challenge_id"`
 
 	AlertEvent *AlertRequest `json:"alert_event"`
 	MediaEvent *MediaRequest `json:"media_event"`
 }
 
+// chainID is the chain the payment was made on, Solana unless specified.
+func (b RequestBody) chainID() chain.ID {
+	if b.Chain == nil {
+		return chain.Solana
+	}
+
+	return chain.ID(*b.Chain)
+}
+
 type AlertRequest struct {
 	Enable            bool    `json:"enable"`
 	NotificationSound *string `json:"notification_sound"`
@@ -58,6 +139,46 @@ type Error struct {
 	Type    string `json:"type"`
 }
 
+// overlay is the content of a donation's overlay events. It is stored with the
+// donation, so a resubmitted payment resends the original events instead of
+// whatever the resubmitting client sends: signatures are public on-chain.
+type overlay struct {
+	SenderUsername *string       `json:"sender_username"`
+	Message        *string       `json:"message"`
+	DurationMs     *int64        `json:"duration_ms"`
+	AlertEvent     *AlertRequest `json:"alert_event"`
+	MediaEvent     *MediaRequest `json:"media_event"`
+}
+
+// failedDonation is a donation of a payment whose overlay events failed.
+type failedDonation struct {
+	id      string
+	overlay []byte
+}
+
+type payment struct {
+	chain     chain.ID
+	asset     chain.Asset
+	units     uint64
+	memos     []string
+	height    uint64
+	blockhash string
+	shares    []share
+}
+
+// share is the part of a donation credited to one receiver. A plain donation
+// has a single share, a collab donation one per group member.
+type share struct {
+	donationID string
+	receiver   string
+	units      uint64
+	legs       []splits.Leg
+	valuation  *pricing.Valuation
+	goal       *goals.Progress
+	extension  *subathon.Extension
+	challenge  *challenges.Progress
+}
+
 type (
 	Request  = middleware.Request[RequestBody]
 	Response = middleware.Response[ResponseBody]
@@ -66,70 +187,683 @@ type (
 type Handler struct {
 	obsService ObsService
 	db         Database
+	chains     ChainRegistry
+	splits     SplitPolicy
+	collabs    CollabRegistry
+	rules      RuleRegistry
+	goals      GoalTracker
+	timers     SubathonTimer
+	polls      PollTracker
+	challenges ChallengeTracker
+	valuer     Valuer
+}
+
+func New(
+	obsService ObsService,
+	db Database,
+	chains ChainRegistry,
+	splits SplitPolicy,
+	collabs CollabRegistry,
+	rules RuleRegistry,
+	goals GoalTracker,
+	timers SubathonTimer,
+	polls PollTracker,
+	challenges ChallengeTracker,
+	valuer Valuer,
+) *Handler {
+	return &Handler{
+		obsService: obsService,
+		db:         db,
+		chains:     chains,
+		splits:     splits,
+		collabs:    collabs,
+		rules:      rules,
+		goals:      goals,
+		timers:     timers,
+		polls:      polls,
+		challenges: challenges,
+		valuer:     valuer,
+	}
+}
+
+func (h *Handler) Handle(ctx context.Context, request Request) (*Response, error) {
+	// The signature is claimed, stored and looked up in one form only, so the
+	// same transaction cannot be credited again under another spelling.
+	request.Body.Signature = chain.Reference(request.Body.chainID(), request.Body.Signature)
+
+	verified, errors := h.verifyPayment(ctx, request.Body)
+	if len(errors) > 0 {
+		return &Response{Body: ResponseBody{Errors: errors}, StatusCode: http.StatusPaymentRequired}, nil
+	}
+
+	request.Body.Currency = &verified.asset.Symbol
+	for i := range verified.shares {
+		share := &verified.shares[i]
+		share.donationID = uuid.NewString()
+		share.valuation = h.valuer.Value(ctx, verified.asset.Symbol, share.units, verified.asset.Decimals)
+	}
+
+	if request.Body.Message == nil {
+		message, err := h.memoMessage(verified)
+		if err != nil {
+			return nil, err
+		}
+
+		request.Body.Message = message
+	}
+
+	value, err := strconv.ParseFloat(amount.Format(verified.units, verified.asset.Decimals), 64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format amount: %w", err)
+	}
+
+	claimed, err := h.claimSignature(ctx, request.Body, verified)
+	if err != nil {
+		return nil, err
+	}
+
+	if !claimed {
+		return h.resendEvents(request, verified, value)
+	}
+
+	response := ResponseBody{Errors: make([]Error, 0, 2)}
+
+	if err = h.deliver(request, verified.shares, verified.asset, value, &response); err != nil {
+		return nil, err
+	}
+
+	for _, share := range verified.shares {
+		if share.goal == nil {
+			continue
+		}
+
+		if err = h.goals.Notify(*share.goal, request.Body.SenderUsername); err != nil {
+			response.Errors = append(response.Errors, Error{Message: err.Error(), Type: "goal_event"})
+		}
+	}
+
+	for _, share := range verified.shares {
+		if share.extension == nil {
+			continue
+		}
+
+		err = h.timers.Notify(share.receiver, &share.extension.Timer, share.extension.Seconds, request.Body.SenderUsername)
+		if err != nil {
+			response.Errors = append(response.Errors, Error{Message: err.Error(), Type: "timer_event"})
+		}
+	}
+
+	for _, share := range verified.shares {
+		if share.challenge == nil {
+			continue
+		}
+
+		if err = h.challenges.Notify(*share.challenge, request.Body.SenderUsername); err != nil {
+			response.Errors = append(response.Errors, Error{Message: err.Error(), Type: "challenge_event"})
+		}
+	}
+
+	if len(response.Errors) > 0 {
+		return &Response{Body: response, StatusCode: http.StatusInternalServerError}, nil
+	}
+
+	return &Response{Body: response}, nil
 }
 
-func New(obsService ObsService, db Database) *Handler {
-	return &Handler{obsService: obsService, db: db}
+// deliver sends the overlay events of each share and records the outcome on its
+// donation. Delivery errors are added to the response.
+func (h *Handler) deliver(request Request, shares []share, asset chain.Asset, value float64, response *ResponseBody) error {
+	for _, share := range shares {
+		channels, errors := h.sendEvents(request.Body, share.receiver, value)
+		if len(errors) > 0 {
+			response.Errors = append(response.Errors, errors...)
+
+			if err := donations.Transition(h.db, share.donationID, donations.StateAlertFailed, joinErrors(errors)); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		// Without its history row the donation is not done either, so it is left
+		// in alert_failed for a resubmission to deliver and record it again.
+		if errors := h.saveDonation(request, share, asset, channels); len(errors) > 0 {
+			response.Errors = append(response.Errors, errors...)
+
+			if err := donations.Transition(h.db, share.donationID, donations.StateAlertFailed, joinErrors(errors)); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if len(channels) > 0 {
+			if err := donations.Transition(h.db, share.donationID, donations.StateAlertDelivered, "overlay events delivered"); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
 }
 
-func (h *Handler) Handle(_ context.Context, request Request) (*Response, error) {
+// resendEvents handles a resubmitted payment. Shares whose donation is still in
+// alert_failed get their stored overlay events sent again; a payment without
+// failed alerts has already been used.
+func (h *Handler) resendEvents(request Request, verified *payment, value float64) (*Response, error) {
+	failed, err := h.failedDonations(request.Body.Signature)
+	if err != nil {
+		return nil, err
+	}
+
+	var stored []byte
+	retried := make([]share, 0, len(failed))
+	for _, share := range verified.shares {
+		if donation, ok := failed[share.receiver]; ok {
+			share.donationID = donation.id
+			stored = donation.overlay
+			retried = append(retried, share)
+		}
+	}
+
+	if len(retried) == 0 {
+		return &Response{
+			Body: ResponseBody{Errors: []Error{{
+				Message: "transaction signature has already been used for a donation",
+				Type:    "payment_already_used",
+			}}},
+			StatusCode: http.StatusConflict,
+		}, nil
+	}
+
+	// Every share of a payment is created from the same request, so they all
+	// store the same overlay content.
+	var content overlay
+	if err = json.Unmarshal(stored, &content); err != nil {
+		return nil, fmt.Errorf("invalid overlay content of donation %s: %w", retried[0].donationID, err)
+	}
+
+	request.Body.SenderUsername = content.SenderUsername
+	request.Body.Message = content.Message
+	request.Body.DurationMs = content.DurationMs
+	request.Body.AlertEvent = content.AlertEvent
+	request.Body.MediaEvent = content.MediaEvent
+
 	response := ResponseBody{Errors: make([]Error, 0, 2)}
+
+	if err = h.deliver(request, retried, verified.asset, value, &response); err != nil {
+		return nil, err
+	}
+
+	if len(response.Errors) > 0 {
+		return &Response{Body: response, StatusCode: http.StatusInternalServerError}, nil
+	}
+
+	return &Response{Body: response}, nil
+}
+
+// failedDonations maps the receivers of the payment's donations whose alerts
+// failed to those donations. Only donations made through send-donate store
+// their overlay content and can be resent.
+func (h *Handler) failedDonations(signature string) (map[string]failedDonation, error) {
+	const query = `
+		SELECT id, receiver, overlay
+		FROM donations
+		WHERE tx_signature = $1 AND state = $2 AND overlay IS NOT NULL;
+	`
+
+	rows, err := h.db.Query(query, signature, string(donations.StateAlertFailed))
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	failed := make(map[string]failedDonation)
+	for rows.Next() {
+		var (
+			donation failedDonation
+			receiver string
+		)
+		if err = rows.Scan(&donation.id, &receiver, &donation.overlay); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		failed[receiver] = donation
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return failed, nil
+}
+
+func (h *Handler) sendEvents(body RequestBody, receiver string, value float64) (map[string]struct{}, []Error) {
+	errors := make([]Error, 0, 2)
 	channels := make(map[string]struct{})
 
-	if request.Body.MediaEvent != nil && request.Body.MediaEvent.Enable {
-		_, channel, err := h.obsService.WebhookMedia(request.Body.Receiver, obsservice.MediaEvent{
-			Username:   request.Body.SenderUsername,
-			Amount:     request.Body.Amount,
-			Currency:   request.Body.Currency,
-			Message:    request.Body.Message,
-			DurationMs: request.Body.DurationMs,
-			YoutubeUrl: request.Body.MediaEvent.YoutubeUrl,
-			StartTime:  request.Body.MediaEvent.StartTime,
-			EndTime:    request.Body.MediaEvent.EndTime,
-			AutoPlay:   request.Body.MediaEvent.AutoPlay,
-			Controls:   request.Body.MediaEvent.Controls,
-			Mute:       request.Body.MediaEvent.Mute,
+	if body.MediaEvent != nil && body.MediaEvent.Enable {
+		_, channel, err := h.obsService.WebhookMedia(receiver, obsservice.MediaEvent{
+			Username:   body.SenderUsername,
+			Amount:     &value,
+			Currency:   body.Currency,
+			Message:    body.Message,
+			DurationMs: body.DurationMs,
+			YoutubeUrl: body.MediaEvent.YoutubeUrl,
+			StartTime:  body.MediaEvent.StartTime,
+			EndTime:    body.MediaEvent.EndTime,
+			AutoPlay:   body.MediaEvent.AutoPlay,
+			Controls:   body.MediaEvent.Controls,
+			Mute:       body.MediaEvent.Mute,
 		})
 
 		if err != nil {
-			response.Errors = append(response.Errors, Error{Message: err.Error()})
+			errors = append(errors, Error{Message: err.Error()})
 		}
 
 		channels[channel] = struct{}{}
 	}
 
-	if request.Body.AlertEvent != nil && request.Body.AlertEvent.Enable {
-		_, channel, err := h.obsService.WebhookAlert(request.Body.Receiver, obsservice.AlertEvent{
-			Username:          request.Body.SenderUsername,
-			Amount:            request.Body.Amount,
-			Currency:          request.Body.Currency,
-			Message:           request.Body.Message,
-			DurationMs:        request.Body.DurationMs,
-			NotificationSound: request.Body.AlertEvent.NotificationSound,
-			VoiceUrl:          request.Body.AlertEvent.VoiceUrl,
-			ImageUrl:          request.Body.AlertEvent.ImageUrl,
-			GifUrl:            request.Body.AlertEvent.GifUrl,
+	if body.AlertEvent != nil && body.AlertEvent.Enable {
+		_, channel, err := h.obsService.WebhookAlert(receiver, obsservice.AlertEvent{
+			Username:          body.SenderUsername,
+			Amount:            &value,
+			Currency:          body.Currency,
+			Message:           body.Message,
+			DurationMs:        body.DurationMs,
+			NotificationSound: body.AlertEvent.NotificationSound,
+			VoiceUrl:          body.AlertEvent.VoiceUrl,
+			ImageUrl:          body.AlertEvent.ImageUrl,
+			GifUrl:            body.AlertEvent.GifUrl,
 		})
 
 		if err != nil {
-			response.Errors = append(response.Errors, Error{Message: err.Error()})
+			errors = append(errors, Error{Message: err.Error()})
 		}
 
 		channels[channel] = struct{}{}
 	}
 
-	if len(response.Errors) > 0 {
-		return &Response{Body: response, StatusCode: http.StatusInternalServerError}, nil
+	return channels, errors
+}
+
+func (h *Handler) verifyPayment(ctx context.Context, body RequestBody) (*payment, []Error) {
+	if body.Signature == "" || body.SenderAddress == "" {
+		return nil, []Error{{Message: "transaction signature and sender address are required", Type: "payment_required"}}
 	}
 
-	if errors := h.saveDonation(request, channels); len(errors) > 0 {
-		return &Response{Body: ResponseBody{Errors: errors}, StatusCode: http.StatusInternalServerError}, nil
+	if body.Amount == nil {
+		return nil, []Error{{Message: "donation amount is required", Type: "payment_required"}}
 	}
 
-	return &Response{Body: response}, nil
+	chainID := body.chainID()
+	verifier, err := h.chains.Verifier(chainID)
+	if err != nil {
+		return nil, []Error{{Message: err.Error(), Type: "unsupported_chain"}}
+	}
+
+	asset, ok := h.chains.Native(chainID)
+	if body.Currency != nil {
+		asset, ok = h.chains.Asset(chainID, *body.Currency)
+	}
+
+	if !ok {
+		return nil, []Error{{Message: fmt.Sprintf("unsupported currency on %s", chainID), Type: "unsupported_currency"}}
+	}
+
+	expected, err := amount.Parse(body.Amount.String(), asset.Decimals)
+	if err != nil {
+		return nil, []Error{{Message: err.Error(), Type: "payment_verification"}}
+	}
+
+	if expected == 0 {
+		return nil, []Error{{Message: "donation amount is required", Type: "payment_required"}}
+	}
+
+	if failures := h.checkRules(body, asset, expected); len(failures) > 0 {
+		return nil, failures
+	}
+
+	if body.PollOptionID != nil {
+		if err = h.polls.Validate(h.db, body.Receiver, *body.PollOptionID, asset.Symbol); err != nil {
+			return nil, []Error{{Message: err.Error(), Type: "invalid_poll_choice"}}
+		}
+	}
+
+	if body.ChallengeID != nil {
+		if err = h.challenges.Validate(h.db, body.Receiver, *body.ChallengeID, asset.Symbol); err != nil {
+			return nil, []Error{{Message: err.Error(), Type: "invalid_challenge"}}
+		}
+	}
+
+	shares, failures := h.divide(body, chainID, expected)
+	if len(failures) > 0 {
+		return nil, failures
+	}
+
+	transfers := make([]chain.Transfer, 0, len(shares))
+	for i := range shares {
+		legs, failures := h.legs(chainID, shares[i])
+		if len(failures) > 0 {
+			return nil, failures
+		}
+
+		shares[i].legs = legs
+		for _, leg := range legs {
+			transfers = append(transfers, chain.Transfer{
+				Sender:    body.SenderAddress,
+				Recipient: leg.Recipient,
+				Token:     asset.Token,
+				Amount:    leg.Amount,
+			})
+		}
+	}
+
+	result, err := verifier.Verify(ctx, body.Signature, transfers)
+
+	if mismatch, ok := verifier.Explain(err); ok {
+		return nil, []Error{{Message: mismatch.Message, Type: mismatch.Reason}}
+	}
+
+	if err != nil {
+		return nil, []Error{{Message: err.Error(), Type: "payment_verification"}}
+	}
+
+	return &payment{
+		chain:     chainID,
+		asset:     asset,
+		units:     expected,
+		memos:     result.Memos,
+		height:    result.Height,
+		blockhash: result.Blockhash,
+		shares:    shares,
+	}, nil
 }
 
-func (h *Handler) saveDonation(request Request, channels map[string]struct{}) []Error {
+// memoMessage takes the donation text from the transaction's memos, skipping
+// the ones written by the app itself: the transfer request memo and donation
+// IDs, including those of transactions built by /api/donation-transactions.
+func (h *Handler) memoMessage(verified *payment) (*string, error) {
+	if len(verified.memos) == 0 {
+		return nil, nil
+	}
+
+	ignore := []string{solanapay.TransferMemo}
+	for _, share := range verified.shares {
+		ignore = append(ignore, share.donationID)
+	}
+
+	const query = `SELECT donation_id FROM payment_requests WHERE donation_id = ANY($1);`
+
+	rows, err := h.db.Query(query, pq.Array(verified.memos))
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up memo donation IDs: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var donationID string
+		if err = rows.Scan(&donationID); err != nil {
+			return nil, fmt.Errorf("failed to scan memo donation ID: %w", err)
+		}
+
+		ignore = append(ignore, donationID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to look up memo donation IDs: %w", err)
+	}
+
+	return txverifier.Message(verified.memos, ignore...), nil
+}
+
+// legs plans the transfers that pay a share. Revenue splits and the platform
+// fee are paid to Solana wallets, so on other chains the whole share goes to the
+// receiver's EVM address.
+func (h *Handler) legs(chainID chain.ID, share share) ([]splits.Leg, []Error) {
+	if chainID == chain.Solana {
+		legs, err := h.splits.Plan(share.receiver, share.units)
+		if errors.Is(err, splits.ErrInvalidSplit) {
+			return nil, []Error{{Message: err.Error(), Type: "invalid_split"}}
+		}
+
+		if err != nil {
+			return nil, []Error{{Message: err.Error(), Type: "payment_verification"}}
+		}
+
+		return legs, nil
+	}
+
+	const query = `SELECT evm_address FROM users WHERE wallet = $1;`
+
+	var address sql.NullString
+	err := h.db.QueryRow(query, share.receiver).Scan(&address)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, []Error{{Message: fmt.Sprintf("failed to load evm address: %s", err), Type: "payment_verification"}}
+	}
+
+	if !address.Valid || address.String == "" {
+		return nil, []Error{{
+			Message: fmt.Sprintf("receiver %s has no EVM address for donations on %s", share.receiver, chainID),
+			Type:    "unsupported_chain",
+		}}
+	}
+
+	return []splits.Leg{{Recipient: address.String, Kind: splits.KindStreamer, Amount: share.units}}, nil
+}
+
+// checkRules rejects overlay features the receiver's donation rules price above
+// the donated amount. It runs before the signature is claimed, so the donor can
+// resubmit the same payment without them.
+func (h *Handler) checkRules(body RequestBody, asset chain.Asset, units uint64) []Error {
+	alert := body.AlertEvent != nil && body.AlertEvent.Enable
+	features := donationrules.Features{
+		Alert: alert,
+		Voice: alert && body.AlertEvent.VoiceUrl != nil,
+		Media: body.MediaEvent != nil && body.MediaEvent.Enable,
+	}
+
+	if features.Media {
+		features.MediaSeconds = donationrules.MediaSeconds(body.MediaEvent.StartTime, body.MediaEvent.EndTime, body.DurationMs)
+	}
+
+	violations, err := h.rules.Check(body.Receiver, asset.Symbol, units, asset.Decimals, features)
+	if err != nil {
+		return []Error{{Message: err.Error(), Type: "payment_verification"}}
+	}
+
+	failures := make([]Error, 0, len(violations))
+	for _, violation := range violations {
+		failures = append(failures, Error{Message: violation.Message, Type: violation.Reason})
+	}
+
+	return failures
+}
+
+// divide assigns the gross amount to the receiver, or across the members of the
+// collab group the receiver is donating through. Collab donations are paid on
+// Solana only.
+func (h *Handler) divide(body RequestBody, chainID chain.ID, gross uint64) ([]share, []Error) {
+	if body.CollabGroupID == nil {
+		return []share{{receiver: body.Receiver, units: gross}}, nil
+	}
+
+	if chainID != chain.Solana {
+		return nil, []Error{{
+			Message: fmt.Sprintf("collab donations are paid on %s, not %s", chain.Solana, chainID),
+			Type:    "invalid_collab_group",
+		}}
+	}
+
+	members, err := h.collabs.Members(*body.CollabGroupID)
+	if errors.Is(err, collab.ErrGroupNotFound) || errors.Is(err, collab.ErrGroupInactive) {
+		return nil, []Error{{Message: err.Error(), Type: "invalid_collab_group"}}
+	}
+
+	if err != nil {
+		return nil, []Error{{Message: err.Error(), Type: "payment_verification"}}
+	}
+
+	isMember := func(m collab.Member) bool { return m.Wallet == body.Receiver }
+	if !slices.ContainsFunc(members, isMember) {
+		return nil, []Error{{
+			Message: fmt.Sprintf("receiver %s is not a member of collab group %s", body.Receiver, *body.CollabGroupID),
+			Type:    "invalid_collab_group",
+		}}
+	}
+
+	portions := collab.Divide(gross, members)
+	shares := make([]share, 0, len(members))
+	for i, m := range members {
+		shares = append(shares, share{receiver: m.Wallet, units: portions[i]})
+	}
+
+	return shares, nil
+}
+
+func (h *Handler) claimSignature(ctx context.Context, body RequestBody, verified *payment) (bool, error) {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	const insertQuery = `
+		INSERT INTO used_signatures (signature, sender_address, receiver)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (signature) DO NOTHING;
+	`
+
+	result, err := tx.Exec(insertQuery, body.Signature, body.SenderAddress, body.Receiver)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim transaction signature: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim transaction signature: %w", err)
+	}
+
+	if affected != 1 {
+		return false, nil
+	}
+
+	username := ""
+	if body.SenderUsername != nil {
+		username = *body.SenderUsername
+	}
+
+	content, err := json.Marshal(overlay{
+		SenderUsername: body.SenderUsername,
+		Message:        body.Message,
+		DurationMs:     body.DurationMs,
+		AlertEvent:     body.AlertEvent,
+		MediaEvent:     body.MediaEvent,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to encode overlay content: %w", err)
+	}
+
+	for i := range verified.shares {
+		share := &verified.shares[i]
+
+		err = donations.Create(tx, donations.Donation{
+			ID:             share.donationID,
+			Chain:          verified.chain,
+			Receiver:       share.receiver,
+			SenderAddress:  &body.SenderAddress,
+			SenderUsername: username,
+			Amount:         share.units,
+			Decimals:       verified.asset.Decimals,
+			Currency:       verified.asset.Symbol,
+			TxSignature:    &body.Signature,
+			TxHeight:       verified.height,
+			TxBlockhash:    verified.blockhash,
+			CollabGroupID:  body.CollabGroupID,
+			Overlay:        content,
+		}, donations.StatePaymentSeen, "transaction submitted by client")
+		if err != nil {
+			return false, err
+		}
+
+		if err = splits.Save(tx, share.donationID, share.legs); err != nil {
+			return false, err
+		}
+
+		err = donations.Transition(tx, share.donationID, donations.StateConfirmed, "transfer verified at confirmed commitment")
+		if err != nil {
+			return false, err
+		}
+
+		if share.valuation != nil {
+			if err = donations.AttachValuation(tx, share.donationID, *share.valuation); err != nil {
+				return false, err
+			}
+		}
+
+		share.goal, err = h.goals.Contribute(tx, goals.Contribution{
+			DonationID: share.donationID,
+			Receiver:   share.receiver,
+			Currency:   verified.asset.Symbol,
+			Units:      share.units,
+			Decimals:   verified.asset.Decimals,
+			Valuation:  share.valuation,
+		})
+		if err != nil {
+			return false, err
+		}
+
+		share.extension, err = h.timers.Extend(tx, subathon.Donation{
+			DonationID: share.donationID,
+			Receiver:   share.receiver,
+			Currency:   verified.asset.Symbol,
+			Units:      share.units,
+			Decimals:   verified.asset.Decimals,
+		})
+		if err != nil {
+			return false, err
+		}
+
+		// The poll belongs to the receiver the donor picked, so only the share
+		// paid to them votes.
+		if body.PollOptionID != nil && share.receiver == body.Receiver {
+			err = h.polls.Record(tx, polls.Vote{
+				OptionID:   *body.PollOptionID,
+				DonationID: share.donationID,
+				Receiver:   share.receiver,
+				Currency:   verified.asset.Symbol,
+				Units:      share.units,
+				Decimals:   verified.asset.Decimals,
+				Valuation:  share.valuation,
+			})
+			if err != nil {
+				return false, err
+			}
+		}
+
+		if body.ChallengeID != nil && share.receiver == body.Receiver {
+			share.challenge, err = h.challenges.Pledge(tx, challenges.Pledge{
+				ChallengeID: *body.ChallengeID,
+				DonationID:  share.donationID,
+				Receiver:    share.receiver,
+				Currency:    verified.asset.Symbol,
+				Units:       share.units,
+				Decimals:    verified.asset.Decimals,
+				Valuation:   share.valuation,
+			})
+			if err != nil {
+				return false, err
+			}
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return true, nil
+}
+
+func (h *Handler) saveDonation(request Request, share share, asset chain.Asset, channels map[string]struct{}) []Error {
 	errors := make([]Error, 0, len(channels))
 
 	for channel := range channels {
@@ -140,21 +874,11 @@ func (h *Handler) saveDonation(request Request, channels map[string]struct{}) []
 			layout = "alert"
 		}
 
-		amount := ""
-		if request.Body.Amount != nil {
-			amount = fmt.Sprintf("%f", *request.Body.Amount)
-		}
-
 		username := ""
 		if request.Body.SenderUsername != nil {
 			username = *request.Body.SenderUsername
 		}
 
-		currency := ""
-		if request.Body.Currency != nil {
-			currency = *request.Body.Currency
-		}
-
 		var durationMs *float64
 		if request.Body.DurationMs != nil {
 			duration := float64(*request.Body.DurationMs)
@@ -173,12 +897,14 @@ func (h *Handler) saveDonation(request Request, channels map[string]struct{}) []
 
 		_, err := h.db.Exec(
 			`INSERT INTO donations_history 
-			(receiver, donation_amount, sender_username, currency, text, audio_url, image_url, duration_ms, layout, channel) 
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
-			request.Body.Receiver, amount,
-			username, currency, request.Body.Message,
+			(receiver, amount, decimals, sender_username, currency, text, audio_url, image_url, duration_ms, layout, channel, sender_address, tx_signature, donation_id) 
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`,
+			share.receiver, strconv.FormatUint(share.units, 10), asset.Decimals,
+			username, asset.Symbol, request.Body.Message,
 			audioURL, imageURL, durationMs,
 			layout, channel,
+			request.Body.SenderAddress, request.Body.Signature,
+			share.donationID,
 		)
 
 		if err != nil {
@@ -188,3 +914,12 @@ func (h *Handler) saveDonation(request Request, channels map[string]struct{}) []
 
 	return errors
 }
+
+func joinErrors(errs []Error) string {
+	messages := make([]string, 0, len(errs))
+	for _, e := range errs {
+		messages = append(messages, e.Message)
+	}
+
+	return strings.Join(messages, "; ")
+}
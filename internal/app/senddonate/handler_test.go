@@ -0,0 +1,290 @@
+package senddonate
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"twitch-crypto-donations/internal/pkg/chain"
+	"twitch-crypto-donations/internal/pkg/donationrules"
+	"twitch-crypto-donations/internal/pkg/goals"
+	"twitch-crypto-donations/internal/pkg/obsservice"
+	"twitch-crypto-donations/internal/pkg/pricing"
+	"twitch-crypto-donations/internal/pkg/subathon"
+)
+
+const (
+	receiver   = "streamer-wallet"
+	evmAddress = "0x2222222222222222222222222222222222222222"
+	donor      = "0x1111111111111111111111111111111111111111"
+	hash       = "0xabcdefabcdefabcdefabcdefabcdefabcdefabcdefabcdefabcdefabcdefabcd"
+)
+
+func TestHandleCreditsAnEVMHashOnceInAnyCase(t *testing.T) {
+	store := newFakeStore()
+	obs := &fakeObs{}
+
+	chains := chain.NewRegistry()
+	chains.Register(chain.Ethereum, fakeVerifier{hash: hash}, []chain.Asset{{Symbol: "ETH", Decimals: 18}})
+
+	handler := New(obs, sql.OpenDB(fakeConnector{store: store}), chains, nil, nil,
+		fakeRules{}, fakeGoals{}, fakeTimers{}, nil, nil, fakeValuer{})
+
+	first, err := handler.Handle(context.Background(), Request{Body: body(strings.ToUpper(hash))})
+	if err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	if first.StatusCode != 0 && first.StatusCode != http.StatusOK {
+		t.Fatalf("Handle() status = %d, errors = %v", first.StatusCode, first.Body.Errors)
+	}
+
+	second, err := handler.Handle(context.Background(), Request{Body: body(hash)})
+	if err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	if second.StatusCode != http.StatusConflict {
+		t.Fatalf("resubmitted Handle() status = %d, want %d", second.StatusCode, http.StatusConflict)
+	}
+
+	if alerts := obs.count(); alerts != 1 {
+		t.Fatalf("sent %d alerts, want 1", alerts)
+	}
+
+	if claimed := store.claimed(); len(claimed) != 1 || claimed[0] != hash {
+		t.Fatalf("claimed signatures = %v, want [%s]", claimed, hash)
+	}
+}
+
+func body(signature string) RequestBody {
+	ethereum := string(chain.Ethereum)
+	value := json.Number("0.5")
+	username := "donor"
+
+	return RequestBody{
+		Signature:      signature,
+		SenderAddress:  donor,
+		Receiver:       receiver,
+		SenderUsername: &username,
+		Amount:         &value,
+		Chain:          &ethereum,
+		AlertEvent:     &AlertRequest{Enable: true},
+	}
+}
+
+// fakeVerifier accepts the one transaction it knows, whatever the case of its
+// hash, as an EVM node does.
+type fakeVerifier struct {
+	hash string
+}
+
+func (v fakeVerifier) Verify(_ context.Context, reference string, expected []chain.Transfer) (*chain.Result, error) {
+	if !strings.EqualFold(reference, v.hash) {
+		return nil, errors.New("transaction not found")
+	}
+
+	var total uint64
+	for _, transfer := range expected {
+		total += transfer.Amount
+	}
+
+	return &chain.Result{Height: 1, Sender: donor, Amount: total}, nil
+}
+
+func (v fakeVerifier) Status(context.Context, string) (chain.Status, error) {
+	return chain.StatusConfirmed, nil
+}
+
+func (v fakeVerifier) Expired(context.Context, uint64, string) (bool, error) {
+	return false, nil
+}
+
+func (v fakeVerifier) Explain(err error) (*chain.Mismatch, bool) {
+	return chain.Explain(err)
+}
+
+// fakeStore keeps the claimed signatures and answers the lookups send-donate
+// makes for an EVM donation. The other statements succeed without effect.
+type fakeStore struct {
+	mu   sync.Mutex
+	used []string
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{}
+}
+
+func (s *fakeStore) claimed() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.used...)
+}
+
+func (s *fakeStore) exec(query string, args []driver.NamedValue) (driver.Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if strings.Contains(query, "INSERT INTO used_signatures") {
+		signature := args[0].Value.(string)
+		for _, used := range s.used {
+			if used == signature {
+				return driver.RowsAffected(0), nil
+			}
+		}
+		s.used = append(s.used, signature)
+	}
+
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStore) query(query string, _ []driver.NamedValue) (driver.Rows, error) {
+	switch {
+	case strings.Contains(query, "FROM users"):
+		return &fakeRows{columns: []string{"evm_address"}, values: [][]driver.Value{{evmAddress}}}, nil
+	case strings.Contains(query, "FROM donations"):
+		return &fakeRows{columns: []string{"id", "receiver", "overlay"}}, nil
+	}
+
+	return nil, fmt.Errorf("unexpected query: %s", query)
+}
+
+type fakeConnector struct {
+	store *fakeStore
+}
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) {
+	return &fakeConn{store: c.store}, nil
+}
+
+func (c fakeConnector) Driver() driver.Driver {
+	return fakeDriver{store: c.store}
+}
+
+type fakeDriver struct {
+	store *fakeStore
+}
+
+func (d fakeDriver) Open(string) (driver.Conn, error) {
+	return &fakeConn{store: d.store}, nil
+}
+
+type fakeConn struct {
+	store *fakeStore
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("prepared statements are not supported")
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return c, nil
+}
+
+func (c *fakeConn) Commit() error {
+	return nil
+}
+
+func (c *fakeConn) Rollback() error {
+	return nil
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return c.store.exec(query, args)
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return c.store.query(query, args)
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+	next    int
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.values) {
+		return io.EOF
+	}
+
+	copy(dest, r.values[r.next])
+	r.next++
+
+	return nil
+}
+
+type fakeObs struct {
+	mu     sync.Mutex
+	alerts []obsservice.AlertEvent
+}
+
+func (o *fakeObs) WebhookAlert(_ string, request obsservice.AlertEvent) (any, string, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.alerts = append(o.alerts, request)
+
+	return nil, "channel", nil
+}
+
+func (o *fakeObs) WebhookMedia(string, obsservice.MediaEvent) (any, string, error) {
+	return nil, "channel", nil
+}
+
+func (o *fakeObs) count() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.alerts)
+}
+
+type fakeRules struct{}
+
+func (fakeRules) Check(string, string, uint64, uint8, donationrules.Features) ([]donationrules.Violation, error) {
+	return nil, nil
+}
+
+type fakeValuer struct{}
+
+func (fakeValuer) Value(context.Context, string, uint64, uint8) *pricing.Valuation {
+	return nil
+}
+
+type fakeGoals struct{}
+
+func (fakeGoals) Contribute(goals.Executor, goals.Contribution) (*goals.Progress, error) {
+	return nil, nil
+}
+
+func (fakeGoals) Notify(goals.Progress, *string) error {
+	return nil
+}
+
+type fakeTimers struct{}
+
+func (fakeTimers) Extend(subathon.Executor, subathon.Donation) (*subathon.Extension, error) {
+	return nil, nil
+}
+
+func (fakeTimers) Notify(string, *subathon.Timer, int64, *string) error {
+	return nil
+}
@@ -0,0 +1,98 @@
+package sendtestalert
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"twitch-crypto-donations/internal/pkg/middleware"
+	"twitch-crypto-donations/internal/pkg/obsservice"
+)
+
+const (
+	defaultUsername = "KapachiPay"
+	defaultMessage  = "This is a test alert"
+	defaultAmount   = 1.0
+	defaultCurrency = "SOL"
+
+	// testPrefix is put before the username, so viewers can tell a test alert
+	// from a donation on overlays that ignore the event's test flag.
+	testPrefix = "[TEST] "
+)
+
+type ObsService interface {
+	WebhookAlert(wallet string, request obsservice.AlertEvent) (any, string, error)
+}
+
+type RequestBody struct {
+	Username   *string  `json:"username"`
+	Amount     *float64 `json:"amount"`
+	Currency   *string  `json:"currency"`
+	Message    *string  `json:"message"`
+	DurationMs *int64   `json:"duration_ms"`
+}
+
+type ResponseBody struct {
+	Channel string `json:"channel"`
+}
+
+type (
+	Request  = middleware.Request[RequestBody]
+	Response = middleware.Response[ResponseBody]
+)
+
+type Handler struct {
+	obsService ObsService
+}
+
+func New(obsService ObsService) *Handler {
+	return &Handler{obsService: obsService}
+}
+
+// Handle shows an alert on the authenticated streamer's overlay without a
+// donation, to check the overlay setup. Nothing is recorded, and the alert is
+// flagged as a test so it is not taken for a donation.
+func (h *Handler) Handle(_ context.Context, request Request) (*Response, error) {
+	address, exists := request.Context[middleware.AddressKey].(string)
+	if !exists || address == "" {
+		return &Response{StatusCode: http.StatusUnauthorized}, fmt.Errorf("jwt is not found or api middleware is failed")
+	}
+
+	event := obsservice.AlertEvent{
+		Username:   request.Body.Username,
+		Amount:     request.Body.Amount,
+		Currency:   request.Body.Currency,
+		Message:    request.Body.Message,
+		DurationMs: request.Body.DurationMs,
+		Test:       true,
+	}
+
+	username := defaultUsername
+	if event.Username != nil {
+		username = *event.Username
+	}
+
+	username = testPrefix + username
+	event.Username = &username
+
+	if event.Message == nil {
+		message := defaultMessage
+		event.Message = &message
+	}
+
+	if event.Amount == nil {
+		amount := defaultAmount
+		event.Amount = &amount
+	}
+
+	if event.Currency == nil {
+		currency := defaultCurrency
+		event.Currency = &currency
+	}
+
+	_, channel, err := h.obsService.WebhookAlert(address, event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send test alert: %w", err)
+	}
+
+	return &Response{Body: ResponseBody{Channel: channel}, StatusCode: http.StatusOK}, nil
+}
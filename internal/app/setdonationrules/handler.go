@@ -0,0 +1,169 @@
+package setdonationrules
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"twitch-crypto-donations/internal/pkg/amount"
+	"twitch-crypto-donations/internal/pkg/donationrules"
+	"twitch-crypto-donations/internal/pkg/middleware"
+	"twitch-crypto-donations/internal/pkg/mints"
+)
+
+type Database interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+type MintRegistry interface {
+	BySymbol(symbol string) (mints.Mint, bool)
+}
+
+type RequestBody struct {
+	Rules []Rule `json:"rules"`
+}
+
+type Rule struct {
+	Currency            string  `json:"currency"`
+	MinAlert            *string `json:"min_alert"`
+	MinVoice            *string `json:"min_voice"`
+	MinMedia            *string `json:"min_media"`
+	MediaPricePerSecond *string `json:"media_price_per_second"`
+}
+
+type ResponseBody struct {
+	Rules []ResponseRule `json:"rules"`
+}
+
+type ResponseRule struct {
+	Currency            string `json:"currency"`
+	MinAlert            string `json:"min_alert"`
+	MinVoice            string `json:"min_voice"`
+	MinMedia            string `json:"min_media"`
+	MediaPricePerSecond string `json:"media_price_per_second"`
+}
+
+type (
+	Request  = middleware.Request[RequestBody]
+	Response = middleware.Response[ResponseBody]
+)
+
+type Handler struct {
+	db    Database
+	mints MintRegistry
+}
+
+func New(db Database, mints MintRegistry) *Handler {
+	return &Handler{db: db, mints: mints}
+}
+
+// Handle replaces the streamer's donation rules with the given set. An empty
+// list removes every rule.
+func (h *Handler) Handle(ctx context.Context, request Request) (*Response, error) {
+	address, exists := request.Context[middleware.AddressKey].(string)
+	if !exists || address == "" {
+		return &Response{StatusCode: http.StatusUnauthorized}, fmt.Errorf("jwt is not found or api middleware is failed")
+	}
+
+	rules, err := h.parse(request.Body.Rules)
+	if err != nil {
+		return &Response{StatusCode: http.StatusBadRequest}, err
+	}
+
+	if err = h.replace(ctx, address, rules); err != nil {
+		return nil, err
+	}
+
+	response := ResponseBody{Rules: make([]ResponseRule, 0, len(rules))}
+	for _, rule := range rules {
+		mint, _ := h.mints.BySymbol(rule.Currency)
+		response.Rules = append(response.Rules, ResponseRule{
+			Currency:            rule.Currency,
+			MinAlert:            amount.Format(rule.MinAlert, mint.Decimals),
+			MinVoice:            amount.Format(rule.MinVoice, mint.Decimals),
+			MinMedia:            amount.Format(rule.MinMedia, mint.Decimals),
+			MediaPricePerSecond: amount.Format(rule.MediaPricePerSecond, mint.Decimals),
+		})
+	}
+
+	return &Response{Body: response, StatusCode: http.StatusOK}, nil
+}
+
+func (h *Handler) parse(requested []Rule) ([]donationrules.Rules, error) {
+	rules := make([]donationrules.Rules, 0, len(requested))
+	seen := make(map[string]struct{}, len(requested))
+
+	for _, rule := range requested {
+		mint, ok := h.mints.BySymbol(rule.Currency)
+		if !ok {
+			return nil, fmt.Errorf("unsupported currency %s", rule.Currency)
+		}
+
+		if _, ok = seen[mint.Symbol]; ok {
+			return nil, fmt.Errorf("rules for %s are listed more than once", mint.Symbol)
+		}
+		seen[mint.Symbol] = struct{}{}
+
+		parsed := donationrules.Rules{Currency: mint.Symbol}
+		fields := []struct {
+			name   string
+			value  *string
+			target *uint64
+		}{
+			{"min_alert", rule.MinAlert, &parsed.MinAlert},
+			{"min_voice", rule.MinVoice, &parsed.MinVoice},
+			{"min_media", rule.MinMedia, &parsed.MinMedia},
+			{"media_price_per_second", rule.MediaPricePerSecond, &parsed.MediaPricePerSecond},
+		}
+
+		for _, field := range fields {
+			if field.value == nil {
+				continue
+			}
+
+			units, err := amount.Parse(*field.value, mint.Decimals)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %q for %s: %w", field.name, *field.value, mint.Symbol, err)
+			}
+			*field.target = units
+		}
+
+		rules = append(rules, parsed)
+	}
+
+	return rules, nil
+}
+
+func (h *Handler) replace(ctx context.Context, receiver string, rules []donationrules.Rules) error {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Exec(`DELETE FROM donation_rules WHERE receiver = $1;`, receiver); err != nil {
+		return fmt.Errorf("failed to clear donation rules: %w", err)
+	}
+
+	const insertQuery = `
+		INSERT INTO donation_rules (receiver, currency, min_alert, min_voice, min_media, media_price_per_second)
+		VALUES ($1, $2, $3, $4, $5, $6);
+	`
+
+	for _, rule := range rules {
+		_, err = tx.Exec(insertQuery, receiver, rule.Currency,
+			strconv.FormatUint(rule.MinAlert, 10), strconv.FormatUint(rule.MinVoice, 10),
+			strconv.FormatUint(rule.MinMedia, 10), strconv.FormatUint(rule.MediaPricePerSecond, 10),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save donation rules: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package setrevenuesplits
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"twitch-crypto-donations/internal/pkg/middleware"
+	"twitch-crypto-donations/internal/pkg/splits"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+const maxSplits = 8
+
+type Database interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+type SplitPolicy interface {
+	FeeWallet() string
+}
+
+type RequestBody struct {
+	Splits []Split `json:"splits"`
+}
+
+type Split struct {
+	Recipient string `json:"recipient"`
+	Bps       uint64 `json:"bps"`
+	Label     string `json:"label"`
+}
+
+type ResponseBody struct {
+	Splits []Split `json:"splits"`
+}
+
+type (
+	Request  = middleware.Request[RequestBody]
+	Response = middleware.Response[ResponseBody]
+)
+
+type Handler struct {
+	db     Database
+	splits SplitPolicy
+}
+
+func New(db Database, splits SplitPolicy) *Handler {
+	return &Handler{db: db, splits: splits}
+}
+
+// Handle replaces the streamer's revenue splits with the given set. An empty
+// list removes every split.
+func (h *Handler) Handle(ctx context.Context, request Request) (*Response, error) {
+	address, exists := request.Context[middleware.AddressKey].(string)
+	if !exists || address == "" {
+		return &Response{StatusCode: http.StatusUnauthorized}, fmt.Errorf("jwt is not found or api middleware is failed")
+	}
+
+	if err := h.validate(address, request.Body.Splits); err != nil {
+		return &Response{StatusCode: http.StatusBadRequest}, err
+	}
+
+	if err := h.replace(ctx, address, request.Body.Splits); err != nil {
+		return nil, err
+	}
+
+	result := request.Body.Splits
+	if result == nil {
+		result = make([]Split, 0)
+	}
+
+	return &Response{Body: ResponseBody{Splits: result}, StatusCode: http.StatusOK}, nil
+}
+
+func (h *Handler) validate(receiver string, shares []Split) error {
+	if len(shares) > maxSplits {
+		return fmt.Errorf("at most %d revenue splits are allowed", maxSplits)
+	}
+
+	var total uint64
+	seen := make(map[string]struct{}, len(shares))
+	for _, share := range shares {
+		if _, err := solana.PublicKeyFromBase58(share.Recipient); err != nil {
+			return fmt.Errorf("invalid split recipient %q: %w", share.Recipient, err)
+		}
+
+		if share.Recipient == receiver {
+			return fmt.Errorf("split recipient %s is the streamer wallet", share.Recipient)
+		}
+
+		if share.Recipient == h.splits.FeeWallet() {
+			return fmt.Errorf("split recipient %s is the platform fee wallet", share.Recipient)
+		}
+
+		if _, ok := seen[share.Recipient]; ok {
+			return fmt.Errorf("split recipient %s is listed more than once", share.Recipient)
+		}
+		seen[share.Recipient] = struct{}{}
+
+		if share.Bps == 0 || share.Bps >= splits.MaxBps {
+			return fmt.Errorf("split of %d basis points for %s is out of range", share.Bps, share.Recipient)
+		}
+		total += share.Bps
+	}
+
+	if total >= splits.MaxBps {
+		return fmt.Errorf("revenue splits of %d basis points leave nothing for the streamer", total)
+	}
+
+	return nil
+}
+
+func (h *Handler) replace(ctx context.Context, receiver string, shares []Split) error {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Exec(`DELETE FROM revenue_splits WHERE receiver = $1;`, receiver); err != nil {
+		return fmt.Errorf("failed to clear revenue splits: %w", err)
+	}
+
+	const insertQuery = `
+		INSERT INTO revenue_splits (receiver, recipient, bps, label)
+		VALUES ($1, $2, $3, $4);
+	`
+
+	for _, share := range shares {
+		if _, err = tx.Exec(insertQuery, receiver, share.Recipient, share.Bps, share.Label); err != nil {
+			return fmt.Errorf("failed to save revenue split: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
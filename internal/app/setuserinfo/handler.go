@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"twitch-crypto-donations/internal/pkg/evmverifier"
 	"twitch-crypto-donations/internal/pkg/middleware"
 )
 
@@ -21,6 +22,7 @@ type RequestBody struct {
 	DisplayName *string `json:"display_name"`
 	Bio         *string `json:"bio"`
 	AvatarUrl   *string `json:"avatar_url"`
+	EvmAddress  *string `json:"evm_address"`
 }
 
 type (
@@ -46,6 +48,12 @@ func (h *Handler) Handle(_ context.Context, request Request) (*Response, error)
 		}, fmt.Errorf("jwt is not found or api middleware is failed")
 	}
 
+	if request.Body.EvmAddress != nil && !evmverifier.IsAddress(*request.Body.EvmAddress) {
+		return &Response{
+			StatusCode: http.StatusBadRequest,
+		}, fmt.Errorf("invalid evm address %q", *request.Body.EvmAddress)
+	}
+
 	updates, args, argCount := h.buildQuery(request.Body)
 	if len(updates) == 0 {
 		return &Response{
@@ -109,5 +117,11 @@ func (h *Handler) buildQuery(body RequestBody) ([]string, []interface{}, int) {
 		argCount++
 	}
 
+	if body.EvmAddress != nil {
+		updates = append(updates, fmt.Sprintf("evm_address = $%d", argCount))
+		args = append(args, strings.ToLower(*body.EvmAddress))
+		argCount++
+	}
+
 	return updates, args, argCount
 }
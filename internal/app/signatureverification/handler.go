@@ -9,18 +9,30 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 	"twitch-crypto-donations/internal/pkg/middleware"
+	"twitch-crypto-donations/internal/pkg/sessions"
+	"twitch-crypto-donations/internal/pkg/siws"
 
 	"github.com/mr-tron/base58"
 )
 
+var (
+	ErrNonceNotFound = errors.New("nonce is invalid or has already been used")
+	ErrNonceExpired  = errors.New("nonce has expired")
+)
+
 type Database interface {
 	QueryRow(query string, args ...any) *sql.Row
 	Exec(query string, args ...any) (sql.Result, error)
 }
 
-type JwtManager interface {
-	GenerateJwt(address string) (string, error)
+type SessionManager interface {
+	Create(address, userAgent string) (*sessions.Tokens, error)
+}
+
+type MessageValidator interface {
+	Validate(message siws.Message, address string, now time.Time) error
 }
 
 type RequestBody struct {
@@ -30,7 +42,10 @@ type RequestBody struct {
 }
 
 type ResponseBody struct {
-	JwtToken string `json:"jwt_token"`
+	JwtToken              string    `json:"jwt_token"`
+	ExpiresAt             time.Time `json:"expires_at"`
+	RefreshToken          string    `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
 }
 
 type (
@@ -39,40 +54,59 @@ type (
 )
 
 type Handler struct {
-	db  Database
-	jwt JwtManager
+	db        Database
+	sessions  SessionManager
+	validator MessageValidator
 }
 
-func New(db Database, jwt JwtManager) *Handler {
-	return &Handler{db: db, jwt: jwt}
+func New(db Database, sessions SessionManager, validator MessageValidator) *Handler {
+	return &Handler{db: db, sessions: sessions, validator: validator}
 }
 
+// Handle exchanges a signed Sign-In With Solana message for a new session: a
+// short-lived JWT and a refresh token. The message
+// must be one this service issued to the address and not yet expired, and its
+// nonce is consumed so it cannot be replayed.
 func (h *Handler) Handle(_ context.Context, request Request) (*Response, error) {
-	nonce, err := h.extractNonce(request.Body.Message)
+	message, err := siws.Parse(request.Body.Message)
 	if err != nil {
-		return nil, err
+		return &Response{StatusCode: http.StatusBadRequest}, err
+	}
+
+	signature, err := h.decodeSignature(request.Body.Signature)
+	if err != nil {
+		return &Response{StatusCode: http.StatusBadRequest}, fmt.Errorf("invalid signature format: %w", err)
+	}
+
+	if err = h.validator.Validate(message, request.Body.Address, time.Now().UTC()); err != nil {
+		return &Response{StatusCode: http.StatusUnauthorized}, fmt.Errorf("authentication failed: %w", err)
 	}
 
-	if err = h.validateAndConsumeNonce(nonce, request.Body.Address); err != nil {
-		return nil, fmt.Errorf("authentication failed: %w", err)
+	if err = h.validateAndConsumeNonce(message.Nonce, request.Body.Address, time.Now().UTC()); err != nil {
+		return &Response{StatusCode: http.StatusUnauthorized}, fmt.Errorf("authentication failed: %w", err)
 	}
 
-	if err = h.verifySolanaSignature(request.Body.Address, request.Body.Signature, request.Body.Message); err != nil {
-		return nil, fmt.Errorf("signature verification failed: %w", err)
+	if err = h.verifySolanaSignature(request.Body.Address, signature, request.Body.Message); err != nil {
+		return &Response{StatusCode: http.StatusUnauthorized}, fmt.Errorf("signature verification failed: %w", err)
 	}
 
-	jwtToken, err := h.jwt.GenerateJwt(request.Body.Address)
+	tokens, err := h.sessions.Create(request.Body.Address, request.Headers.Get("User-Agent"))
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate JWT: %w", err)
+		return nil, fmt.Errorf("failed to start session: %w", err)
 	}
 
 	return &Response{
-		Body:       ResponseBody{JwtToken: jwtToken},
+		Body: ResponseBody{
+			JwtToken:              tokens.AccessToken,
+			ExpiresAt:             tokens.AccessTokenExpiresAt,
+			RefreshToken:          tokens.RefreshToken,
+			RefreshTokenExpiresAt: tokens.RefreshTokenExpiresAt,
+		},
 		StatusCode: http.StatusOK,
 	}, nil
 }
 
-func (h *Handler) verifySolanaSignature(publicKeyStr, signatureStr, message string) error {
+func (h *Handler) verifySolanaSignature(publicKeyStr string, signatureBytes []byte, message string) error {
 	publicKeyBytes, err := base58.Decode(publicKeyStr)
 	if err != nil {
 		return fmt.Errorf("invalid public key format: %w", err)
@@ -82,15 +116,6 @@ func (h *Handler) verifySolanaSignature(publicKeyStr, signatureStr, message stri
 		return fmt.Errorf("invalid public key length: expected %d, got %d", ed25519.PublicKeySize, len(publicKeyBytes))
 	}
 
-	signatureBytes, err := h.decodeSignature(signatureStr)
-	if err != nil {
-		return fmt.Errorf("invalid signature format: %w", err)
-	}
-
-	if len(signatureBytes) != ed25519.SignatureSize {
-		return fmt.Errorf("invalid signature length: expected %d, got %d", ed25519.SignatureSize, len(signatureBytes))
-	}
-
 	messageBytes := []byte(message)
 
 	valid := ed25519.Verify(publicKeyBytes, messageBytes, signatureBytes)
@@ -101,59 +126,48 @@ func (h *Handler) verifySolanaSignature(publicKeyStr, signatureStr, message stri
 	return nil
 }
 
+// decodeSignature accepts the 64-byte signature hex encoded, as browser
+// wallets return it as bytes, or base58 encoded, as Solana tooling prints it.
 func (h *Handler) decodeSignature(signatureStr string) ([]byte, error) {
 	signatureBytes, err := hex.DecodeString(signatureStr)
 	if err == nil && len(signatureBytes) == ed25519.SignatureSize {
 		return signatureBytes, nil
 	}
 
-	return nil, errors.New("signature must be either hex encoded")
+	signatureBytes, err = base58.Decode(signatureStr)
+	if err == nil && len(signatureBytes) == ed25519.SignatureSize {
+		return signatureBytes, nil
+	}
+
+	return nil, fmt.Errorf("signature must be a hex or base58 encoded %d-byte signature", ed25519.SignatureSize)
 }
 
-func (h *Handler) validateAndConsumeNonce(nonce, claimedAddress string) error {
-	deleteQuery := `DELETE FROM nonces WHERE nonce = $1 RETURNING address;`
+// validateAndConsumeNonce deletes the nonce whether or not it is still valid,
+// so an expired nonce cannot be retried either.
+func (h *Handler) validateAndConsumeNonce(nonce, claimedAddress string, now time.Time) error {
+	const deleteQuery = `DELETE FROM nonces WHERE nonce = $1 RETURNING address, expires_at <= $2;`
 
-	var storedAddress string
-	err := h.db.QueryRow(deleteQuery, nonce).Scan(&storedAddress)
+	var (
+		storedAddress string
+		expired       bool
+	)
 
+	err := h.db.QueryRow(deleteQuery, nonce, now).Scan(&storedAddress, &expired)
 	if errors.Is(err, sql.ErrNoRows) {
-		return errors.New("nonce is invalid or has expired")
+		return ErrNonceNotFound
 	}
 
 	if err != nil {
 		return fmt.Errorf("database query error: %w", err)
 	}
 
+	if expired {
+		return ErrNonceExpired
+	}
+
 	if !strings.EqualFold(storedAddress, claimedAddress) {
 		return errors.New("nonce was requested by a different address")
 	}
 
 	return nil
 }
-
-func (h *Handler) extractNonce(message string) (string, error) {
-	const prefix = "Nonce: "
-
-	start := strings.Index(message, prefix)
-	if start == -1 {
-		return "", errors.New("nonce not found in message")
-	}
-
-	start += len(prefix)
-
-	end := start
-	for end < len(message) {
-		end++
-	}
-
-	if end == start {
-		return "", errors.New("empty nonce")
-	}
-
-	nonce := strings.TrimSpace(message[start:end])
-	if nonce == "" {
-		return "", errors.New("empty nonce after trimming")
-	}
-
-	return nonce, nil
-}
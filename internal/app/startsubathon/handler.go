@@ -0,0 +1,186 @@
+package startsubathon
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"twitch-crypto-donations/internal/pkg/middleware"
+	"twitch-crypto-donations/internal/pkg/mints"
+	"twitch-crypto-donations/internal/pkg/subathon"
+
+	"github.com/google/uuid"
+)
+
+type Database interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+type MintRegistry interface {
+	BySymbol(symbol string) (mints.Mint, bool)
+}
+
+type TimerNotifier interface {
+	Notify(owner string, timer *subathon.Timer, added int64, username *string) error
+}
+
+type Logger interface {
+	Info(msg string, ctx ...interface{})
+}
+
+type RequestBody struct {
+	DurationSeconds int64  `json:"duration_seconds"`
+	CapSeconds      *int64 `json:"cap_seconds"`
+	Rates           []Rate `json:"rates"`
+	Paused          bool   `json:"paused"`
+}
+
+type Rate struct {
+	Currency       string `json:"currency"`
+	SecondsPerUnit string `json:"seconds_per_unit"`
+}
+
+type ResponseBody struct {
+	Paused           bool       `json:"paused"`
+	EndsAt           *time.Time `json:"ends_at"`
+	RemainingSeconds int64      `json:"remaining_seconds"`
+	InitialSeconds   int64      `json:"initial_seconds"`
+	AddedSeconds     int64      `json:"added_seconds"`
+	CapSeconds       *int64     `json:"cap_seconds"`
+	StartedAt        time.Time  `json:"started_at"`
+	Rates            []Rate     `json:"rates"`
+}
+
+type (
+	Request  = middleware.Request[RequestBody]
+	Response = middleware.Response[ResponseBody]
+)
+
+type Handler struct {
+	db     Database
+	mints  MintRegistry
+	timers TimerNotifier
+	logger Logger
+}
+
+func New(db Database, mints MintRegistry, timers TimerNotifier, logger Logger) *Handler {
+	return &Handler{db: db, mints: mints, timers: timers, logger: logger}
+}
+
+// Handle starts a subathon for the authenticated streamer, replacing the
+// previous one along with the time donations added to it.
+func (h *Handler) Handle(ctx context.Context, request Request) (*Response, error) {
+	address, exists := request.Context[middleware.AddressKey].(string)
+	if !exists || address == "" {
+		return &Response{StatusCode: http.StatusUnauthorized}, fmt.Errorf("jwt is not found or api middleware is failed")
+	}
+
+	rates, err := h.validate(request.Body)
+	if err != nil {
+		return &Response{StatusCode: http.StatusBadRequest}, err
+	}
+
+	timer, err := h.start(ctx, address, request.Body, rates)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = h.timers.Notify(address, timer, 0, nil); err != nil {
+		h.logger.Info("failed to push subathon timer", "wallet", address, "error", err.Error())
+	}
+
+	response := ResponseBody{
+		Paused:           timer.Paused(),
+		EndsAt:           timer.EndsAt,
+		RemainingSeconds: timer.Remaining(time.Now()),
+		InitialSeconds:   timer.InitialSeconds,
+		AddedSeconds:     timer.AddedSeconds,
+		CapSeconds:       timer.CapSeconds,
+		StartedAt:        timer.StartedAt,
+		Rates:            make([]Rate, 0, len(timer.Rates)),
+	}
+
+	for _, rate := range timer.Rates {
+		response.Rates = append(response.Rates, Rate{Currency: rate.Currency, SecondsPerUnit: rate.SecondsPerUnit})
+	}
+
+	return &Response{Body: response, StatusCode: http.StatusCreated}, nil
+}
+
+func (h *Handler) validate(body RequestBody) ([]subathon.Rate, error) {
+	if body.DurationSeconds <= 0 {
+		return nil, fmt.Errorf("subathon duration must be positive")
+	}
+
+	if body.CapSeconds != nil && *body.CapSeconds < body.DurationSeconds {
+		return nil, fmt.Errorf("subathon cap of %d seconds is below its duration", *body.CapSeconds)
+	}
+
+	rates := make([]subathon.Rate, 0, len(body.Rates))
+	seen := make(map[string]struct{}, len(body.Rates))
+	for _, rate := range body.Rates {
+		mint, ok := h.mints.BySymbol(rate.Currency)
+		if !ok {
+			return nil, fmt.Errorf("unsupported currency %s", rate.Currency)
+		}
+
+		if _, ok = seen[mint.Symbol]; ok {
+			return nil, fmt.Errorf("rate for %s is listed more than once", mint.Symbol)
+		}
+		seen[mint.Symbol] = struct{}{}
+
+		value := strings.TrimSpace(rate.SecondsPerUnit)
+		if !subathon.ValidRate(value) {
+			return nil, fmt.Errorf("invalid seconds per unit %q for %s", rate.SecondsPerUnit, mint.Symbol)
+		}
+
+		rates = append(rates, subathon.Rate{Currency: mint.Symbol, SecondsPerUnit: value})
+	}
+
+	return rates, nil
+}
+
+func (h *Handler) start(ctx context.Context, owner string, body RequestBody, rates []subathon.Rate) (*subathon.Timer, error) {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Exec(`DELETE FROM subathon_timers WHERE owner = $1;`, owner); err != nil {
+		return nil, fmt.Errorf("failed to clear subathon timer: %w", err)
+	}
+
+	const insertQuery = `
+		INSERT INTO subathon_timers (id, owner, initial_seconds, cap_seconds, ends_at, paused_remaining)
+		VALUES ($1, $2, $3::BIGINT, $4,
+		        CASE WHEN $5 THEN NULL ELSE NOW() + $3::BIGINT * INTERVAL '1 second' END,
+		        CASE WHEN $5 THEN $3::BIGINT END);
+	`
+
+	timerID := uuid.NewString()
+	if _, err = tx.Exec(insertQuery, timerID, owner, body.DurationSeconds, body.CapSeconds, body.Paused); err != nil {
+		return nil, fmt.Errorf("failed to save subathon timer: %w", err)
+	}
+
+	const rateQuery = `INSERT INTO subathon_rates (timer_id, currency, seconds_per_unit) VALUES ($1, $2, $3);`
+
+	for _, rate := range rates {
+		if _, err = tx.Exec(rateQuery, timerID, rate.Currency, rate.SecondsPerUnit); err != nil {
+			return nil, fmt.Errorf("failed to save subathon rate: %w", err)
+		}
+	}
+
+	timer, err := subathon.Find(tx, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return timer, nil
+}
@@ -0,0 +1,66 @@
+package stopsubathon
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"twitch-crypto-donations/internal/pkg/middleware"
+	"twitch-crypto-donations/internal/pkg/subathon"
+)
+
+type Database interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+type TimerNotifier interface {
+	Notify(owner string, timer *subathon.Timer, added int64, username *string) error
+}
+
+type Logger interface {
+	Info(msg string, ctx ...interface{})
+}
+
+type (
+	Request  = middleware.Request[struct{}]
+	Response = middleware.Response[struct{}]
+)
+
+type Handler struct {
+	db     Database
+	timers TimerNotifier
+	logger Logger
+}
+
+func New(db Database, timers TimerNotifier, logger Logger) *Handler {
+	return &Handler{db: db, timers: timers, logger: logger}
+}
+
+// Handle ends the authenticated streamer's subathon and clears the timer from
+// the overlay.
+func (h *Handler) Handle(_ context.Context, request Request) (*Response, error) {
+	address, exists := request.Context[middleware.AddressKey].(string)
+	if !exists || address == "" {
+		return &Response{StatusCode: http.StatusUnauthorized}, fmt.Errorf("jwt is not found or api middleware is failed")
+	}
+
+	result, err := h.db.Exec(`DELETE FROM subathon_timers WHERE owner = $1;`, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete subathon timer: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete subathon timer: %w", err)
+	}
+
+	if affected == 0 {
+		return &Response{StatusCode: http.StatusNotFound}, fmt.Errorf("%w: %s", subathon.ErrTimerNotFound, address)
+	}
+
+	if err = h.timers.Notify(address, nil, 0, nil); err != nil {
+		h.logger.Info("failed to push subathon timer", "wallet", address, "error", err.Error())
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,192 @@
+package updategoal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"twitch-crypto-donations/internal/pkg/amount"
+	"twitch-crypto-donations/internal/pkg/goals"
+	"twitch-crypto-donations/internal/pkg/middleware"
+	"twitch-crypto-donations/internal/pkg/mints"
+)
+
+const maxTitleLength = 100
+
+type Database interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+type MintRegistry interface {
+	BySymbol(symbol string) (mints.Mint, bool)
+}
+
+type RequestBody struct {
+	Title        *string    `json:"title"`
+	TargetAmount *string    `json:"target_amount"`
+	Deadline     *time.Time `json:"deadline"`
+	Active       *bool      `json:"active"`
+}
+
+type ResponseBody struct {
+	ID           string     `json:"id"`
+	Title        string     `json:"title"`
+	Currency     *string    `json:"currency"`
+	FiatCurrency *string    `json:"fiat_currency"`
+	TargetAmount string     `json:"target_amount"`
+	RaisedAmount string     `json:"raised_amount"`
+	Progress     float64    `json:"progress"`
+	Deadline     *time.Time `json:"deadline"`
+	Active       bool       `json:"active"`
+	ReachedAt    *time.Time `json:"reached_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+type (
+	Request  = middleware.Request[RequestBody]
+	Response = middleware.Response[ResponseBody]
+)
+
+type Handler struct {
+	db    Database
+	mints MintRegistry
+}
+
+func New(db Database, mints MintRegistry) *Handler {
+	return &Handler{db: db, mints: mints}
+}
+
+// Handle updates the fields given for one of the authenticated streamer's
+// donation goals. The unit a goal is counted in cannot change. Activating a goal
+// deactivates the streamer's other goals.
+func (h *Handler) Handle(ctx context.Context, request Request) (*Response, error) {
+	address, exists := request.Context[middleware.AddressKey].(string)
+	if !exists || address == "" {
+		return &Response{StatusCode: http.StatusUnauthorized}, fmt.Errorf("jwt is not found or api middleware is failed")
+	}
+
+	goalID := request.PathParams["id"]
+
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	goal, err := goals.Find(tx, goalID)
+	if errors.Is(err, goals.ErrGoalNotFound) {
+		return &Response{StatusCode: http.StatusNotFound}, err
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if goal.Owner != address {
+		return &Response{StatusCode: http.StatusNotFound}, fmt.Errorf("%w: %s", goals.ErrGoalNotFound, goalID)
+	}
+
+	if err = h.apply(goal, request.Body); err != nil {
+		return &Response{StatusCode: http.StatusBadRequest}, err
+	}
+
+	if err = h.save(tx, goal); err != nil {
+		return nil, err
+	}
+
+	saved, err := goals.Find(tx, goalID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &Response{
+		Body: ResponseBody{
+			ID:           saved.ID,
+			Title:        saved.Title,
+			Currency:     saved.Currency,
+			FiatCurrency: saved.FiatCurrency,
+			TargetAmount: saved.Target,
+			RaisedAmount: saved.Raised,
+			Progress:     saved.Percent(),
+			Deadline:     saved.Deadline,
+			Active:       saved.Active,
+			ReachedAt:    saved.ReachedAt,
+			CreatedAt:    saved.CreatedAt,
+		},
+		StatusCode: http.StatusOK,
+	}, nil
+}
+
+func (h *Handler) apply(goal *goals.Goal, body RequestBody) error {
+	if body.Title != nil {
+		title := strings.TrimSpace(*body.Title)
+		if title == "" || len([]rune(title)) > maxTitleLength {
+			return fmt.Errorf("goal title must be between 1 and %d characters", maxTitleLength)
+		}
+		goal.Title = title
+	}
+
+	if body.TargetAmount != nil {
+		decimals := uint8(goals.FiatDecimals)
+		if goal.Currency != nil {
+			mint, ok := h.mints.BySymbol(*goal.Currency)
+			if !ok {
+				return fmt.Errorf("currency %s is no longer accepted", *goal.Currency)
+			}
+			decimals = mint.Decimals
+		}
+
+		target, err := amount.Parse(*body.TargetAmount, decimals)
+		if err != nil || target == 0 {
+			return fmt.Errorf("invalid target amount %q", *body.TargetAmount)
+		}
+		goal.Target = amount.Format(target, decimals)
+	}
+
+	if body.Deadline != nil {
+		if !body.Deadline.After(time.Now()) {
+			return fmt.Errorf("goal deadline must be in the future")
+		}
+		goal.Deadline = body.Deadline
+	}
+
+	if body.Active != nil {
+		goal.Active = *body.Active
+	}
+
+	return nil
+}
+
+func (h *Handler) save(tx *sql.Tx, goal *goals.Goal) error {
+	if goal.Active {
+		const deactivateQuery = `UPDATE donation_goals SET active = FALSE, updated_at = NOW() WHERE owner = $1 AND active AND id <> $2;`
+
+		if _, err := tx.Exec(deactivateQuery, goal.Owner, goal.ID); err != nil {
+			return fmt.Errorf("failed to deactivate goals: %w", err)
+		}
+	}
+
+	const updateQuery = `
+		UPDATE donation_goals
+		SET title = $2, target = $3, deadline = $4, active = $5, updated_at = NOW()
+		WHERE id = $1;
+	`
+
+	if _, err := tx.Exec(updateQuery, goal.ID, goal.Title, goal.Target, goal.Deadline, goal.Active); err != nil {
+		return fmt.Errorf("failed to update goal: %w", err)
+	}
+
+	// A new target can complete the goal or undo its completion.
+	if _, err := goals.MarkReached(tx, goal.ID); err != nil {
+		return err
+	}
+
+	return nil
+}
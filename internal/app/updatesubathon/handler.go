@@ -0,0 +1,241 @@
+package updatesubathon
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"twitch-crypto-donations/internal/pkg/middleware"
+	"twitch-crypto-donations/internal/pkg/mints"
+	"twitch-crypto-donations/internal/pkg/subathon"
+)
+
+type Database interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+type MintRegistry interface {
+	BySymbol(symbol string) (mints.Mint, bool)
+}
+
+type TimerNotifier interface {
+	Notify(owner string, timer *subathon.Timer, added int64, username *string) error
+}
+
+type Logger interface {
+	Info(msg string, ctx ...interface{})
+}
+
+// RequestBody changes only the fields it sets. A cap of zero removes the cap.
+type RequestBody struct {
+	CapSeconds *int64  `json:"cap_seconds"`
+	Rates      *[]Rate `json:"rates"`
+	Paused     *bool   `json:"paused"`
+}
+
+type Rate struct {
+	Currency       string `json:"currency"`
+	SecondsPerUnit string `json:"seconds_per_unit"`
+}
+
+type ResponseBody struct {
+	Paused           bool       `json:"paused"`
+	EndsAt           *time.Time `json:"ends_at"`
+	RemainingSeconds int64      `json:"remaining_seconds"`
+	InitialSeconds   int64      `json:"initial_seconds"`
+	AddedSeconds     int64      `json:"added_seconds"`
+	CapSeconds       *int64     `json:"cap_seconds"`
+	StartedAt        time.Time  `json:"started_at"`
+	Rates            []Rate     `json:"rates"`
+}
+
+type (
+	Request  = middleware.Request[RequestBody]
+	Response = middleware.Response[ResponseBody]
+)
+
+type Handler struct {
+	db     Database
+	mints  MintRegistry
+	timers TimerNotifier
+	logger Logger
+}
+
+func New(db Database, mints MintRegistry, timers TimerNotifier, logger Logger) *Handler {
+	return &Handler{db: db, mints: mints, timers: timers, logger: logger}
+}
+
+// Handle pauses or resumes the authenticated streamer's subathon, or changes
+// its cap and rates. Pausing keeps the remaining time until it is resumed.
+func (h *Handler) Handle(ctx context.Context, request Request) (*Response, error) {
+	address, exists := request.Context[middleware.AddressKey].(string)
+	if !exists || address == "" {
+		return &Response{StatusCode: http.StatusUnauthorized}, fmt.Errorf("jwt is not found or api middleware is failed")
+	}
+
+	if request.Body.CapSeconds != nil && *request.Body.CapSeconds < 0 {
+		return &Response{StatusCode: http.StatusBadRequest}, fmt.Errorf("subathon cap must not be negative")
+	}
+
+	var rates []subathon.Rate
+	if request.Body.Rates != nil {
+		var err error
+		if rates, err = h.validateRates(*request.Body.Rates); err != nil {
+			return &Response{StatusCode: http.StatusBadRequest}, err
+		}
+	}
+
+	timer, err := h.update(ctx, address, request.Body, rates)
+	if errors.Is(err, subathon.ErrTimerNotFound) {
+		return &Response{StatusCode: http.StatusNotFound}, err
+	}
+
+	if errors.Is(err, errCapBelowTime) {
+		return &Response{StatusCode: http.StatusBadRequest}, err
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = h.timers.Notify(address, timer, 0, nil); err != nil {
+		h.logger.Info("failed to push subathon timer", "wallet", address, "error", err.Error())
+	}
+
+	response := ResponseBody{
+		Paused:           timer.Paused(),
+		EndsAt:           timer.EndsAt,
+		RemainingSeconds: timer.Remaining(time.Now()),
+		InitialSeconds:   timer.InitialSeconds,
+		AddedSeconds:     timer.AddedSeconds,
+		CapSeconds:       timer.CapSeconds,
+		StartedAt:        timer.StartedAt,
+		Rates:            make([]Rate, 0, len(timer.Rates)),
+	}
+
+	for _, rate := range timer.Rates {
+		response.Rates = append(response.Rates, Rate{Currency: rate.Currency, SecondsPerUnit: rate.SecondsPerUnit})
+	}
+
+	return &Response{Body: response, StatusCode: http.StatusOK}, nil
+}
+
+var errCapBelowTime = errors.New("subathon cap is below the time already on the timer")
+
+func (h *Handler) validateRates(body []Rate) ([]subathon.Rate, error) {
+	rates := make([]subathon.Rate, 0, len(body))
+	seen := make(map[string]struct{}, len(body))
+	for _, rate := range body {
+		mint, ok := h.mints.BySymbol(rate.Currency)
+		if !ok {
+			return nil, fmt.Errorf("unsupported currency %s", rate.Currency)
+		}
+
+		if _, ok = seen[mint.Symbol]; ok {
+			return nil, fmt.Errorf("rate for %s is listed more than once", mint.Symbol)
+		}
+		seen[mint.Symbol] = struct{}{}
+
+		value := strings.TrimSpace(rate.SecondsPerUnit)
+		if !subathon.ValidRate(value) {
+			return nil, fmt.Errorf("invalid seconds per unit %q for %s", rate.SecondsPerUnit, mint.Symbol)
+		}
+
+		rates = append(rates, subathon.Rate{Currency: mint.Symbol, SecondsPerUnit: value})
+	}
+
+	return rates, nil
+}
+
+func (h *Handler) update(ctx context.Context, owner string, body RequestBody, rates []subathon.Rate) (*subathon.Timer, error) {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var (
+		timerID        string
+		initial, added int64
+	)
+
+	err = tx.QueryRow(`SELECT id, initial_seconds, added_seconds FROM subathon_timers WHERE owner = $1 FOR UPDATE;`, owner).
+		Scan(&timerID, &initial, &added)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", subathon.ErrTimerNotFound, owner)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to load subathon timer: %w", err)
+	}
+
+	if body.CapSeconds != nil {
+		var capSeconds *int64
+		if *body.CapSeconds > 0 {
+			if *body.CapSeconds < initial+added {
+				return nil, fmt.Errorf("%w: %d seconds", errCapBelowTime, initial+added)
+			}
+			capSeconds = body.CapSeconds
+		}
+
+		if _, err = tx.Exec(`UPDATE subathon_timers SET cap_seconds = $2, updated_at = NOW() WHERE id = $1;`, timerID, capSeconds); err != nil {
+			return nil, fmt.Errorf("failed to update subathon timer: %w", err)
+		}
+	}
+
+	if body.Paused != nil && *body.Paused {
+		const pauseQuery = `
+			UPDATE subathon_timers
+			SET paused_remaining = GREATEST(0, CEIL(EXTRACT(EPOCH FROM ends_at - NOW())))::BIGINT,
+			    ends_at = NULL,
+			    updated_at = NOW()
+			WHERE id = $1 AND ends_at IS NOT NULL;
+		`
+
+		if _, err = tx.Exec(pauseQuery, timerID); err != nil {
+			return nil, fmt.Errorf("failed to pause subathon timer: %w", err)
+		}
+	}
+
+	if body.Paused != nil && !*body.Paused {
+		const resumeQuery = `
+			UPDATE subathon_timers
+			SET ends_at = NOW() + paused_remaining * INTERVAL '1 second',
+			    paused_remaining = NULL,
+			    updated_at = NOW()
+			WHERE id = $1 AND paused_remaining IS NOT NULL;
+		`
+
+		if _, err = tx.Exec(resumeQuery, timerID); err != nil {
+			return nil, fmt.Errorf("failed to resume subathon timer: %w", err)
+		}
+	}
+
+	if rates != nil {
+		if _, err = tx.Exec(`DELETE FROM subathon_rates WHERE timer_id = $1;`, timerID); err != nil {
+			return nil, fmt.Errorf("failed to clear subathon rates: %w", err)
+		}
+
+		const rateQuery = `INSERT INTO subathon_rates (timer_id, currency, seconds_per_unit) VALUES ($1, $2, $3);`
+
+		for _, rate := range rates {
+			if _, err = tx.Exec(rateQuery, timerID, rate.Currency, rate.SecondsPerUnit); err != nil {
+				return nil, fmt.Errorf("failed to save subathon rate: %w", err)
+			}
+		}
+	}
+
+	timer, err := subathon.Find(tx, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return timer, nil
+}
@@ -7,25 +7,87 @@ import (
 	"net/http"
 	"strings"
 	"time"
+	"twitch-crypto-donations/internal/app/acceptcollabinvite"
+	"twitch-crypto-donations/internal/app/builddonationtransaction"
+	"twitch-crypto-donations/internal/app/closepoll"
+	"twitch-crypto-donations/internal/app/createapikey"
+	"twitch-crypto-donations/internal/app/createchallenge"
+	"twitch-crypto-donations/internal/app/createcollabgroup"
+	"twitch-crypto-donations/internal/app/creategiveaway"
+	"twitch-crypto-donations/internal/app/creategoal"
+	"twitch-crypto-donations/internal/app/createpaymentrequest"
+	"twitch-crypto-donations/internal/app/createpoll"
+	"twitch-crypto-donations/internal/app/deletegoal"
 	"twitch-crypto-donations/internal/app/donationsanalytics"
 	"twitch-crypto-donations/internal/app/donationshistory"
+	"twitch-crypto-donations/internal/app/getchallenges"
 	"twitch-crypto-donations/internal/app/getdefaultobssettings"
+	"twitch-crypto-donations/internal/app/getdonation"
+	"twitch-crypto-donations/internal/app/getdonationrules"
+	"twitch-crypto-donations/internal/app/getgiveaway"
+	"twitch-crypto-donations/internal/app/getgoal"
+	"twitch-crypto-donations/internal/app/getjwks"
+	"twitch-crypto-donations/internal/app/getpaymentrequest"
+	"twitch-crypto-donations/internal/app/getpolls"
+	"twitch-crypto-donations/internal/app/getrevenuesplits"
 	"twitch-crypto-donations/internal/app/getstreamerinfo"
+	"twitch-crypto-donations/internal/app/getsubathon"
+	"twitch-crypto-donations/internal/app/listapikeys"
+	"twitch-crypto-donations/internal/app/listchallenges"
+	"twitch-crypto-donations/internal/app/listcollabgroups"
+	"twitch-crypto-donations/internal/app/listdonations"
+	"twitch-crypto-donations/internal/app/listevents"
+	"twitch-crypto-donations/internal/app/listgiveaways"
+	"twitch-crypto-donations/internal/app/listgoals"
+	"twitch-crypto-donations/internal/app/listpolls"
+	"twitch-crypto-donations/internal/app/logout"
+	"twitch-crypto-donations/internal/app/logoutall"
 	"twitch-crypto-donations/internal/app/noncegeneration"
 	"twitch-crypto-donations/internal/app/paymentconfirmation"
+	"twitch-crypto-donations/internal/app/refreshsession"
+	"twitch-crypto-donations/internal/app/resolvechallenge"
+	"twitch-crypto-donations/internal/app/revokeapikey"
 	"twitch-crypto-donations/internal/app/senddonate"
+	"twitch-crypto-donations/internal/app/sendtestalert"
+	"twitch-crypto-donations/internal/app/setdonationrules"
 	"twitch-crypto-donations/internal/app/setobswebhooks"
+	"twitch-crypto-donations/internal/app/setrevenuesplits"
 	"twitch-crypto-donations/internal/app/setuserinfo"
 	"twitch-crypto-donations/internal/app/signatureverification"
+	"twitch-crypto-donations/internal/app/startsubathon"
+	"twitch-crypto-donations/internal/app/stopsubathon"
 	"twitch-crypto-donations/internal/app/updatedefaultobssettings"
+	"twitch-crypto-donations/internal/app/updategoal"
+	"twitch-crypto-donations/internal/app/updatesubathon"
+	"twitch-crypto-donations/internal/pkg/apikeys"
+	"twitch-crypto-donations/internal/pkg/chain"
+	"twitch-crypto-donations/internal/pkg/challenges"
+	"twitch-crypto-donations/internal/pkg/collab"
+	"twitch-crypto-donations/internal/pkg/donationrules"
 	"twitch-crypto-donations/internal/pkg/environment"
+	"twitch-crypto-donations/internal/pkg/evmverifier"
+	"twitch-crypto-donations/internal/pkg/finality"
+	"twitch-crypto-donations/internal/pkg/giveaways"
+	"twitch-crypto-donations/internal/pkg/goals"
 	httppkg "twitch-crypto-donations/internal/pkg/http"
+	"twitch-crypto-donations/internal/pkg/jobs"
 	"twitch-crypto-donations/internal/pkg/jwt"
 	"twitch-crypto-donations/internal/pkg/logger"
 	"twitch-crypto-donations/internal/pkg/middleware"
+	"twitch-crypto-donations/internal/pkg/mints"
 	"twitch-crypto-donations/internal/pkg/obsservice"
+	"twitch-crypto-donations/internal/pkg/polls"
+	"twitch-crypto-donations/internal/pkg/pricing"
 	"twitch-crypto-donations/internal/pkg/router"
 	"twitch-crypto-donations/internal/pkg/server"
+	"twitch-crypto-donations/internal/pkg/sessions"
+	"twitch-crypto-donations/internal/pkg/siws"
+	"twitch-crypto-donations/internal/pkg/solanapay"
+	"twitch-crypto-donations/internal/pkg/splits"
+	"twitch-crypto-donations/internal/pkg/subathon"
+	"twitch-crypto-donations/internal/pkg/txbuilder"
+	"twitch-crypto-donations/internal/pkg/txverifier"
+	"twitch-crypto-donations/internal/pkg/walletwatcher"
 
 	"github.com/gagliardetto/solana-go/rpc"
 	"github.com/gin-gonic/gin"
@@ -123,11 +185,15 @@ func NewEngine(
 	handlers router.Handlers,
 	prefixRouter environment.RoutePrefix,
 	swaggerPath environment.SwaggerPath,
-	secret environment.JwtSecret,
+	tokens *jwt.Manager,
+	sessions *sessions.Manager,
+	apiKeys *apikeys.Store,
 	logger *logger.LogrusAdapter,
 	middlewares []gin.HandlerFunc,
 ) *gin.Engine {
-	return router.New(gin.New(), handlers, prefixRouter, swaggerPath, middleware.NewJwtMiddleware(secret, logger), middlewares...)
+	jwtMiddleware := middleware.NewJwtMiddleware(tokens, sessions, logger)
+	apiKeyMiddleware := middleware.NewApiKeyMiddleware(apiKeys, logger)
+	return router.New(gin.New(), handlers, prefixRouter, swaggerPath, jwtMiddleware, apiKeyMiddleware, middlewares...)
 }
 
 func NewMiddlewares(appEnv environment.AppEnv, path environment.SwaggerPath) []gin.HandlerFunc {
@@ -146,14 +212,128 @@ func NewMiddlewares(appEnv environment.AppEnv, path environment.SwaggerPath) []g
 	return middlewares
 }
 
-func NewServer(engine *gin.Engine, listenPort environment.HTTPListenPort) *server.Server {
-	return server.New(engine, string(listenPort))
+func NewPriceProvider(
+	source environment.PriceSource,
+	staticPrices environment.StaticPrices,
+	apiURL environment.PriceAPIURL,
+	coinIDs environment.PriceCoinIDs,
+	cacheTTL environment.PriceCacheTTLSeconds,
+	httpClient *httppkg.Client,
+	logger *logger.LogrusAdapter,
+) (pricing.PriceProvider, error) {
+	switch source {
+	case "static":
+		return pricing.NewStatic(staticPrices)
+	case "http":
+		provider, err := pricing.NewHTTP(httpClient, logger, apiURL, coinIDs)
+		if err != nil {
+			return nil, err
+		}
+		return pricing.NewCache(provider, cacheTTL), nil
+	default:
+		return nil, fmt.Errorf("unknown price source %q", source)
+	}
+}
+
+func NewChainRegistry(
+	solanaVerifier *txverifier.Verifier,
+	mintRegistry *mints.Registry,
+	evmRpcURLs environment.EvmRpcURLs,
+	evmTokens environment.EvmTokens,
+	httpClient *httppkg.Client,
+) (*chain.Registry, error) {
+	registry := chain.NewRegistry()
+
+	solanaAssets := []chain.Asset{{Symbol: mints.NativeSymbol, Decimals: 9}}
+	for _, mint := range mintRegistry.Tokens() {
+		solanaAssets = append(solanaAssets, chain.Asset{Symbol: mint.Symbol, Token: mint.Address.String(), Decimals: mint.Decimals})
+	}
+	registry.Register(chain.Solana, solanaVerifier, solanaAssets)
+
+	urls, err := evmverifier.ParseRpcURLs(evmRpcURLs)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := evmverifier.ParseTokens(evmTokens)
+	if err != nil {
+		return nil, err
+	}
+
+	for id := range tokens {
+		if _, ok := urls[id]; !ok {
+			return nil, fmt.Errorf("evm tokens configured for chain %s without an rpc url", id)
+		}
+	}
+
+	for id, url := range urls {
+		if id == chain.Solana {
+			return nil, fmt.Errorf("chain %s cannot be configured as an evm chain", id)
+		}
+
+		assets := append([]chain.Asset{{Symbol: evmverifier.NativeSymbol, Decimals: evmverifier.NativeDecimals}}, tokens[id]...)
+		registry.Register(id, evmverifier.New(httpClient, url), assets)
+	}
+
+	return registry, nil
+}
+
+// nonceCleanupInterval matches the lifetime of a sign-in nonce, so an expired
+// nonce is gone at most one lifetime after it expired.
+const nonceCleanupInterval = 5 * time.Minute
+
+// sessionCleanupInterval is how often expired and long-revoked sessions are
+// deleted.
+const sessionCleanupInterval = time.Hour
+
+// NewJobRunner registers the periodic maintenance jobs. Nonce cleanup comes
+// first.
+func NewJobRunner(logger *logger.LogrusAdapter, nonces *noncegeneration.Handler, sessions *sessions.Manager) *jobs.Runner {
+	runner := jobs.NewRunner(logger)
+	runner.Register("nonce-cleanup", nonceCleanupInterval, nonces.CleanupExpiredNonces)
+	runner.Register("session-cleanup", sessionCleanupInterval, sessions.Cleanup)
+
+	return runner
+}
+
+func NewBackgroundTasks(
+	jobRunner *jobs.Runner,
+	watcher *walletwatcher.Watcher,
+	resolver *solanapay.Resolver,
+	reconciler *finality.Reconciler,
+	pollCloser *polls.Closer,
+	giveawayDrawer *giveaways.Drawer,
+) []server.BackgroundTask {
+	return []server.BackgroundTask{jobRunner, resolver, watcher, reconciler, pollCloser, giveawayDrawer}
+}
+
+func NewServer(engine *gin.Engine, listenPort environment.HTTPListenPort, tasks []server.BackgroundTask) *server.Server {
+	return server.New(engine, string(listenPort), tasks)
 }
 
 var WireSet = wire.NewSet(
 	environment.WireSet,
 	jwt.New,
+	sessions.New,
+	apikeys.New,
 	httppkg.New,
+	mints.New,
+	txverifier.New,
+	txbuilder.New,
+	walletwatcher.New,
+	solanapay.NewResolver,
+	pricing.NewValuer,
+	finality.New,
+	splits.New,
+	collab.New,
+	donationrules.New,
+	goals.New,
+	subathon.New,
+	polls.New,
+	polls.NewCloser,
+	challenges.New,
+	siws.New,
+	giveaways.NewDrawer,
 	obsservice.New,
 	senddonate.New,
 	setuserinfo.New,
@@ -163,8 +343,49 @@ var WireSet = wire.NewSet(
 	donationshistory.New,
 	donationsanalytics.New,
 	paymentconfirmation.New,
+	getpaymentrequest.New,
+	createpaymentrequest.New,
+	builddonationtransaction.New,
+	listdonations.New,
+	getdonation.New,
+	listevents.New,
+	getrevenuesplits.New,
+	setrevenuesplits.New,
+	createcollabgroup.New,
+	listcollabgroups.New,
+	acceptcollabinvite.New,
+	getdonationrules.New,
+	setdonationrules.New,
+	creategoal.New,
+	listgoals.New,
+	updategoal.New,
+	deletegoal.New,
+	getgoal.New,
+	startsubathon.New,
+	updatesubathon.New,
+	stopsubathon.New,
+	getsubathon.New,
+	createpoll.New,
+	listpolls.New,
+	closepoll.New,
+	getpolls.New,
+	createchallenge.New,
+	listchallenges.New,
+	resolvechallenge.New,
+	getchallenges.New,
+	creategiveaway.New,
+	listgiveaways.New,
+	getgiveaway.New,
 	getdefaultobssettings.New,
 	signatureverification.New,
+	refreshsession.New,
+	logout.New,
+	logoutall.New,
+	getjwks.New,
+	createapikey.New,
+	listapikeys.New,
+	revokeapikey.New,
+	sendtestalert.New,
 	updatedefaultobssettings.New,
 
 	wire.Bind(new(donationsanalytics.Database), new(*sql.DB)),
@@ -174,14 +395,141 @@ var WireSet = wire.NewSet(
 	wire.Bind(new(getstreamerinfo.Database), new(*sql.DB)),
 	wire.Bind(new(updatedefaultobssettings.ObsService), new(*obsservice.ObsService)),
 	wire.Bind(new(donationshistory.Database), new(*sql.DB)),
-	wire.Bind(new(paymentconfirmation.RpcClient), new(*rpc.Client)),
+	wire.Bind(new(paymentconfirmation.ChainRegistry), new(*chain.Registry)),
+	wire.Bind(new(paymentconfirmation.Database), new(*sql.DB)),
+	wire.Bind(new(paymentconfirmation.SplitPolicy), new(*splits.Policy)),
+	wire.Bind(new(paymentconfirmation.DonationSettler), new(*solanapay.Resolver)),
 	wire.Bind(new(noncegeneration.Database), new(*sql.DB)),
-	wire.Bind(new(signatureverification.JwtManager), new(*jwt.Manager)),
+	wire.Bind(new(noncegeneration.MessageIssuer), new(*siws.Issuer)),
+	wire.Bind(new(signatureverification.MessageValidator), new(*siws.Issuer)),
+	wire.Bind(new(signatureverification.SessionManager), new(*sessions.Manager)),
+	wire.Bind(new(sessions.Database), new(*sql.DB)),
+	wire.Bind(new(sessions.TokenIssuer), new(*jwt.Manager)),
+	wire.Bind(new(refreshsession.SessionManager), new(*sessions.Manager)),
+	wire.Bind(new(logout.SessionManager), new(*sessions.Manager)),
+	wire.Bind(new(logoutall.SessionManager), new(*sessions.Manager)),
+	wire.Bind(new(getjwks.KeySet), new(*jwt.Manager)),
+	wire.Bind(new(apikeys.Database), new(*sql.DB)),
+	wire.Bind(new(createapikey.KeyStore), new(*apikeys.Store)),
+	wire.Bind(new(listapikeys.KeyStore), new(*apikeys.Store)),
+	wire.Bind(new(revokeapikey.KeyStore), new(*apikeys.Store)),
+	wire.Bind(new(sendtestalert.ObsService), new(*obsservice.ObsService)),
 	wire.Bind(new(signatureverification.Database), new(*sql.DB)),
 	wire.Bind(new(setobswebhooks.ObsService), new(*obsservice.ObsService)),
 	wire.Bind(new(setobswebhooks.Database), new(*sql.DB)),
 	wire.Bind(new(senddonate.ObsService), new(*obsservice.ObsService)),
 	wire.Bind(new(senddonate.Database), new(*sql.DB)),
+	wire.Bind(new(senddonate.ChainRegistry), new(*chain.Registry)),
+	wire.Bind(new(senddonate.Valuer), new(*pricing.Valuer)),
+	wire.Bind(new(senddonate.SplitPolicy), new(*splits.Policy)),
+	wire.Bind(new(senddonate.CollabRegistry), new(*collab.Registry)),
+	wire.Bind(new(senddonate.RuleRegistry), new(*donationrules.Registry)),
+	wire.Bind(new(senddonate.GoalTracker), new(*goals.Tracker)),
+	wire.Bind(new(senddonate.SubathonTimer), new(*subathon.Tracker)),
+	wire.Bind(new(senddonate.PollTracker), new(*polls.Tracker)),
+	wire.Bind(new(senddonate.ChallengeTracker), new(*challenges.Tracker)),
+	wire.Bind(new(txverifier.RpcClient), new(*rpc.Client)),
+	wire.Bind(new(walletwatcher.Database), new(*sql.DB)),
+	wire.Bind(new(walletwatcher.RpcClient), new(*rpc.Client)),
+	wire.Bind(new(walletwatcher.TransferDetector), new(*txverifier.Verifier)),
+	wire.Bind(new(walletwatcher.MintRegistry), new(*mints.Registry)),
+	wire.Bind(new(walletwatcher.SplitPolicy), new(*splits.Policy)),
+	wire.Bind(new(walletwatcher.ObsService), new(*obsservice.ObsService)),
+	wire.Bind(new(walletwatcher.Logger), new(*logger.LogrusAdapter)),
+	wire.Bind(new(walletwatcher.Valuer), new(*pricing.Valuer)),
+	wire.Bind(new(walletwatcher.GoalTracker), new(*goals.Tracker)),
+	wire.Bind(new(walletwatcher.SubathonTimer), new(*subathon.Tracker)),
+	wire.Bind(new(walletwatcher.RuleRegistry), new(*donationrules.Registry)),
+	wire.Bind(new(solanapay.Database), new(*sql.DB)),
+	wire.Bind(new(solanapay.RpcClient), new(*rpc.Client)),
+	wire.Bind(new(solanapay.ChainRegistry), new(*chain.Registry)),
+	wire.Bind(new(solanapay.ObsService), new(*obsservice.ObsService)),
+	wire.Bind(new(solanapay.Logger), new(*logger.LogrusAdapter)),
+	wire.Bind(new(solanapay.Valuer), new(*pricing.Valuer)),
+	wire.Bind(new(solanapay.GoalTracker), new(*goals.Tracker)),
+	wire.Bind(new(solanapay.SubathonTimer), new(*subathon.Tracker)),
+	wire.Bind(new(solanapay.RuleRegistry), new(*donationrules.Registry)),
+	wire.Bind(new(pricing.Logger), new(*logger.LogrusAdapter)),
+	wire.Bind(new(createpaymentrequest.Database), new(*sql.DB)),
+	wire.Bind(new(createpaymentrequest.MintRegistry), new(*mints.Registry)),
+	wire.Bind(new(createpaymentrequest.SplitPolicy), new(*splits.Policy)),
+	wire.Bind(new(createpaymentrequest.RuleRegistry), new(*donationrules.Registry)),
+	wire.Bind(new(getpaymentrequest.Database), new(*sql.DB)),
+	wire.Bind(new(builddonationtransaction.Database), new(*sql.DB)),
+	wire.Bind(new(builddonationtransaction.MintRegistry), new(*mints.Registry)),
+	wire.Bind(new(builddonationtransaction.TransactionBuilder), new(*txbuilder.Builder)),
+	wire.Bind(new(builddonationtransaction.SplitPolicy), new(*splits.Policy)),
+	wire.Bind(new(builddonationtransaction.RuleRegistry), new(*donationrules.Registry)),
+	wire.Bind(new(txbuilder.RpcClient), new(*rpc.Client)),
+	wire.Bind(new(listdonations.Database), new(*sql.DB)),
+	wire.Bind(new(getdonation.Database), new(*sql.DB)),
+	wire.Bind(new(listevents.Database), new(*sql.DB)),
+	wire.Bind(new(getrevenuesplits.SplitPolicy), new(*splits.Policy)),
+	wire.Bind(new(setrevenuesplits.Database), new(*sql.DB)),
+	wire.Bind(new(setrevenuesplits.SplitPolicy), new(*splits.Policy)),
+	wire.Bind(new(splits.Database), new(*sql.DB)),
+	wire.Bind(new(collab.Database), new(*sql.DB)),
+	wire.Bind(new(createcollabgroup.Database), new(*sql.DB)),
+	wire.Bind(new(listcollabgroups.Database), new(*sql.DB)),
+	wire.Bind(new(acceptcollabinvite.Database), new(*sql.DB)),
+	wire.Bind(new(donationrules.Database), new(*sql.DB)),
+	wire.Bind(new(getdonationrules.RuleRegistry), new(*donationrules.Registry)),
+	wire.Bind(new(getdonationrules.MintRegistry), new(*mints.Registry)),
+	wire.Bind(new(setdonationrules.Database), new(*sql.DB)),
+	wire.Bind(new(setdonationrules.MintRegistry), new(*mints.Registry)),
+	wire.Bind(new(goals.ObsService), new(*obsservice.ObsService)),
+	wire.Bind(new(creategoal.Database), new(*sql.DB)),
+	wire.Bind(new(creategoal.MintRegistry), new(*mints.Registry)),
+	wire.Bind(new(creategoal.GoalTracker), new(*goals.Tracker)),
+	wire.Bind(new(listgoals.Database), new(*sql.DB)),
+	wire.Bind(new(updategoal.Database), new(*sql.DB)),
+	wire.Bind(new(updategoal.MintRegistry), new(*mints.Registry)),
+	wire.Bind(new(deletegoal.Database), new(*sql.DB)),
+	wire.Bind(new(getgoal.Database), new(*sql.DB)),
+	wire.Bind(new(subathon.ObsService), new(*obsservice.ObsService)),
+	wire.Bind(new(startsubathon.Database), new(*sql.DB)),
+	wire.Bind(new(startsubathon.MintRegistry), new(*mints.Registry)),
+	wire.Bind(new(startsubathon.TimerNotifier), new(*subathon.Tracker)),
+	wire.Bind(new(startsubathon.Logger), new(*logger.LogrusAdapter)),
+	wire.Bind(new(updatesubathon.Database), new(*sql.DB)),
+	wire.Bind(new(updatesubathon.MintRegistry), new(*mints.Registry)),
+	wire.Bind(new(updatesubathon.TimerNotifier), new(*subathon.Tracker)),
+	wire.Bind(new(updatesubathon.Logger), new(*logger.LogrusAdapter)),
+	wire.Bind(new(stopsubathon.Database), new(*sql.DB)),
+	wire.Bind(new(stopsubathon.TimerNotifier), new(*subathon.Tracker)),
+	wire.Bind(new(stopsubathon.Logger), new(*logger.LogrusAdapter)),
+	wire.Bind(new(getsubathon.Database), new(*sql.DB)),
+	wire.Bind(new(polls.ObsService), new(*obsservice.ObsService)),
+	wire.Bind(new(polls.Database), new(*sql.DB)),
+	wire.Bind(new(polls.Notifier), new(*polls.Tracker)),
+	wire.Bind(new(polls.Logger), new(*logger.LogrusAdapter)),
+	wire.Bind(new(createpoll.Database), new(*sql.DB)),
+	wire.Bind(new(createpoll.MintRegistry), new(*mints.Registry)),
+	wire.Bind(new(createpoll.PollTracker), new(*polls.Tracker)),
+	wire.Bind(new(listpolls.Database), new(*sql.DB)),
+	wire.Bind(new(closepoll.Database), new(*sql.DB)),
+	wire.Bind(new(getpolls.Database), new(*sql.DB)),
+	wire.Bind(new(challenges.ObsService), new(*obsservice.ObsService)),
+	wire.Bind(new(createchallenge.Database), new(*sql.DB)),
+	wire.Bind(new(createchallenge.MintRegistry), new(*mints.Registry)),
+	wire.Bind(new(createchallenge.ChallengeTracker), new(*challenges.Tracker)),
+	wire.Bind(new(createchallenge.Logger), new(*logger.LogrusAdapter)),
+	wire.Bind(new(resolvechallenge.Database), new(*sql.DB)),
+	wire.Bind(new(resolvechallenge.ChallengeTracker), new(*challenges.Tracker)),
+	wire.Bind(new(resolvechallenge.Logger), new(*logger.LogrusAdapter)),
+	wire.Bind(new(listchallenges.Database), new(*sql.DB)),
+	wire.Bind(new(getchallenges.Database), new(*sql.DB)),
+	wire.Bind(new(giveaways.DrawerDatabase), new(*sql.DB)),
+	wire.Bind(new(giveaways.RpcClient), new(*rpc.Client)),
+	wire.Bind(new(giveaways.Logger), new(*logger.LogrusAdapter)),
+	wire.Bind(new(creategiveaway.Database), new(*sql.DB)),
+	wire.Bind(new(creategiveaway.MintRegistry), new(*mints.Registry)),
+	wire.Bind(new(listgiveaways.Database), new(*sql.DB)),
+	wire.Bind(new(getgiveaway.Database), new(*sql.DB)),
+	wire.Bind(new(finality.Database), new(*sql.DB)),
+	wire.Bind(new(finality.ChainRegistry), new(*chain.Registry)),
+	wire.Bind(new(finality.SubathonTimer), new(*subathon.Tracker)),
+	wire.Bind(new(finality.Logger), new(*logger.LogrusAdapter)),
 	wire.Bind(new(obsservice.Logger), new(*logger.LogrusAdapter)),
 	wire.Bind(new(obsservice.Database), new(*sql.DB)),
 	wire.Bind(new(obsservice.HttpClient), new(*httppkg.Client)),
@@ -194,7 +542,11 @@ var WireSet = wire.NewSet(
 	NewConnectionString,
 	NewDatabase,
 	NewHttpClient,
+	NewPriceProvider,
+	NewChainRegistry,
 	NewMiddlewares,
 	NewEngine,
+	NewJobRunner,
+	NewBackgroundTasks,
 	NewServer,
 )
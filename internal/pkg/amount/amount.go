@@ -0,0 +1,95 @@
+package amount
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidAmount = errors.New("invalid amount")
+
+func Parse(value string, decimals uint8) (uint64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, fmt.Errorf("%w: empty value", ErrInvalidAmount)
+	}
+
+	whole, fraction, _ := strings.Cut(value, ".")
+	if whole == "" && fraction == "" {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, value)
+	}
+
+	if len(fraction) > int(decimals) {
+		trimmed := strings.TrimRight(fraction[decimals:], "0")
+		if trimmed != "" {
+			return 0, fmt.Errorf("%w: %q has more than %d decimal places", ErrInvalidAmount, value, decimals)
+		}
+		fraction = fraction[:decimals]
+	}
+
+	fraction += strings.Repeat("0", int(decimals)-len(fraction))
+
+	var units uint64
+	for _, r := range whole + fraction {
+		if r < '0' || r > '9' {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, value)
+		}
+
+		hi, lo := bits.Mul64(units, 10)
+		sum, carry := bits.Add64(lo, uint64(r-'0'), 0)
+		if hi != 0 || carry != 0 {
+			return 0, fmt.Errorf("%w: %q exceeds the largest supported amount %s", ErrInvalidAmount, value, Format(math.MaxUint64, decimals))
+		}
+
+		units = sum
+	}
+
+	return units, nil
+}
+
+func Format(units uint64, decimals uint8) string {
+	return format(strconv.FormatUint(units, 10), decimals)
+}
+
+// FormatNumeric renders a base-unit integer of any size, such as a NUMERIC sum
+// read from the database, without going through a fixed-width type.
+func FormatNumeric(units string, decimals uint8) (string, error) {
+	digits := strings.TrimLeft(strings.TrimSpace(units), "0")
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return "", fmt.Errorf("%w: %q", ErrInvalidAmount, units)
+		}
+	}
+
+	return format(digits, decimals), nil
+}
+
+// Trim drops the trailing zeros NUMERIC sums carry from the widest scale.
+func Trim(value string) string {
+	if !strings.Contains(value, ".") {
+		return value
+	}
+
+	return strings.TrimSuffix(strings.TrimRight(value, "0"), ".")
+}
+
+func format(digits string, decimals uint8) string {
+	if pad := int(decimals) + 1 - len(digits); pad > 0 {
+		digits = strings.Repeat("0", pad) + digits
+	}
+
+	if decimals == 0 {
+		return digits
+	}
+
+	split := len(digits) - int(decimals)
+	fraction := strings.TrimRight(digits[split:], "0")
+	if fraction == "" {
+		return digits[:split]
+	}
+
+	return digits[:split] + "." + fraction
+}
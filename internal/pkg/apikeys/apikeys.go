@@ -0,0 +1,218 @@
+package apikeys
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/mr-tron/base58"
+)
+
+const (
+	ScopeDonationsRead = "donations:read"
+	ScopeAlertsWrite   = "alerts:write"
+	ScopeSettingsWrite = "settings:write"
+)
+
+// Scopes are the scopes a key can be granted.
+var Scopes = []string{ScopeDonationsRead, ScopeAlertsWrite, ScopeSettingsWrite}
+
+const (
+	// keyPrefix marks our keys, so leaked ones are easy to recognise in logs
+	// and secret scanners.
+	keyPrefix = "kp_"
+	// displayLength is how much of a key is stored in clear to tell keys apart
+	// in listings.
+	displayLength = len(keyPrefix) + 8
+	// lastUsedPrecision throttles last-used updates to one write per key per
+	// minute.
+	lastUsedPrecision = time.Minute
+)
+
+var (
+	ErrKeyNotFound = errors.New("api key not found")
+	ErrInvalidKey  = errors.New("api key is invalid")
+	ErrKeyRevoked  = errors.New("api key has been revoked")
+	ErrKeyExpired  = errors.New("api key has expired")
+)
+
+type Database interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// Key is an API key a streamer created for a bot or integration. It acts for
+// the owner on the routes its scopes allow. Only a hash of the secret is kept.
+type Key struct {
+	ID         string
+	Owner      string
+	Name       string
+	Prefix     string
+	Scopes     []string
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+}
+
+func (k Key) Allows(scope string) bool {
+	return slices.Contains(k.Scopes, scope)
+}
+
+type Store struct {
+	db Database
+}
+
+func New(db Database) *Store {
+	return &Store{db: db}
+}
+
+// Create stores a new key and returns it with its secret, which is shown to
+// the owner once and cannot be recovered afterwards.
+func (s *Store) Create(owner, name string, scopes []string, expiresAt *time.Time) (*Key, string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return nil, "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+
+	secret := keyPrefix + base58.Encode(bytes)
+	key := &Key{
+		ID:        uuid.NewString(),
+		Owner:     owner,
+		Name:      name,
+		Prefix:    secret[:displayLength],
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	const insertQuery = `
+		INSERT INTO api_keys (id, owner, name, prefix, key_hash, scopes, expires_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8);
+	`
+
+	_, err := s.db.Exec(insertQuery, key.ID, owner, name, key.Prefix, hash(secret), pq.Array(scopes), expiresAt, key.CreatedAt)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to save api key: %w", err)
+	}
+
+	return key, secret, nil
+}
+
+// List returns the owner's keys, revoked ones included, newest first.
+func (s *Store) List(owner string) ([]Key, error) {
+	const listQuery = `
+		SELECT id, owner, name, prefix, scopes, expires_at, last_used_at, revoked_at, created_at
+		FROM api_keys
+		WHERE owner = $1
+		ORDER BY created_at DESC;
+	`
+
+	rows, err := s.db.Query(listQuery, owner)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make([]Key, 0, 4)
+	for rows.Next() {
+		var k Key
+		err = rows.Scan(&k.ID, &k.Owner, &k.Name, &k.Prefix, pq.Array(&k.Scopes), &k.ExpiresAt, &k.LastUsedAt, &k.RevokedAt, &k.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		keys = append(keys, k)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return keys, nil
+}
+
+// Revoke disables one of the owner's keys. Revoking a revoked key succeeds.
+func (s *Store) Revoke(owner, keyID string) error {
+	const revokeQuery = `
+		UPDATE api_keys
+		SET revoked_at = COALESCE(revoked_at, $3), updated_at = $3
+		WHERE id = $1 AND owner = $2;
+	`
+
+	result, err := s.db.Exec(revokeQuery, keyID, owner, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("%w: %s", ErrKeyNotFound, keyID)
+	}
+
+	return nil
+}
+
+// Authenticate returns the key the secret belongs to if it is neither revoked
+// nor expired, and records its use.
+func (s *Store) Authenticate(secret string) (*Key, error) {
+	if !strings.HasPrefix(secret, keyPrefix) {
+		return nil, ErrInvalidKey
+	}
+
+	const keyQuery = `
+		SELECT id, owner, name, prefix, scopes, expires_at, last_used_at, revoked_at, created_at
+		FROM api_keys
+		WHERE key_hash = $1;
+	`
+
+	var k Key
+	err := s.db.QueryRow(keyQuery, hash(secret)).Scan(
+		&k.ID, &k.Owner, &k.Name, &k.Prefix, pq.Array(&k.Scopes), &k.ExpiresAt, &k.LastUsedAt, &k.RevokedAt, &k.CreatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidKey
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to load api key: %w", err)
+	}
+
+	now := time.Now().UTC()
+	if k.RevokedAt != nil {
+		return nil, ErrKeyRevoked
+	}
+
+	if k.ExpiresAt != nil && !now.Before(*k.ExpiresAt) {
+		return nil, ErrKeyExpired
+	}
+
+	const usedQuery = `
+		UPDATE api_keys
+		SET last_used_at = $2
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < $3);
+	`
+
+	if _, err = s.db.Exec(usedQuery, k.ID, now, now.Add(-lastUsedPrecision)); err != nil {
+		return nil, fmt.Errorf("failed to record api key use: %w", err)
+	}
+
+	return &k, nil
+}
+
+func hash(secret string) string {
+	digest := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(digest[:])
+}
@@ -0,0 +1,180 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type ID string
+
+const (
+	Solana   ID = "solana"
+	Ethereum ID = "ethereum"
+	Base     ID = "base"
+)
+
+// Status is how far a transaction has progressed towards irreversibility.
+type Status string
+
+const (
+	StatusNotFound  Status = "not_found"
+	StatusConfirmed Status = "confirmed"
+	StatusFinalized Status = "finalized"
+	StatusFailed    Status = "failed"
+)
+
+const (
+	ReasonTransactionFailed  = "transaction_failed"
+	ReasonRecipientNotFound  = "recipient_not_in_transaction"
+	ReasonTransferNotFound   = "transfer_not_found"
+	ReasonInsufficientAmount = "insufficient_amount"
+	ReasonBalanceMismatch    = "balance_mismatch"
+)
+
+var ErrUnsupportedChain = errors.New("unsupported chain")
+
+// Transfer is a payment a transaction is expected to carry. Token is the
+// address of the token contract or mint, empty for the chain's native coin.
+type Transfer struct {
+	Sender    string
+	Recipient string
+	Token     string
+	Amount    uint64
+}
+
+// Result describes a verified transaction. Height is the slot or block number
+// the transaction was included in. Blockhash is the recent blockhash a Solana
+// transaction was signed with, which bounds how long it can land; it is empty
+// on other chains.
+type Result struct {
+	Height    uint64
+	Blockhash string
+	Sender    string
+	Token     string
+	Amount    uint64
+	Memos     []string
+}
+
+// Mismatch is returned when a transaction exists but does not pay what was expected.
+type Mismatch struct {
+	Reason   string
+	Message  string
+	Expected uint64
+	Actual   uint64
+}
+
+func (m *Mismatch) Error() string {
+	return m.Message
+}
+
+// Asset is a currency accepted on a chain.
+type Asset struct {
+	Symbol   string
+	Token    string
+	Decimals uint8
+}
+
+func (a Asset) IsNative() bool {
+	return a.Token == ""
+}
+
+// Reference returns the form of a transaction reference that is verified,
+// stored and looked up. EVM hashes are hex and name the same transaction in any
+// case, so they are lowercased; Solana signatures are base58 and case matters.
+func Reference(id ID, reference string) string {
+	if id == Solana {
+		return reference
+	}
+
+	return strings.ToLower(reference)
+}
+
+type PaymentVerifier interface {
+	Verify(ctx context.Context, reference string, expected []Transfer) (*Result, error)
+	Status(ctx context.Context, reference string) (Status, error)
+	// Expired reports whether a transaction seen at height, with blockhash on
+	// Solana, can no longer land once it is not found anymore.
+	Expired(ctx context.Context, height uint64, blockhash string) (bool, error)
+	Explain(err error) (*Mismatch, bool)
+}
+
+// Explain is the Explain implementation shared by verifiers that report
+// mismatches as *Mismatch errors.
+func Explain(err error) (*Mismatch, bool) {
+	var mismatch *Mismatch
+	if errors.As(err, &mismatch) {
+		return mismatch, true
+	}
+
+	return nil, false
+}
+
+type network struct {
+	verifier PaymentVerifier
+	assets   map[string]Asset
+}
+
+type Registry struct {
+	networks map[ID]network
+}
+
+func NewRegistry() *Registry {
+	return &Registry{networks: make(map[ID]network)}
+}
+
+func (r *Registry) Register(id ID, verifier PaymentVerifier, assets []Asset) {
+	n := network{verifier: verifier, assets: make(map[string]Asset, len(assets))}
+	for _, asset := range assets {
+		n.assets[strings.ToUpper(asset.Symbol)] = asset
+	}
+
+	r.networks[id] = n
+}
+
+func (r *Registry) Verifier(id ID) (PaymentVerifier, error) {
+	n, ok := r.networks[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedChain, id)
+	}
+
+	return n.verifier, nil
+}
+
+func (r *Registry) Asset(id ID, symbol string) (Asset, bool) {
+	n, ok := r.networks[id]
+	if !ok {
+		return Asset{}, false
+	}
+
+	asset, ok := n.assets[strings.ToUpper(symbol)]
+	return asset, ok
+}
+
+// Native returns the chain's native coin.
+func (r *Registry) Native(id ID) (Asset, bool) {
+	n, ok := r.networks[id]
+	if !ok {
+		return Asset{}, false
+	}
+
+	for _, asset := range n.assets {
+		if asset.IsNative() {
+			return asset, true
+		}
+	}
+
+	return Asset{}, false
+}
+
+func (r *Registry) Chains() []ID {
+	ids := make([]ID, 0, len(r.networks))
+	for id := range r.networks {
+		ids = append(ids, id)
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
@@ -0,0 +1,391 @@
+package challenges
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+	"twitch-crypto-donations/internal/pkg/amount"
+	"twitch-crypto-donations/internal/pkg/environment"
+	"twitch-crypto-donations/internal/pkg/obsservice"
+	"twitch-crypto-donations/internal/pkg/pricing"
+)
+
+// FiatDecimals is the precision of fiat challenge thresholds.
+const FiatDecimals = 2
+
+const (
+	StatusOpen      = "open"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+var (
+	ErrChallengeNotFound = errors.New("challenge not found")
+	ErrChallengeResolved = errors.New("challenge is already resolved")
+	ErrCurrencyMismatch  = errors.New("donation currency does not match the challenge")
+)
+
+type Database interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+type Executor interface {
+	QueryRow(query string, args ...any) *sql.Row
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+type ObsService interface {
+	WebhookChallengeCreated(wallet string, request obsservice.ChallengeEvent) (any, string, error)
+	WebhookChallengeFunded(wallet string, request obsservice.ChallengeEvent) (any, string, error)
+	WebhookChallengeResolved(wallet string, request obsservice.ChallengeEvent) (any, string, error)
+}
+
+// Challenge is a dare viewers pledge donations towards until the streamer marks
+// it completed or failed. Pledges are counted either in a currency or in a fiat
+// currency from donation valuations. Threshold and Pledged are decimal amounts
+// of that unit.
+type Challenge struct {
+	ID           string
+	Owner        string
+	Title        string
+	Description  *string
+	Currency     *string
+	FiatCurrency *string
+	Threshold    string
+	Pledged      string
+	Pledges      int64
+	Status       string
+	FundedAt     *time.Time
+	ResolvedAt   *time.Time
+	CreatedAt    time.Time
+}
+
+// Unit is the currency symbol or fiat code the challenge is counted in.
+func (c Challenge) Unit() string {
+	if c.Currency != nil {
+		return *c.Currency
+	}
+
+	return *c.FiatCurrency
+}
+
+// Percent is the pledged share of the threshold, which passes 100 once the
+// challenge is overfunded.
+func (c Challenge) Percent() float64 {
+	threshold, ok := new(big.Rat).SetString(c.Threshold)
+	if !ok || threshold.Sign() <= 0 {
+		return 0
+	}
+
+	pledged, ok := new(big.Rat).SetString(c.Pledged)
+	if !ok {
+		return 0
+	}
+
+	percent, _ := pledged.Mul(pledged, big.NewRat(100, 1)).Quo(pledged, threshold).Float64()
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(percent, 'f', 2, 64), 64)
+
+	return rounded
+}
+
+// Pledge is a confirmed donation pledged towards one of the receiver's
+// challenges.
+type Pledge struct {
+	ChallengeID string
+	DonationID  string
+	Receiver    string
+	Currency    string
+	Units       uint64
+	Decimals    uint8
+	Valuation   *pricing.Valuation
+}
+
+// Progress is the state of a challenge after a pledge. Funded is set only for
+// the pledge that took the challenge past its threshold.
+type Progress struct {
+	Challenge Challenge
+	Pledged   string
+	Funded    bool
+}
+
+type Tracker struct {
+	obsService ObsService
+	fiat       string
+}
+
+func New(obsService ObsService, fiat environment.FiatCurrency) *Tracker {
+	return &Tracker{obsService: obsService, fiat: pricing.Fiat(fiat)}
+}
+
+// FiatCurrencies are the fiat currencies donations are valued in, and so the
+// ones fiat challenges can be counted in.
+func (t *Tracker) FiatCurrencies() []string {
+	return pricing.FiatCurrencies(t.fiat)
+}
+
+// Validate checks that the challenge is an open challenge of the receiver that
+// counts donations in the given currency.
+func (t *Tracker) Validate(db Executor, receiver, challengeID, currency string) error {
+	const challengeQuery = `SELECT currency, status FROM challenges WHERE id = $1 AND owner = $2;`
+
+	var (
+		challengeCurrency *string
+		status            string
+	)
+
+	err := db.QueryRow(challengeQuery, challengeID, receiver).Scan(&challengeCurrency, &status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %s", ErrChallengeNotFound, challengeID)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to load challenge: %w", err)
+	}
+
+	if status != StatusOpen {
+		return ErrChallengeResolved
+	}
+
+	if challengeCurrency != nil && *challengeCurrency != currency {
+		return fmt.Errorf("%w: challenge counts %s", ErrCurrencyMismatch, *challengeCurrency)
+	}
+
+	return nil
+}
+
+// Pledge records the donation towards the challenge. It returns nil when the
+// challenge was resolved in the meantime, or when the donation has no valuation
+// in a fiat challenge's currency.
+func (t *Tracker) Pledge(db Executor, pledge Pledge) (*Progress, error) {
+	const challengeQuery = `
+		SELECT currency, fiat_currency
+		FROM challenges
+		WHERE id = $1 AND owner = $2 AND status = $3
+		FOR UPDATE;
+	`
+
+	var currency, fiatCurrency *string
+
+	err := db.QueryRow(challengeQuery, pledge.ChallengeID, pledge.Receiver, StatusOpen).Scan(&currency, &fiatCurrency)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to load challenge: %w", err)
+	}
+
+	var pledged string
+	switch {
+	case currency != nil && *currency == pledge.Currency:
+		pledged = amount.Format(pledge.Units, pledge.Decimals)
+	case fiatCurrency != nil && pledge.Valuation != nil && *fiatCurrency == pricing.USD:
+		pledged = pledge.Valuation.USD
+	case fiatCurrency != nil && pledge.Valuation != nil && *fiatCurrency == pledge.Valuation.FiatCurrency:
+		pledged = pledge.Valuation.Fiat
+	default:
+		return nil, nil
+	}
+
+	const insertQuery = `
+		INSERT INTO challenge_pledges (challenge_id, donation_id, amount)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (challenge_id, donation_id) DO NOTHING;
+	`
+
+	if _, err = db.Exec(insertQuery, pledge.ChallengeID, pledge.DonationID, pledged); err != nil {
+		return nil, fmt.Errorf("failed to save challenge pledge: %w", err)
+	}
+
+	funded, err := markFunded(db, pledge.ChallengeID)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge, err := Find(db, pledge.ChallengeID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Progress{Challenge: *challenge, Pledged: pledged, Funded: funded}, nil
+}
+
+// Created announces a new challenge on the owner's overlay.
+func (t *Tracker) Created(challenge Challenge) error {
+	if _, _, err := t.obsService.WebhookChallengeCreated(challenge.Owner, event(challenge)); err != nil {
+		return fmt.Errorf("failed to send challenge created: %w", err)
+	}
+
+	return nil
+}
+
+// Notify sends challenge-funded when the pledge took the challenge past its
+// threshold, and nothing otherwise.
+func (t *Tracker) Notify(progress Progress, username *string) error {
+	if !progress.Funded {
+		return nil
+	}
+
+	e := event(progress.Challenge)
+	e.Username = username
+	if value, err := strconv.ParseFloat(progress.Pledged, 64); err == nil {
+		e.Amount = &value
+	}
+
+	if _, _, err := t.obsService.WebhookChallengeFunded(progress.Challenge.Owner, e); err != nil {
+		return fmt.Errorf("failed to send challenge funded: %w", err)
+	}
+
+	return nil
+}
+
+// Resolved announces that the streamer completed or failed the challenge.
+func (t *Tracker) Resolved(challenge Challenge) error {
+	if _, _, err := t.obsService.WebhookChallengeResolved(challenge.Owner, event(challenge)); err != nil {
+		return fmt.Errorf("failed to send challenge resolved: %w", err)
+	}
+
+	return nil
+}
+
+func event(challenge Challenge) obsservice.ChallengeEvent {
+	e := obsservice.ChallengeEvent{
+		ChallengeID: challenge.ID,
+		Title:       challenge.Title,
+		Description: challenge.Description,
+		Currency:    challenge.Unit(),
+		Progress:    challenge.Percent(),
+		Status:      challenge.Status,
+	}
+	e.Pledged, _ = strconv.ParseFloat(challenge.Pledged, 64)
+	e.Threshold, _ = strconv.ParseFloat(challenge.Threshold, 64)
+
+	return e
+}
+
+// markFunded records when the pledges first covered the threshold and reports
+// whether that happened just now.
+func markFunded(db Executor, challengeID string) (bool, error) {
+	const updateQuery = `
+		UPDATE challenges c
+		SET funded_at = NOW(), updated_at = NOW()
+		WHERE c.id = $1 AND c.funded_at IS NULL AND c.threshold <= (
+			SELECT COALESCE(SUM(p.amount), 0)
+			FROM challenge_pledges p
+			JOIN donations d ON d.id = p.donation_id
+			WHERE p.challenge_id = c.id AND d.state <> 'reverted'
+		);
+	`
+
+	result, err := db.Exec(updateQuery, challengeID)
+	if err != nil {
+		return false, fmt.Errorf("failed to update challenge %s: %w", challengeID, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to update challenge %s: %w", challengeID, err)
+	}
+
+	return affected == 1, nil
+}
+
+const selectChallenges = `
+	SELECT c.id, c.owner, c.title, c.description, c.currency, c.fiat_currency, c.threshold,
+	       COALESCE(SUM(p.amount) FILTER (WHERE d.state <> 'reverted'), 0),
+	       COUNT(d.id) FILTER (WHERE d.state <> 'reverted'),
+	       c.status, c.funded_at, c.resolved_at, c.created_at
+	FROM challenges c
+	LEFT JOIN challenge_pledges p ON p.challenge_id = c.id
+	LEFT JOIN donations d ON d.id = p.donation_id
+`
+
+// Find returns the challenge with its pledges, or ErrChallengeNotFound.
+func Find(db Executor, challengeID string) (*Challenge, error) {
+	query := selectChallenges + ` WHERE c.id = $1 GROUP BY c.id;`
+
+	challenge, err := scan(db.QueryRow(query, challengeID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrChallengeNotFound, challengeID)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &challenge, nil
+}
+
+// List returns the owner's challenges with their pledges, open ones first.
+func List(db Database, owner string) ([]Challenge, error) {
+	query := selectChallenges + `
+		WHERE c.owner = $1
+		GROUP BY c.id
+		ORDER BY c.status = 'open' DESC, c.created_at DESC;
+	`
+
+	return list(db, query, owner)
+}
+
+// ListByUsername returns the streamer's open challenges followed by the most
+// recently resolved ones, up to limit challenges.
+func ListByUsername(db Database, username string, limit int) ([]Challenge, error) {
+	query := selectChallenges + `
+		JOIN users u ON u.wallet = c.owner
+		WHERE u.username = $1
+		GROUP BY c.id
+		ORDER BY c.status = 'open' DESC, COALESCE(c.resolved_at, c.created_at) DESC
+		LIMIT $2;
+	`
+
+	return list(db, query, username, limit)
+}
+
+func list(db Database, query string, args ...any) ([]Challenge, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	challenges := make([]Challenge, 0, 2)
+	for rows.Next() {
+		challenge, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		challenges = append(challenges, challenge)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return challenges, nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scan(row scanner) (Challenge, error) {
+	var c Challenge
+	err := row.Scan(
+		&c.ID, &c.Owner, &c.Title, &c.Description, &c.Currency, &c.FiatCurrency, &c.Threshold, &c.Pledged,
+		&c.Pledges, &c.Status, &c.FundedAt, &c.ResolvedAt, &c.CreatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Challenge{}, err
+	}
+
+	if err != nil {
+		return Challenge{}, fmt.Errorf("failed to scan row: %w", err)
+	}
+
+	c.Threshold, c.Pledged = amount.Trim(c.Threshold), amount.Trim(c.Pledged)
+
+	return c, nil
+}
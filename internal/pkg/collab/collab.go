@@ -0,0 +1,101 @@
+package collab
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"twitch-crypto-donations/internal/pkg/splits"
+)
+
+type Status string
+
+const (
+	StatusInvited  Status = "invited"
+	StatusAccepted Status = "accepted"
+)
+
+const MaxMembers = 8
+
+var (
+	ErrGroupNotFound = errors.New("collab group not found")
+	ErrGroupInactive = errors.New("collab group has pending invitations")
+)
+
+type Database interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+type Member struct {
+	Wallet string
+	Bps    uint64
+	Status Status
+}
+
+type Registry struct {
+	db Database
+}
+
+func New(db Database) *Registry {
+	return &Registry{db: db}
+}
+
+// Members returns the members of a group that every invitee has accepted, with
+// the owner first.
+func (r *Registry) Members(groupID string) ([]Member, error) {
+	const query = `
+		SELECT m.wallet, m.bps, m.status
+		FROM collab_members m
+		JOIN collab_groups g ON g.id = m.group_id
+		WHERE m.group_id = $1
+		ORDER BY (m.wallet = g.owner) DESC, m.id;
+	`
+
+	rows, err := r.db.Query(query, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	members := make([]Member, 0, 2)
+	for rows.Next() {
+		var m Member
+		if err = rows.Scan(&m.Wallet, &m.Bps, &m.Status); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		members = append(members, m)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	if len(members) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrGroupNotFound, groupID)
+	}
+
+	for _, m := range members {
+		if m.Status != StatusAccepted {
+			return nil, fmt.Errorf("%w: %s has not accepted", ErrGroupInactive, m.Wallet)
+		}
+	}
+
+	return members, nil
+}
+
+// Divide splits a gross amount between members by their ratios. Rounding
+// leftovers go to the first member.
+func Divide(gross uint64, members []Member) []uint64 {
+	portions := make([]uint64, len(members))
+	if len(members) == 0 {
+		return portions
+	}
+
+	remainder := gross
+	for i := 1; i < len(members); i++ {
+		portions[i] = splits.Proportion(gross, members[i].Bps)
+		remainder -= portions[i]
+	}
+	portions[0] = remainder
+
+	return portions
+}
@@ -0,0 +1,259 @@
+package donationrules
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"twitch-crypto-donations/internal/pkg/amount"
+)
+
+const (
+	ReasonBelowAlertMinimum     = "below_alert_minimum"
+	ReasonBelowVoiceMinimum     = "below_voice_minimum"
+	ReasonBelowMediaMinimum     = "below_media_minimum"
+	ReasonMediaDurationRequired = "media_duration_required"
+)
+
+type Database interface {
+	QueryRow(query string, args ...any) *sql.Row
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// Rules are a streamer's minimum amounts for the overlay features of a
+// donation in one currency, in base units. Zero means no minimum.
+type Rules struct {
+	Currency            string
+	MinAlert            uint64
+	MinVoice            uint64
+	MinMedia            uint64
+	MediaPricePerSecond uint64
+}
+
+// Features are the overlay features a donation asks for. MediaSeconds is the
+// requested playtime, or nil when the donor left it open.
+type Features struct {
+	Alert        bool
+	Voice        bool
+	Media        bool
+	MediaSeconds *uint64
+}
+
+type Violation struct {
+	Reason  string
+	Message string
+}
+
+type Registry struct {
+	db Database
+}
+
+func New(db Database) *Registry {
+	return &Registry{db: db}
+}
+
+func (r *Registry) Rules(receiver string) ([]Rules, error) {
+	const query = `
+		SELECT currency, min_alert, min_voice, min_media, media_price_per_second
+		FROM donation_rules
+		WHERE receiver = $1
+		ORDER BY currency;
+	`
+
+	rows, err := r.db.Query(query, receiver)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	rules := make([]Rules, 0, 1)
+	for rows.Next() {
+		rule, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return rules, nil
+}
+
+// ForCurrency returns the receiver's rules for currency, or nil when the
+// receiver has not set any.
+func (r *Registry) ForCurrency(receiver, currency string) (*Rules, error) {
+	const query = `
+		SELECT currency, min_alert, min_voice, min_media, media_price_per_second
+		FROM donation_rules
+		WHERE receiver = $1 AND currency = $2;
+	`
+
+	rule, err := scan(r.db.QueryRow(query, receiver, currency))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &rule, nil
+}
+
+// Check lists the violations of the receiver's rules for currency by a
+// donation of units, or none when the receiver has not set any.
+func (r *Registry) Check(receiver, currency string, units uint64, decimals uint8, features Features) ([]Violation, error) {
+	rules, err := r.ForCurrency(receiver, currency)
+	if err != nil || rules == nil {
+		return nil, err
+	}
+
+	return rules.Check(units, decimals, features), nil
+}
+
+// Allow turns off the features a paid donation of units does not pay enough
+// for. A donation that is already paid cannot be rejected, so it is shown
+// without them instead; a voice message is dropped along with its alert.
+func (r *Registry) Allow(receiver, currency string, units uint64, decimals uint8, features Features) (Features, error) {
+	violations, err := r.Check(receiver, currency, units, decimals, features)
+	if err != nil {
+		return features, err
+	}
+
+	for _, violation := range violations {
+		switch violation.Reason {
+		case ReasonBelowAlertMinimum:
+			features.Alert, features.Voice = false, false
+		case ReasonBelowVoiceMinimum:
+			features.Voice = false
+		case ReasonBelowMediaMinimum, ReasonMediaDurationRequired:
+			features.Media = false
+		}
+	}
+
+	return features, nil
+}
+
+// Err joins the messages of violations into a single error, or returns nil
+// when there are none.
+func Err(violations []Violation) error {
+	if len(violations) == 0 {
+		return nil
+	}
+
+	messages := make([]string, 0, len(violations))
+	for _, violation := range violations {
+		messages = append(messages, violation.Message)
+	}
+
+	return errors.New(strings.Join(messages, "; "))
+}
+
+// MediaSeconds is the requested media playtime: the clip between startTime and
+// endTime, or durationMs rounded up to whole seconds. It is nil when neither
+// is set.
+func MediaSeconds(startTime, endTime, durationMs *int64) *uint64 {
+	if endTime != nil {
+		var start int64
+		if startTime != nil {
+			start = *startTime
+		}
+
+		if *endTime > start {
+			seconds := uint64(*endTime - start)
+			return &seconds
+		}
+	}
+
+	if durationMs != nil && *durationMs > 0 {
+		seconds := uint64(*durationMs+999) / 1000
+		return &seconds
+	}
+
+	return nil
+}
+
+// MediaPrice is the amount media of the given playtime needs: the media
+// minimum or the per-second price, whichever is higher.
+func (r Rules) MediaPrice(seconds uint64) uint64 {
+	hi, price := bits.Mul64(r.MediaPricePerSecond, seconds)
+	if hi != 0 {
+		price = ^uint64(0)
+	}
+
+	return max(price, r.MinMedia)
+}
+
+// Check lists every feature the donation of units asks for but does not pay
+// enough for.
+func (r Rules) Check(units uint64, decimals uint8, features Features) []Violation {
+	violations := make([]Violation, 0)
+	below := func(minimum uint64, reason, feature string) {
+		if units < minimum {
+			violations = append(violations, Violation{
+				Reason: reason,
+				Message: fmt.Sprintf("%s requires at least %s %s, got %s",
+					feature, amount.Format(minimum, decimals), r.Currency, amount.Format(units, decimals)),
+			})
+		}
+	}
+
+	if features.Alert {
+		below(r.MinAlert, ReasonBelowAlertMinimum, "alert")
+	}
+
+	if features.Voice {
+		below(r.MinVoice, ReasonBelowVoiceMinimum, "voice message")
+	}
+
+	if features.Media {
+		if features.MediaSeconds == nil && r.MediaPricePerSecond > 0 {
+			violations = append(violations, Violation{
+				Reason:  ReasonMediaDurationRequired,
+				Message: "media playtime must be set with end_time or duration_ms",
+			})
+		} else {
+			var seconds uint64
+			if features.MediaSeconds != nil {
+				seconds = *features.MediaSeconds
+			}
+			below(r.MediaPrice(seconds), ReasonBelowMediaMinimum, "media")
+		}
+	}
+
+	return violations
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scan(row scanner) (Rules, error) {
+	var (
+		rule   Rules
+		values [4]string
+	)
+
+	if err := row.Scan(&rule.Currency, &values[0], &values[1], &values[2], &values[3]); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Rules{}, err
+		}
+		return Rules{}, fmt.Errorf("failed to scan row: %w", err)
+	}
+
+	targets := []*uint64{&rule.MinAlert, &rule.MinVoice, &rule.MinMedia, &rule.MediaPricePerSecond}
+	for i, value := range values {
+		parsed, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return Rules{}, fmt.Errorf("invalid donation rule amount %q: %w", value, err)
+		}
+		*targets[i] = parsed
+	}
+
+	return rule, nil
+}
@@ -0,0 +1,166 @@
+package donations
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"twitch-crypto-donations/internal/pkg/chain"
+	"twitch-crypto-donations/internal/pkg/pricing"
+
+	"github.com/lib/pq"
+)
+
+type State string
+
+const (
+	StateIntentCreated  State = "intent_created"
+	StatePaymentSeen    State = "payment_seen"
+	StateConfirmed      State = "confirmed"
+	StateFinalized      State = "finalized"
+	StateAlertDelivered State = "alert_delivered"
+	StateAlertFailed    State = "alert_failed"
+	StateExpired        State = "expired"
+	StateReverted       State = "reverted"
+)
+
+var ErrInvalidTransition = errors.New("invalid donation state transition")
+
+var transitions = map[State][]State{
+	StateIntentCreated:  {StatePaymentSeen, StateExpired},
+	StatePaymentSeen:    {StateConfirmed, StateExpired},
+	StateConfirmed:      {StateFinalized, StateAlertDelivered, StateAlertFailed, StateReverted},
+	StateFinalized:      {StateAlertDelivered, StateAlertFailed},
+	StateAlertFailed:    {StateAlertDelivered, StateAlertFailed, StateReverted},
+	StateAlertDelivered: {StateReverted},
+}
+
+type Executor interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+type Donation struct {
+	ID             string
+	Chain          chain.ID
+	Receiver       string
+	SenderAddress  *string
+	SenderUsername string
+	Amount         uint64
+	Decimals       uint8
+	Currency       string
+	TxSignature    *string
+	// TxHeight and TxBlockhash are the slot or block the transaction was seen
+	// in and its Solana blockhash, zero when unknown. The finality reconciler
+	// uses them to tell whether a transaction it no longer finds can still land.
+	TxHeight      uint64
+	TxBlockhash   string
+	CollabGroupID *string
+	// Overlay is the content of the overlay events the donor asked for, kept
+	// so that failed events are sent again exactly as they were.
+	Overlay []byte
+}
+
+func Create(db Executor, donation Donation, state State, reason string) error {
+	const insertQuery = `
+		WITH created AS (
+			INSERT INTO donations (id, receiver, sender_address, sender_username, amount, decimals, currency, tx_signature, collab_group_id, state, chain, overlay, tx_height, tx_blockhash)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $12, $13, NULLIF($14::BIGINT, 0), NULLIF($15::TEXT, ''))
+			RETURNING id
+		)
+		INSERT INTO donation_transitions (donation_id, from_state, to_state, reason)
+		SELECT id, NULL, $10, $11 FROM created;
+	`
+
+	chainID := donation.Chain
+	if chainID == "" {
+		chainID = chain.Solana
+	}
+
+	_, err := db.Exec(insertQuery,
+		donation.ID, donation.Receiver, donation.SenderAddress, donation.SenderUsername,
+		strconv.FormatUint(donation.Amount, 10), donation.Decimals, donation.Currency, donation.TxSignature,
+		donation.CollabGroupID, string(state), reason, string(chainID), donation.Overlay,
+		int64(donation.TxHeight), donation.TxBlockhash,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create donation: %w", err)
+	}
+
+	return nil
+}
+
+func Transition(db Executor, id string, to State, reason string) error {
+	const updateQuery = `
+		WITH current AS (
+			SELECT id, state FROM donations WHERE id = $1 FOR UPDATE
+		), updated AS (
+			UPDATE donations d
+			SET state = $2, updated_at = NOW()
+			FROM current c
+			WHERE d.id = c.id AND c.state = ANY($3)
+			RETURNING d.id, c.state AS from_state
+		)
+		INSERT INTO donation_transitions (donation_id, from_state, to_state, reason)
+		SELECT id, from_state, $2, $4 FROM updated;
+	`
+
+	result, err := db.Exec(updateQuery, id, string(to), pq.Array(sources(to)), reason)
+	if err != nil {
+		return fmt.Errorf("failed to transition donation %s to %s: %w", id, to, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to transition donation %s to %s: %w", id, to, err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("%w: donation %s cannot move to %s", ErrInvalidTransition, id, to)
+	}
+
+	return nil
+}
+
+func AttachPayment(db Executor, id, signature string, result *chain.Result) error {
+	const updateQuery = `
+		UPDATE donations
+		SET tx_signature = $2, sender_address = COALESCE(sender_address, $3),
+		    tx_height = NULLIF($4::BIGINT, 0), tx_blockhash = NULLIF($5::TEXT, ''), updated_at = NOW()
+		WHERE id = $1;
+	`
+
+	_, err := db.Exec(updateQuery, id, signature, result.Sender, int64(result.Height), result.Blockhash)
+	if err != nil {
+		return fmt.Errorf("failed to attach payment to donation %s: %w", id, err)
+	}
+
+	return nil
+}
+
+func AttachValuation(db Executor, id string, valuation pricing.Valuation) error {
+	const updateQuery = `
+		UPDATE donations
+		SET usd_value = $2, fiat_value = $3, fiat_currency = $4, priced_at = $5, updated_at = NOW()
+		WHERE id = $1;
+	`
+
+	_, err := db.Exec(updateQuery, id, valuation.USD, valuation.Fiat, valuation.FiatCurrency, valuation.PricedAt)
+	if err != nil {
+		return fmt.Errorf("failed to attach valuation to donation %s: %w", id, err)
+	}
+
+	return nil
+}
+
+func sources(to State) []string {
+	from := make([]string, 0, len(transitions))
+	for state, targets := range transitions {
+		for _, target := range targets {
+			if target == to {
+				from = append(from, string(state))
+			}
+		}
+	}
+
+	return from
+}
@@ -26,10 +26,37 @@ type (
 
 	OBSServiceDomain string
 
-	JwtSecret            string
-	TokenExpirationHours int
+	JwtSecret               string
+	JwtAlgorithm            string
+	JwtSigningKeyFile       string
+	JwtVerificationKeyFiles string
+	AccessTokenTTLMinutes   int
+	RefreshTokenTTLHours    int
 
-	RpcEndpoint string
+	SIWSDomain  string
+	SIWSURI     string
+	SIWSChainID string
+
+	RpcEndpoint   string
+	AcceptedMints string
+
+	EvmRpcURLs string
+	EvmTokens  string
+
+	WatcherPollIntervalSeconds int
+	WatcherMinAmounts          string
+	PaymentRequestTTLMinutes   int
+	FinalityCheckDelaySeconds  int
+
+	PlatformFeeBps    int
+	PlatformFeeWallet string
+
+	PriceSource          string
+	PriceAPIURL          string
+	PriceCoinIDs         string
+	StaticPrices         string
+	PriceCacheTTLSeconds int
+	FiatCurrency         string
 )
 
 func getEnv(key string) (string, error) {
@@ -105,14 +132,54 @@ func GetJwtSecret() (JwtSecret, error) {
 	return JwtSecret(val), err
 }
 
-func GetTokenExpirationHours() (TokenExpirationHours, error) {
-	val, err := getEnv("JWT_TOKEN_EXPIRATION_HOURS")
+func GetJwtAlgorithm() (JwtAlgorithm, error) {
+	val, err := getEnv("JWT_ALGORITHM")
+	return JwtAlgorithm(val), err
+}
+
+func GetJwtSigningKeyFile() (JwtSigningKeyFile, error) {
+	val, err := getEnv("JWT_SIGNING_KEY_FILE")
+	return JwtSigningKeyFile(val), err
+}
+
+func GetJwtVerificationKeyFiles() (JwtVerificationKeyFiles, error) {
+	val, err := getEnv("JWT_VERIFICATION_KEY_FILES")
+	return JwtVerificationKeyFiles(val), err
+}
+
+func GetAccessTokenTTLMinutes() (AccessTokenTTLMinutes, error) {
+	val, err := getEnv("JWT_ACCESS_TOKEN_TTL_MINUTES")
+	if err != nil {
+		return 0, err
+	}
+
+	rv, err := strconv.Atoi(val)
+	return AccessTokenTTLMinutes(rv), err
+}
+
+func GetRefreshTokenTTLHours() (RefreshTokenTTLHours, error) {
+	val, err := getEnv("REFRESH_TOKEN_TTL_HOURS")
 	if err != nil {
 		return 0, err
 	}
 
 	rv, err := strconv.Atoi(val)
-	return TokenExpirationHours(rv), err
+	return RefreshTokenTTLHours(rv), err
+}
+
+func GetSIWSDomain() (SIWSDomain, error) {
+	val, err := getEnv("SIWS_DOMAIN")
+	return SIWSDomain(val), err
+}
+
+func GetSIWSURI() (SIWSURI, error) {
+	val, err := getEnv("SIWS_URI")
+	return SIWSURI(val), err
+}
+
+func GetSIWSChainID() (SIWSChainID, error) {
+	val, err := getEnv("SIWS_CHAIN_ID")
+	return SIWSChainID(val), err
 }
 
 func GetRpcEndpoint() (RpcEndpoint, error) {
@@ -120,6 +187,106 @@ func GetRpcEndpoint() (RpcEndpoint, error) {
 	return RpcEndpoint(val), err
 }
 
+func GetAcceptedMints() (AcceptedMints, error) {
+	val, err := getEnv("ACCEPTED_MINTS")
+	return AcceptedMints(val), err
+}
+
+func GetEvmRpcURLs() (EvmRpcURLs, error) {
+	val, err := getEnv("EVM_RPC_URLS")
+	return EvmRpcURLs(val), err
+}
+
+func GetEvmTokens() (EvmTokens, error) {
+	val, err := getEnv("EVM_TOKENS")
+	return EvmTokens(val), err
+}
+
+func GetWatcherPollIntervalSeconds() (WatcherPollIntervalSeconds, error) {
+	val, err := getEnv("WATCHER_POLL_INTERVAL_SECONDS")
+	if err != nil {
+		return 0, err
+	}
+
+	rv, err := strconv.Atoi(val)
+	return WatcherPollIntervalSeconds(rv), err
+}
+
+func GetWatcherMinAmounts() (WatcherMinAmounts, error) {
+	val, err := getEnv("WATCHER_MIN_AMOUNTS")
+	return WatcherMinAmounts(val), err
+}
+
+func GetPaymentRequestTTLMinutes() (PaymentRequestTTLMinutes, error) {
+	val, err := getEnv("PAYMENT_REQUEST_TTL_MINUTES")
+	if err != nil {
+		return 0, err
+	}
+
+	rv, err := strconv.Atoi(val)
+	return PaymentRequestTTLMinutes(rv), err
+}
+
+func GetFinalityCheckDelaySeconds() (FinalityCheckDelaySeconds, error) {
+	val, err := getEnv("FINALITY_CHECK_DELAY_SECONDS")
+	if err != nil {
+		return 0, err
+	}
+
+	rv, err := strconv.Atoi(val)
+	return FinalityCheckDelaySeconds(rv), err
+}
+
+func GetPlatformFeeBps() (PlatformFeeBps, error) {
+	val, err := getEnv("PLATFORM_FEE_BPS")
+	if err != nil {
+		return 0, err
+	}
+
+	rv, err := strconv.Atoi(val)
+	return PlatformFeeBps(rv), err
+}
+
+func GetPlatformFeeWallet() (PlatformFeeWallet, error) {
+	val, err := getEnv("PLATFORM_FEE_WALLET")
+	return PlatformFeeWallet(val), err
+}
+
+func GetPriceSource() (PriceSource, error) {
+	val, err := getEnv("PRICE_SOURCE")
+	return PriceSource(val), err
+}
+
+func GetPriceAPIURL() (PriceAPIURL, error) {
+	val, err := getEnv("PRICE_API_URL")
+	return PriceAPIURL(val), err
+}
+
+func GetPriceCoinIDs() (PriceCoinIDs, error) {
+	val, err := getEnv("PRICE_COIN_IDS")
+	return PriceCoinIDs(val), err
+}
+
+func GetStaticPrices() (StaticPrices, error) {
+	val, err := getEnv("STATIC_PRICES")
+	return StaticPrices(val), err
+}
+
+func GetPriceCacheTTLSeconds() (PriceCacheTTLSeconds, error) {
+	val, err := getEnv("PRICE_CACHE_TTL_SECONDS")
+	if err != nil {
+		return 0, err
+	}
+
+	rv, err := strconv.Atoi(val)
+	return PriceCacheTTLSeconds(rv), err
+}
+
+func GetFiatCurrency() (FiatCurrency, error) {
+	val, err := getEnv("FIAT_CURRENCY")
+	return FiatCurrency(val), err
+}
+
 var WireSet = wire.NewSet(
 	GetHTTPListenPort,
 	GetRoutePrefix,
@@ -134,6 +301,28 @@ var WireSet = wire.NewSet(
 	GetSwaggerPath,
 	GetOBSServiceDomain,
 	GetJwtSecret,
-	GetTokenExpirationHours,
+	GetJwtAlgorithm,
+	GetJwtSigningKeyFile,
+	GetJwtVerificationKeyFiles,
+	GetAccessTokenTTLMinutes,
+	GetRefreshTokenTTLHours,
+	GetSIWSDomain,
+	GetSIWSURI,
+	GetSIWSChainID,
 	GetRpcEndpoint,
+	GetAcceptedMints,
+	GetEvmRpcURLs,
+	GetEvmTokens,
+	GetWatcherPollIntervalSeconds,
+	GetWatcherMinAmounts,
+	GetPaymentRequestTTLMinutes,
+	GetFinalityCheckDelaySeconds,
+	GetPlatformFeeBps,
+	GetPlatformFeeWallet,
+	GetPriceSource,
+	GetPriceAPIURL,
+	GetPriceCoinIDs,
+	GetStaticPrices,
+	GetPriceCacheTTLSeconds,
+	GetFiatCurrency,
 )
@@ -0,0 +1,38 @@
+package events
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+type Type string
+
+const (
+	TypeDonationReverted Type = "donation_reverted"
+)
+
+type Executor interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+type Event struct {
+	Receiver   string
+	Type       Type
+	DonationID *string
+	Message    string
+}
+
+// Raise records an event in the streamer's feed.
+func Raise(db Executor, event Event) error {
+	const insertQuery = `
+		INSERT INTO streamer_events (receiver, type, donation_id, message)
+		VALUES ($1, $2, $3, $4);
+	`
+
+	_, err := db.Exec(insertQuery, event.Receiver, string(event.Type), event.DonationID, event.Message)
+	if err != nil {
+		return fmt.Errorf("failed to raise %s event: %w", event.Type, err)
+	}
+
+	return nil
+}
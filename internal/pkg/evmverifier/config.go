@@ -0,0 +1,65 @@
+package evmverifier
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"twitch-crypto-donations/internal/pkg/chain"
+	"twitch-crypto-donations/internal/pkg/environment"
+)
+
+// ParseRpcURLs reads CHAIN=URL pairs separated by semicolons, for example
+// "base=https://mainnet.base.org;ethereum=https://eth.llamarpc.com".
+func ParseRpcURLs(urls environment.EvmRpcURLs) (map[chain.ID]string, error) {
+	result := make(map[chain.ID]string)
+	for _, entry := range strings.Split(string(urls), ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		id, url, ok := strings.Cut(entry, "=")
+		if !ok || id == "" || url == "" {
+			return nil, fmt.Errorf("invalid evm rpc url %q, expected CHAIN=URL", entry)
+		}
+
+		result[chain.ID(strings.ToLower(id))] = url
+	}
+
+	return result, nil
+}
+
+// ParseTokens reads CHAIN:SYMBOL:ADDRESS:DECIMALS entries of ERC-20 tokens
+// separated by semicolons. Every chain also accepts its native ETH.
+func ParseTokens(tokens environment.EvmTokens) (map[chain.ID][]chain.Asset, error) {
+	result := make(map[chain.ID][]chain.Asset)
+	for _, entry := range strings.Split(string(tokens), ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.Split(entry, ":")
+		if len(parts) != 4 {
+			return nil, fmt.Errorf("invalid evm token entry %q, expected CHAIN:SYMBOL:ADDRESS:DECIMALS", entry)
+		}
+
+		if !IsAddress(parts[2]) {
+			return nil, fmt.Errorf("invalid evm token address %q", parts[2])
+		}
+
+		decimals, err := strconv.ParseUint(parts[3], 10, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid evm token decimals %q: %w", parts[3], err)
+		}
+
+		id := chain.ID(strings.ToLower(parts[0]))
+		result[id] = append(result[id], chain.Asset{
+			Symbol:   strings.ToUpper(parts[1]),
+			Token:    strings.ToLower(parts[2]),
+			Decimals: uint8(decimals),
+		})
+	}
+
+	return result, nil
+}
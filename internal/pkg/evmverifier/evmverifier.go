@@ -0,0 +1,343 @@
+package evmverifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strings"
+	"twitch-crypto-donations/internal/pkg/chain"
+	"twitch-crypto-donations/internal/pkg/http"
+)
+
+const (
+	NativeSymbol   = "ETH"
+	NativeDecimals = 18
+)
+
+// transferTopic is keccak256("Transfer(address,address,uint256)"), the first
+// topic of every ERC-20 Transfer log.
+const transferTopic = "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+
+var (
+	addressPattern = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+	hashPattern    = regexp.MustCompile(`^0x[0-9a-fA-F]{64}$`)
+)
+
+var ErrNotFound = errors.New("transaction not found")
+
+type HttpClient interface {
+	Post(url string) *http.RequestBuilder
+}
+
+// Verifier checks payments on an EVM chain through its JSON-RPC endpoint.
+// Native transfers must be the transaction's own value; transfers made by
+// contracts through internal calls are not visible in the receipt.
+type Verifier struct {
+	httpClient HttpClient
+	rpcURL     string
+}
+
+func New(httpClient HttpClient, rpcURL string) *Verifier {
+	return &Verifier{httpClient: httpClient, rpcURL: rpcURL}
+}
+
+func IsAddress(address string) bool {
+	return addressPattern.MatchString(address)
+}
+
+type leg struct {
+	sender    string
+	recipient string
+	token     string
+	amount    uint64
+}
+
+func (v *Verifier) Verify(ctx context.Context, reference string, expected []chain.Transfer) (*chain.Result, error) {
+	if len(expected) == 0 {
+		return nil, fmt.Errorf("no transfers to verify")
+	}
+
+	if !hashPattern.MatchString(reference) {
+		return nil, fmt.Errorf("invalid transaction hash %q", reference)
+	}
+
+	reference = strings.ToLower(reference)
+
+	legs, err := parseTransfers(expected)
+	if err != nil {
+		return nil, err
+	}
+
+	var r receipt
+	if err = v.call(ctx, "eth_getTransactionReceipt", []any{reference}, &r); err != nil {
+		return nil, err
+	}
+
+	if r.Status != "0x1" {
+		return nil, &chain.Mismatch{Reason: chain.ReasonTransactionFailed, Message: "transaction was reverted"}
+	}
+
+	height, err := blockNumber(r.BlockNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		tx    *transaction
+		total = new(big.Int)
+	)
+	for _, l := range legs {
+		var transferred *big.Int
+		if l.token == "" {
+			if tx == nil {
+				tx = &transaction{}
+				if err = v.call(ctx, "eth_getTransactionByHash", []any{reference}, tx); err != nil {
+					return nil, err
+				}
+			}
+			transferred, err = verifyNativeTransfer(tx, l)
+		} else {
+			transferred, err = verifyTokenTransfer(r.Logs, l)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		total.Add(total, transferred)
+	}
+
+	// Expected amounts fit in uint64, so only an overpayment beyond it ends up
+	// here; it is rejected rather than recorded as a smaller amount.
+	if !total.IsUint64() {
+		return nil, fmt.Errorf("transferred amount %s exceeds the supported range", total)
+	}
+
+	return &chain.Result{
+		Height: height,
+		Sender: strings.ToLower(r.From),
+		Token:  legs[0].token,
+		Amount: total.Uint64(),
+	}, nil
+}
+
+// Status reports a mined transaction as finalized once its block is at or below
+// the chain's finalized block.
+func (v *Verifier) Status(ctx context.Context, reference string) (chain.Status, error) {
+	reference = strings.ToLower(reference)
+
+	var r receipt
+	err := v.call(ctx, "eth_getTransactionReceipt", []any{reference}, &r)
+	if errors.Is(err, ErrNotFound) {
+		return chain.StatusNotFound, nil
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	if r.Status != "0x1" {
+		return chain.StatusFailed, nil
+	}
+
+	var finalized block
+	if err = v.call(ctx, "eth_getBlockByNumber", []any{"finalized", false}, &finalized); err != nil {
+		return "", fmt.Errorf("failed to fetch finalized block: %w", err)
+	}
+
+	height, err := blockNumber(r.BlockNumber)
+	if err != nil {
+		return "", err
+	}
+
+	finalizedHeight, err := blockNumber(finalized.Number)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch finalized block: %w", err)
+	}
+
+	if height <= finalizedHeight {
+		return chain.StatusFinalized, nil
+	}
+
+	return chain.StatusConfirmed, nil
+}
+
+// Expired reports a transaction whose receipt is gone as unable to land once
+// the finalized block is past the block it was included in: that block is
+// final without it. There is no blockhash on EVM chains.
+func (v *Verifier) Expired(ctx context.Context, height uint64, _ string) (bool, error) {
+	var finalized block
+	if err := v.call(ctx, "eth_getBlockByNumber", []any{"finalized", false}, &finalized); err != nil {
+		return false, fmt.Errorf("failed to fetch finalized block: %w", err)
+	}
+
+	finalizedHeight, err := blockNumber(finalized.Number)
+	if err != nil {
+		return false, fmt.Errorf("failed to fetch finalized block: %w", err)
+	}
+
+	return finalizedHeight > height, nil
+}
+
+func (v *Verifier) Explain(err error) (*chain.Mismatch, bool) {
+	return chain.Explain(err)
+}
+
+func parseTransfers(expected []chain.Transfer) ([]leg, error) {
+	legs := make([]leg, 0, len(expected))
+	index := make(map[leg]int, len(expected))
+
+	for _, t := range expected {
+		if !IsAddress(t.Recipient) {
+			return nil, fmt.Errorf("invalid recipient address %q", t.Recipient)
+		}
+
+		if t.Sender != "" && !IsAddress(t.Sender) {
+			return nil, fmt.Errorf("invalid sender address %q", t.Sender)
+		}
+
+		if t.Token != "" && !IsAddress(t.Token) {
+			return nil, fmt.Errorf("invalid token address %q", t.Token)
+		}
+
+		l := leg{
+			sender:    strings.ToLower(t.Sender),
+			recipient: strings.ToLower(t.Recipient),
+			token:     strings.ToLower(t.Token),
+		}
+
+		if i, ok := index[l]; ok {
+			if legs[i].amount > math.MaxUint64-t.Amount {
+				return nil, fmt.Errorf("expected amount to %s exceeds the supported range", t.Recipient)
+			}
+
+			legs[i].amount += t.Amount
+			continue
+		}
+
+		index[l] = len(legs)
+		l.amount = t.Amount
+		legs = append(legs, l)
+	}
+
+	return legs, nil
+}
+
+func verifyNativeTransfer(tx *transaction, l leg) (*big.Int, error) {
+	if tx.To == nil || strings.ToLower(*tx.To) != l.recipient {
+		return nil, &chain.Mismatch{
+			Reason:  chain.ReasonRecipientNotFound,
+			Message: fmt.Sprintf("transaction does not pay %s", l.recipient),
+		}
+	}
+
+	if l.sender != "" && strings.ToLower(tx.From) != l.sender {
+		return nil, &chain.Mismatch{
+			Reason:  chain.ReasonTransferNotFound,
+			Message: fmt.Sprintf("transaction was not sent from %s", l.sender),
+		}
+	}
+
+	value, err := quantity(tx.Value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid transaction value: %w", err)
+	}
+
+	if value.Cmp(new(big.Int).SetUint64(l.amount)) < 0 {
+		return nil, &chain.Mismatch{
+			Reason:   chain.ReasonInsufficientAmount,
+			Message:  fmt.Sprintf("transferred amount is lower than expected: expected %d wei, got %s", l.amount, value),
+			Expected: l.amount,
+			Actual:   value.Uint64(),
+		}
+	}
+
+	return value, nil
+}
+
+func verifyTokenTransfer(logs []logEntry, l leg) (*big.Int, error) {
+	var (
+		found bool
+		total = new(big.Int)
+	)
+	for _, entry := range logs {
+		if strings.ToLower(entry.Address) != l.token || len(entry.Topics) != 3 || strings.ToLower(entry.Topics[0]) != transferTopic {
+			continue
+		}
+
+		if topicAddress(entry.Topics[2]) != l.recipient {
+			continue
+		}
+
+		if l.sender != "" && topicAddress(entry.Topics[1]) != l.sender {
+			continue
+		}
+
+		value, err := quantity(entry.Data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid transfer log amount: %w", err)
+		}
+
+		found = true
+		total.Add(total, value)
+	}
+
+	if !found {
+		return nil, &chain.Mismatch{
+			Reason:  chain.ReasonTransferNotFound,
+			Message: fmt.Sprintf("no %s transfer to %s found in transaction", l.token, l.recipient),
+		}
+	}
+
+	if total.Cmp(new(big.Int).SetUint64(l.amount)) < 0 {
+		return nil, &chain.Mismatch{
+			Reason:   chain.ReasonInsufficientAmount,
+			Message:  fmt.Sprintf("transferred amount is lower than expected: expected %d base units, got %s", l.amount, total),
+			Expected: l.amount,
+			Actual:   total.Uint64(),
+		}
+	}
+
+	return total, nil
+}
+
+// topicAddress extracts the address left-padded into a 32 byte log topic.
+func topicAddress(topic string) string {
+	topic = strings.ToLower(strings.TrimPrefix(topic, "0x"))
+	if len(topic) != 64 {
+		return ""
+	}
+
+	return "0x" + topic[24:]
+}
+
+// quantity decodes a hex encoded JSON-RPC quantity or uint256.
+func quantity(hex string) (*big.Int, error) {
+	digits, ok := strings.CutPrefix(hex, "0x")
+	if !ok || digits == "" {
+		return nil, fmt.Errorf("invalid quantity %q", hex)
+	}
+
+	value, ok := new(big.Int).SetString(digits, 16)
+	if !ok || value.Sign() < 0 {
+		return nil, fmt.Errorf("invalid quantity %q", hex)
+	}
+
+	return value, nil
+}
+
+func blockNumber(hex string) (uint64, error) {
+	value, err := quantity(hex)
+	if err != nil {
+		return 0, fmt.Errorf("invalid block number: %w", err)
+	}
+
+	if !value.IsUint64() {
+		return 0, fmt.Errorf("invalid block number %q", hex)
+	}
+
+	return value.Uint64(), nil
+}
@@ -0,0 +1,357 @@
+package evmverifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"twitch-crypto-donations/internal/pkg/chain"
+	httppkg "twitch-crypto-donations/internal/pkg/http"
+)
+
+const (
+	sender    = "0x1111111111111111111111111111111111111111"
+	streamer  = "0x2222222222222222222222222222222222222222"
+	treasury  = "0x3333333333333333333333333333333333333333"
+	usdc      = "0x036cbd53842c5426634e7929541ec2318f3dcf7e"
+	oneEther  = 1_000_000_000_000_000_000
+	oneDollar = 1_000_000
+)
+
+func TestVerify(t *testing.T) {
+	node := newFakeNode(t)
+	node.finalized = 120
+
+	native := node.mine(100, "0x1", &transaction{From: sender, To: ptr(streamer), Value: hex(oneEther)})
+	reverted := node.mine(100, "0x0", &transaction{From: sender, To: ptr(streamer), Value: hex(oneEther)})
+	tokens := node.mine(101, "0x1", &transaction{From: sender, To: ptr(usdc), Value: "0x0"},
+		transferLog(usdc, sender, streamer, 19*oneDollar),
+		transferLog(usdc, sender, treasury, oneDollar),
+		// A transfer of another token to the same recipient does not count.
+		transferLog("0x4444444444444444444444444444444444444444", sender, streamer, 100*oneDollar),
+	)
+	large := node.mine(102, "0x1", &transaction{From: sender, To: ptr(streamer), Value: "0x" + strings.Repeat("f", 17)})
+	malformed := node.mine(103, "0x1", &transaction{From: sender, To: ptr(usdc), Value: "0x0"},
+		logEntry{Address: usdc, Topics: []string{transferTopic, topic(sender), topic(streamer)}, Data: "0xzz"},
+	)
+	unknown := "0x" + strings.Repeat("ab", 32)
+
+	verifier := New(httppkg.New(node.server.Client()), node.server.URL)
+
+	tests := []struct {
+		name      string
+		reference string
+		expected  []chain.Transfer
+		amount    uint64
+		reason    string
+		err       error
+		failure   string
+	}{
+		{
+			name:      "native value",
+			reference: native,
+			expected:  []chain.Transfer{{Sender: sender, Recipient: streamer, Amount: oneEther}},
+			amount:    oneEther,
+		},
+		{
+			name:      "native value below the expected amount",
+			reference: native,
+			expected:  []chain.Transfer{{Recipient: streamer, Amount: 2 * oneEther}},
+			reason:    chain.ReasonInsufficientAmount,
+		},
+		{
+			name:      "native value to another recipient",
+			reference: native,
+			expected:  []chain.Transfer{{Recipient: treasury, Amount: oneEther}},
+			reason:    chain.ReasonRecipientNotFound,
+		},
+		{
+			name:      "reverted transaction",
+			reference: reverted,
+			expected:  []chain.Transfer{{Recipient: streamer, Amount: oneEther}},
+			reason:    chain.ReasonTransactionFailed,
+		},
+		{
+			name:      "token transfer logs",
+			reference: tokens,
+			expected: []chain.Transfer{
+				{Sender: sender, Recipient: streamer, Token: usdc, Amount: 19 * oneDollar},
+				{Sender: sender, Recipient: treasury, Token: usdc, Amount: oneDollar},
+			},
+			amount: 20 * oneDollar,
+		},
+		{
+			name:      "token transfer below the expected amount",
+			reference: tokens,
+			expected:  []chain.Transfer{{Recipient: streamer, Token: usdc, Amount: 20 * oneDollar}},
+			reason:    chain.ReasonInsufficientAmount,
+		},
+		{
+			name:      "token transfer from another sender",
+			reference: tokens,
+			expected:  []chain.Transfer{{Sender: treasury, Recipient: streamer, Token: usdc, Amount: oneDollar}},
+			reason:    chain.ReasonTransferNotFound,
+		},
+		{
+			name:      "native value beyond the supported range",
+			reference: large,
+			expected:  []chain.Transfer{{Recipient: streamer, Amount: oneEther}},
+			failure:   "exceeds the supported range",
+		},
+		{
+			name:      "malformed token transfer amount",
+			reference: malformed,
+			expected:  []chain.Transfer{{Recipient: streamer, Token: usdc, Amount: oneDollar}},
+			failure:   "invalid quantity",
+		},
+		{
+			name:      "unknown transaction",
+			reference: unknown,
+			expected:  []chain.Transfer{{Recipient: streamer, Amount: oneEther}},
+			err:       ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := verifier.Verify(context.Background(), tt.reference, tt.expected)
+
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("Verify() error = %v, want %v", err, tt.err)
+				}
+				return
+			}
+
+			if tt.failure != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.failure) {
+					t.Fatalf("Verify() error = %v, want %q", err, tt.failure)
+				}
+				return
+			}
+
+			if tt.reason != "" {
+				mismatch, ok := verifier.Explain(err)
+				if !ok {
+					t.Fatalf("Verify() error = %v, want a %s mismatch", err, tt.reason)
+				}
+
+				if mismatch.Reason != tt.reason {
+					t.Fatalf("Verify() mismatch reason = %s, want %s", mismatch.Reason, tt.reason)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+
+			if result.Amount != tt.amount {
+				t.Fatalf("Verify() amount = %d, want %d", result.Amount, tt.amount)
+			}
+
+			if result.Sender != sender {
+				t.Fatalf("Verify() sender = %s, want %s", result.Sender, sender)
+			}
+		})
+	}
+}
+
+func TestStatus(t *testing.T) {
+	node := newFakeNode(t)
+	node.finalized = 120
+
+	final := node.mine(120, "0x1", &transaction{From: sender, To: ptr(streamer), Value: hex(oneEther)})
+	recent := node.mine(121, "0x1", &transaction{From: sender, To: ptr(streamer), Value: hex(oneEther)})
+	reverted := node.mine(100, "0x0", &transaction{From: sender, To: ptr(streamer), Value: hex(oneEther)})
+	unknown := "0x" + strings.Repeat("cd", 32)
+
+	verifier := New(httppkg.New(node.server.Client()), node.server.URL)
+
+	tests := []struct {
+		name      string
+		reference string
+		status    chain.Status
+	}{
+		{name: "at the finalized block", reference: final, status: chain.StatusFinalized},
+		{name: "above the finalized block", reference: recent, status: chain.StatusConfirmed},
+		{name: "reverted", reference: reverted, status: chain.StatusFailed},
+		{name: "unknown", reference: unknown, status: chain.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, err := verifier.Status(context.Background(), tt.reference)
+			if err != nil {
+				t.Fatalf("Status() error = %v", err)
+			}
+
+			if status != tt.status {
+				t.Fatalf("Status() = %s, want %s", status, tt.status)
+			}
+		})
+	}
+}
+
+func TestExpired(t *testing.T) {
+	node := newFakeNode(t)
+	node.finalized = 120
+
+	verifier := New(httppkg.New(node.server.Client()), node.server.URL)
+
+	tests := []struct {
+		name    string
+		height  uint64
+		expired bool
+	}{
+		{name: "below the finalized block", height: 119, expired: true},
+		{name: "at the finalized block", height: 120, expired: false},
+		{name: "above the finalized block", height: 121, expired: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expired, err := verifier.Expired(context.Background(), tt.height, "")
+			if err != nil {
+				t.Fatalf("Expired() error = %v", err)
+			}
+
+			if expired != tt.expired {
+				t.Fatalf("Expired() = %t, want %t", expired, tt.expired)
+			}
+		})
+	}
+}
+
+func TestQuantity(t *testing.T) {
+	tests := []struct {
+		hex   string
+		value string
+		valid bool
+	}{
+		{hex: "0x0", value: "0", valid: true},
+		{hex: "0x10", value: "16", valid: true},
+		{hex: "0x" + strings.Repeat("f", 20), value: "1208925819614629174706175", valid: true},
+		{hex: "0x"},
+		{hex: "10"},
+		{hex: "0xzz"},
+		{hex: "0x-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.hex, func(t *testing.T) {
+			value, err := quantity(tt.hex)
+			if !tt.valid {
+				if err == nil {
+					t.Fatalf("quantity(%q) = %s, want an error", tt.hex, value)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("quantity(%q) error = %v", tt.hex, err)
+			}
+
+			if value.String() != tt.value {
+				t.Fatalf("quantity(%q) = %s, want %s", tt.hex, value, tt.value)
+			}
+		})
+	}
+}
+
+// fakeNode is an EVM JSON-RPC stand-in serving the receipts, transactions and
+// finalized block of the transactions mined on it.
+type fakeNode struct {
+	server       *httptest.Server
+	receipts     map[string]receipt
+	transactions map[string]transaction
+	finalized    uint64
+}
+
+func newFakeNode(t *testing.T) *fakeNode {
+	node := &fakeNode{
+		receipts:     make(map[string]receipt),
+		transactions: make(map[string]transaction),
+	}
+
+	node.server = httptest.NewServer(node)
+	t.Cleanup(node.server.Close)
+
+	return node
+}
+
+func (n *fakeNode) mine(height uint64, status string, tx *transaction, logs ...logEntry) string {
+	hash := fmt.Sprintf("0x%064x", len(n.receipts)+1)
+
+	n.transactions[hash] = *tx
+	n.receipts[hash] = receipt{
+		Status:      status,
+		BlockNumber: hex(height),
+		From:        tx.From,
+		To:          tx.To,
+		Logs:        logs,
+	}
+
+	return hash
+}
+
+func (n *fakeNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		ID     int               `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var hash string
+	if len(request.Params) > 0 {
+		_ = json.Unmarshal(request.Params[0], &hash)
+	}
+
+	var result any
+	switch request.Method {
+	case "eth_getTransactionReceipt":
+		if r, ok := n.receipts[hash]; ok {
+			result = r
+		}
+	case "eth_getTransactionByHash":
+		if tx, ok := n.transactions[hash]; ok {
+			result = tx
+		}
+	case "eth_getBlockByNumber":
+		result = block{Number: hex(n.finalized)}
+	default:
+		http.Error(w, "unexpected method "+request.Method, http.StatusBadRequest)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": request.ID, "result": result})
+}
+
+func transferLog(token, from, to string, amount uint64) logEntry {
+	return logEntry{
+		Address: token,
+		Topics:  []string{transferTopic, topic(from), topic(to)},
+		Data:    fmt.Sprintf("0x%064x", amount),
+	}
+}
+
+func topic(address string) string {
+	return "0x" + strings.Repeat("0", 24) + strings.TrimPrefix(address, "0x")
+}
+
+func hex(value uint64) string {
+	return fmt.Sprintf("0x%x", value)
+}
+
+func ptr(value string) *string {
+	return &value
+}
@@ -0,0 +1,79 @@
+package evmverifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+type rpcRequest struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      int    `json:"id"`
+	Method  string `json:"method"`
+	Params  []any  `json:"params"`
+}
+
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type receipt struct {
+	Status      string     `json:"status"`
+	BlockNumber string     `json:"blockNumber"`
+	From        string     `json:"from"`
+	To          *string    `json:"to"`
+	Logs        []logEntry `json:"logs"`
+}
+
+type logEntry struct {
+	Address string   `json:"address"`
+	Topics  []string `json:"topics"`
+	Data    string   `json:"data"`
+}
+
+type transaction struct {
+	From  string  `json:"from"`
+	To    *string `json:"to"`
+	Value string  `json:"value"`
+}
+
+type block struct {
+	Number string `json:"number"`
+}
+
+// call performs a JSON-RPC request. A null result, which nodes return for
+// unknown transactions, is reported as ErrNotFound.
+func (v *Verifier) call(ctx context.Context, method string, params []any, result any) error {
+	var response rpcResponse
+	err := v.httpClient.
+		Post(v.rpcURL).
+		WithContext(ctx).
+		WithJSON(rpcRequest{JSONRPC: "2.0", ID: 1, Method: method, Params: params}).
+		WithDefaultRetry().
+		DecodeResponseJSON().
+		Parse(&response)
+	if err != nil {
+		return fmt.Errorf("%s failed: %w", method, err)
+	}
+
+	if response.Error != nil {
+		return fmt.Errorf("%s failed: %d %s", method, response.Error.Code, response.Error.Message)
+	}
+
+	if len(response.Result) == 0 || bytes.Equal(response.Result, []byte("null")) {
+		return ErrNotFound
+	}
+
+	if err = json.Unmarshal(response.Result, result); err != nil {
+		return fmt.Errorf("failed to decode %s result: %w", method, err)
+	}
+
+	return nil
+}
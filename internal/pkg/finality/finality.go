@@ -0,0 +1,304 @@
+package finality
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"twitch-crypto-donations/internal/pkg/chain"
+	"twitch-crypto-donations/internal/pkg/donations"
+	"twitch-crypto-donations/internal/pkg/environment"
+	"twitch-crypto-donations/internal/pkg/events"
+	"twitch-crypto-donations/internal/pkg/subathon"
+
+	"github.com/lib/pq"
+)
+
+type Database interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+	Exec(query string, args ...any) (sql.Result, error)
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+type ChainRegistry interface {
+	Verifier(id chain.ID) (chain.PaymentVerifier, error)
+}
+
+type SubathonTimer interface {
+	Revert(db subathon.Executor, donationID string) (*subathon.Extension, error)
+	Notify(owner string, timer *subathon.Timer, added int64, username *string) error
+}
+
+type Logger interface {
+	Info(msg string, ctx ...interface{})
+}
+
+// revertAfterMisses is how many polls in a row must miss a transaction before
+// the reconciler asks whether it can still land.
+const revertAfterMisses = 3
+
+// Reconciler re-checks confirmed donations at finalized commitment once they
+// are old enough, and reverts the ones whose transaction failed or vanished.
+type Reconciler struct {
+	db        Database
+	chains    ChainRegistry
+	timers    SubathonTimer
+	logger    Logger
+	interval  time.Duration
+	delay     time.Duration
+	batchSize int
+}
+
+type pending struct {
+	id        string
+	chain     chain.ID
+	receiver  string
+	signature string
+	height    uint64
+	blockhash string
+	misses    int
+}
+
+func New(
+	db Database,
+	chains ChainRegistry,
+	timers SubathonTimer,
+	logger Logger,
+	interval environment.WatcherPollIntervalSeconds,
+	delay environment.FinalityCheckDelaySeconds,
+) *Reconciler {
+	return &Reconciler{
+		db:        db,
+		chains:    chains,
+		timers:    timers,
+		logger:    logger,
+		interval:  time.Duration(interval) * time.Second,
+		delay:     time.Duration(delay) * time.Second,
+		batchSize: 100,
+	}
+}
+
+func (r *Reconciler) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.Poll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Reconciler) Poll(ctx context.Context) {
+	unfinalized, err := r.unfinalized()
+	if err != nil {
+		r.logger.Info("finality reconciler failed to load donations", "error", err.Error())
+		return
+	}
+
+	for _, donation := range unfinalized {
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err = r.reconcile(ctx, donation); err != nil {
+			r.logger.Info("finality reconciler failed to reconcile donation",
+				"donation", donation.id,
+				"signature", donation.signature,
+				"error", err.Error(),
+			)
+		}
+	}
+}
+
+func (r *Reconciler) reconcile(ctx context.Context, donation pending) error {
+	verifier, err := r.chains.Verifier(donation.chain)
+	if err != nil {
+		return err
+	}
+
+	status, err := verifier.Status(ctx, chain.Reference(donation.chain, donation.signature))
+	if err != nil {
+		return err
+	}
+
+	switch status {
+	case chain.StatusFinalized:
+		return r.finalize(ctx, donation)
+	case chain.StatusFailed:
+		return r.revert(ctx, donation, "transaction failed on-chain")
+	case chain.StatusNotFound:
+		return r.miss(ctx, verifier, donation)
+	default:
+		return r.found(donation)
+	}
+}
+
+// miss counts a poll that did not find the donation's transaction. A lagging
+// or load-balanced node answers the same as a dropped transaction, so the
+// donation is only reverted once the miss repeated over several polls and the
+// transaction can no longer land.
+func (r *Reconciler) miss(ctx context.Context, verifier chain.PaymentVerifier, donation pending) error {
+	const updateQuery = `UPDATE donations SET finality_misses = finality_misses + 1 WHERE id = $1;`
+
+	if _, err := r.db.Exec(updateQuery, donation.id); err != nil {
+		return fmt.Errorf("failed to count finality miss: %w", err)
+	}
+
+	if donation.misses+1 < revertAfterMisses {
+		return nil
+	}
+
+	expired, err := verifier.Expired(ctx, donation.height, donation.blockhash)
+	if err != nil {
+		return err
+	}
+
+	if !expired {
+		return nil
+	}
+
+	return r.revert(ctx, donation, "transaction was dropped or rolled back before finalization and can no longer land")
+}
+
+// found resets the misses of a donation whose transaction was seen again, so
+// only misses in a row count towards reverting it.
+func (r *Reconciler) found(donation pending) error {
+	if donation.misses == 0 {
+		return nil
+	}
+
+	const updateQuery = `UPDATE donations SET finality_misses = 0 WHERE id = $1;`
+
+	if _, err := r.db.Exec(updateQuery, donation.id); err != nil {
+		return fmt.Errorf("failed to reset finality misses: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Reconciler) finalize(ctx context.Context, donation pending) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	const updateQuery = `UPDATE donations SET finalized_at = NOW() WHERE id = $1;`
+
+	if _, err = tx.Exec(updateQuery, donation.id); err != nil {
+		return fmt.Errorf("failed to finalize donation: %w", err)
+	}
+
+	// Only a confirmed donation moves to finalized; once its alert outcome is
+	// recorded the state keeps it, and finality is read from finalized_at.
+	err = donations.Transition(tx, donation.id, donations.StateFinalized, "transaction reached finalized commitment")
+	if err != nil && !errors.Is(err, donations.ErrInvalidTransition) {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Reconciler) revert(ctx context.Context, donation pending, reason string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err = donations.Transition(tx, donation.id, donations.StateReverted, reason); err != nil {
+		return err
+	}
+
+	extension, err := r.timers.Revert(tx, donation.id)
+	if err != nil {
+		return err
+	}
+
+	err = events.Raise(tx, events.Event{
+		Receiver:   donation.receiver,
+		Type:       events.TypeDonationReverted,
+		DonationID: &donation.id,
+		Message:    fmt.Sprintf("Donation %s was reverted: %s", donation.id, reason),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	r.logger.Info("finality reconciler reverted donation", "donation", donation.id, "signature", donation.signature, "reason", reason)
+
+	if extension != nil {
+		err = r.timers.Notify(extension.Timer.Owner, &extension.Timer, extension.Seconds, nil)
+		if err != nil {
+			r.logger.Info("finality reconciler failed to send timer update", "donation", donation.id, "error", err.Error())
+		}
+	}
+
+	return nil
+}
+
+func (r *Reconciler) unfinalized() ([]pending, error) {
+	const query = `
+		SELECT d.id, d.chain, d.receiver, d.tx_signature, d.tx_height, d.tx_blockhash, d.finality_misses
+		FROM donations d
+		WHERE d.finalized_at IS NULL
+		  AND d.tx_signature IS NOT NULL
+		  AND d.state = ANY($1)
+		  AND EXISTS (
+		      SELECT 1 FROM donation_transitions t
+		      WHERE t.donation_id = d.id
+		        AND t.to_state = $2
+		        AND t.created_at < NOW() - make_interval(secs => $3)
+		  )
+		ORDER BY d.created_at
+		LIMIT $4;
+	`
+
+	states := []string{
+		string(donations.StateConfirmed),
+		string(donations.StateAlertDelivered),
+		string(donations.StateAlertFailed),
+	}
+
+	rows, err := r.db.Query(query, pq.Array(states), string(donations.StateConfirmed), r.delay.Seconds(), r.batchSize)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]pending, 0, r.batchSize)
+	for rows.Next() {
+		var (
+			p         pending
+			height    sql.NullInt64
+			blockhash sql.NullString
+		)
+		if err = rows.Scan(&p.id, &p.chain, &p.receiver, &p.signature, &height, &blockhash, &p.misses); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		p.height = uint64(height.Int64)
+		p.blockhash = blockhash.String
+		result = append(result, p)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return result, nil
+}
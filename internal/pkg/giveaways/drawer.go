@@ -0,0 +1,317 @@
+package giveaways
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/big"
+	"time"
+	"twitch-crypto-donations/internal/pkg/environment"
+
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// SeedDelaySlots is how far past the slot seen at commitment time the seed
+// block lies, so its blockhash is unknown when the entries are committed.
+const SeedDelaySlots = 150
+
+type DrawerDatabase interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+type RpcClient interface {
+	GetSlot(ctx context.Context, commitment rpc.CommitmentType) (uint64, error)
+	GetBlocksWithLimit(ctx context.Context, startSlot uint64, limit uint64, commitment rpc.CommitmentType) (*rpc.BlocksResult, error)
+	GetBlockWithOpts(ctx context.Context, slot uint64, opts *rpc.GetBlockOpts) (*rpc.GetBlockResult, error)
+}
+
+type Logger interface {
+	Info(msg string, ctx ...interface{})
+}
+
+// Drawer commits to the entries of giveaways whose window has ended, and draws
+// the winners once the seed slot is finalized. Both steps are keyed on the
+// stored status, so a restart resumes where it stopped.
+type Drawer struct {
+	db        DrawerDatabase
+	rpcClient RpcClient
+	logger    Logger
+	interval  time.Duration
+	batchSize int
+}
+
+type pendingGiveaway struct {
+	id          string
+	owner       string
+	mode        string
+	currency    *string
+	windowStart time.Time
+	windowEnd   time.Time
+	winnerCount int
+	commitment  *string
+	targetSlot  *int64
+}
+
+func NewDrawer(db DrawerDatabase, rpcClient RpcClient, logger Logger, interval environment.WatcherPollIntervalSeconds) *Drawer {
+	return &Drawer{
+		db:        db,
+		rpcClient: rpcClient,
+		logger:    logger,
+		interval:  time.Duration(interval) * time.Second,
+		batchSize: 50,
+	}
+}
+
+func (d *Drawer) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		d.Poll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *Drawer) Poll(ctx context.Context) {
+	pending, err := d.pending()
+	if err != nil {
+		d.logger.Info("giveaway drawer failed to load giveaways", "error", err.Error())
+		return
+	}
+
+	for _, giveaway := range pending {
+		if ctx.Err() != nil {
+			return
+		}
+
+		if giveaway.commitment == nil {
+			err = d.commit(ctx, giveaway)
+		} else {
+			err = d.draw(ctx, giveaway)
+		}
+
+		if err != nil {
+			d.logger.Info("giveaway drawer failed to process giveaway", "giveaway", giveaway.id, "error", err.Error())
+		}
+	}
+}
+
+// commit snapshots the donors of the window and stores the entries with their
+// commitment and the slot whose blockhash will seed the draw.
+func (d *Drawer) commit(ctx context.Context, giveaway pendingGiveaway) error {
+	entries, err := d.snapshot(giveaway)
+	if err != nil {
+		return err
+	}
+
+	current, err := d.rpcClient.GetSlot(ctx, rpc.CommitmentConfirmed)
+	if err != nil {
+		return fmt.Errorf("failed to get current slot: %w", err)
+	}
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	const updateQuery = `
+		UPDATE giveaways
+		SET status = $2, commitment = $3, target_slot = $4, committed_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status = $5;
+	`
+
+	result, err := tx.Exec(updateQuery, giveaway.id, StatusCommitted, Commitment(entries), current+SeedDelaySlots, StatusOpen)
+	if err != nil {
+		return fmt.Errorf("failed to commit giveaway: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to commit giveaway: %w", err)
+	}
+
+	if affected == 0 {
+		return nil
+	}
+
+	const insertQuery = `INSERT INTO giveaway_entries (giveaway_id, position, address, weight) VALUES ($1, $2, $3, $4);`
+
+	for position, entry := range entries {
+		if _, err = tx.Exec(insertQuery, giveaway.id, position, entry.Address, entry.Weight.String()); err != nil {
+			return fmt.Errorf("failed to save giveaway entry: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// snapshot sums each wallet's donations to the owner within the window. A
+// donation sent to several overlay channels has one history row per channel,
+// so rows are reduced to one per donation first, and reverted ones are left out.
+func (d *Drawer) snapshot(giveaway pendingGiveaway) ([]Entry, error) {
+	const entriesQuery = `
+		WITH donated AS (
+			SELECT DISTINCT ON (COALESCE(h.donation_id, h.tx_signature, h.id::TEXT))
+			       h.sender_address, h.amount
+			FROM donations_history h
+			LEFT JOIN donations d ON d.id = h.donation_id
+			WHERE h.receiver = $1
+			  AND h.created_at >= $2 AND h.created_at < $3
+			  AND h.sender_address IS NOT NULL
+			  AND ($4::TEXT IS NULL OR h.currency = $4)
+			  AND (d.id IS NULL OR d.state <> 'reverted')
+			ORDER BY COALESCE(h.donation_id, h.tx_signature, h.id::TEXT), h.id
+		)
+		SELECT sender_address, SUM(amount)
+		FROM donated
+		GROUP BY sender_address
+		HAVING SUM(amount) > 0;
+	`
+
+	rows, err := d.db.Query(entriesQuery, giveaway.owner, giveaway.windowStart, giveaway.windowEnd, giveaway.currency)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]Entry, 0, 16)
+	for rows.Next() {
+		var address, total string
+		if err = rows.Scan(&address, &total); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		weight := big.NewInt(1)
+		if giveaway.mode == ModeWeighted {
+			var ok bool
+			if weight, ok = new(big.Int).SetString(total, 10); !ok {
+				return nil, fmt.Errorf("invalid donated amount %q for %s", total, address)
+			}
+		}
+
+		entries = append(entries, Entry{Address: address, Weight: weight})
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	Sort(entries)
+
+	return entries, nil
+}
+
+// draw seeds the committed entries with the blockhash of the first finalized
+// block at or after the target slot, which skips slots without a block.
+func (d *Drawer) draw(ctx context.Context, giveaway pendingGiveaway) error {
+	blocks, err := d.rpcClient.GetBlocksWithLimit(ctx, uint64(*giveaway.targetSlot), 1, rpc.CommitmentFinalized)
+	if err != nil {
+		return fmt.Errorf("failed to get seed block: %w", err)
+	}
+
+	if blocks == nil || len(*blocks) == 0 {
+		return nil
+	}
+
+	seedSlot := (*blocks)[0]
+	rewards := false
+	block, err := d.rpcClient.GetBlockWithOpts(ctx, seedSlot, &rpc.GetBlockOpts{
+		TransactionDetails: rpc.TransactionDetailsNone,
+		Rewards:            &rewards,
+		Commitment:         rpc.CommitmentFinalized,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get seed block %d: %w", seedSlot, err)
+	}
+
+	entries, err := Entries(d.db, giveaway.id)
+	if err != nil {
+		return err
+	}
+
+	seed := block.Blockhash.String()
+	winners := Draw(entries, *giveaway.commitment, seed, giveaway.winnerCount)
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	const updateQuery = `
+		UPDATE giveaways
+		SET status = $2, seed_slot = $3, seed = $4, drawn_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status = $5;
+	`
+
+	result, err := tx.Exec(updateQuery, giveaway.id, StatusDrawn, seedSlot, seed, StatusCommitted)
+	if err != nil {
+		return fmt.Errorf("failed to draw giveaway: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to draw giveaway: %w", err)
+	}
+
+	if affected == 0 {
+		return nil
+	}
+
+	const insertQuery = `INSERT INTO giveaway_winners (giveaway_id, rank, address) VALUES ($1, $2, $3);`
+
+	for rank, winner := range winners {
+		if _, err = tx.Exec(insertQuery, giveaway.id, rank+1, winner.Address); err != nil {
+			return fmt.Errorf("failed to save giveaway winner: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (d *Drawer) pending() ([]pendingGiveaway, error) {
+	const pendingQuery = `
+		SELECT id, owner, mode, currency, window_start, window_end, winner_count, commitment, target_slot
+		FROM giveaways
+		WHERE (status = $1 AND window_end <= NOW()) OR status = $2
+		ORDER BY window_end
+		LIMIT $3;
+	`
+
+	rows, err := d.db.Query(pendingQuery, StatusOpen, StatusCommitted, d.batchSize)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	pending := make([]pendingGiveaway, 0, 4)
+	for rows.Next() {
+		var g pendingGiveaway
+		err = rows.Scan(&g.id, &g.owner, &g.mode, &g.currency, &g.windowStart, &g.windowEnd, &g.winnerCount, &g.commitment, &g.targetSlot)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		pending = append(pending, g)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return pending, nil
+}
@@ -0,0 +1,248 @@
+package giveaways
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ModeWeighted = "weighted"
+	ModeEqual    = "equal"
+
+	StatusOpen      = "open"
+	StatusCommitted = "committed"
+	StatusDrawn     = "drawn"
+)
+
+// Algorithm names the commitment and draw procedure below, so a published draw
+// can be re-verified with the same steps.
+const Algorithm = "sha256-cumulative-weight-v1"
+
+var ErrGiveawayNotFound = errors.New("giveaway not found")
+
+type Database interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// Giveaway raffles prizes among the wallets that donated to the owner within
+// the window. Entries are snapshotted and committed to once the window ends,
+// and winners are drawn with the blockhash of a later Solana slot as the seed.
+type Giveaway struct {
+	ID          string
+	Owner       string
+	Title       string
+	Mode        string
+	Currency    *string
+	WindowStart time.Time
+	WindowEnd   time.Time
+	WinnerCount int
+	Status      string
+	Commitment  *string
+	TargetSlot  *uint64
+	SeedSlot    *uint64
+	Seed        *string
+	CommittedAt *time.Time
+	DrawnAt     *time.Time
+	CreatedAt   time.Time
+	EntryCount  int
+	Winners     []string
+}
+
+// Entry is a donor wallet with its chance of winning. Weighted giveaways count
+// the donated base units of the currency, equal ones a single entry per wallet.
+type Entry struct {
+	Address string
+	Weight  *big.Int
+}
+
+// Commitment is the hex SHA-256 of the entries sorted by address, one
+// "address:weight" line each, weights in decimal.
+func Commitment(entries []Entry) string {
+	digest := sha256.Sum256([]byte(serialize(entries)))
+	return hex.EncodeToString(digest[:])
+}
+
+func serialize(entries []Entry) string {
+	var b strings.Builder
+	for _, entry := range entries {
+		b.WriteString(entry.Address)
+		b.WriteByte(':')
+		b.WriteString(entry.Weight.String())
+		b.WriteByte('\n')
+	}
+
+	return b.String()
+}
+
+// Sort orders entries by address, the order they are committed and drawn in.
+func Sort(entries []Entry) {
+	slices.SortFunc(entries, func(a, b Entry) int { return strings.Compare(a.Address, b.Address) })
+}
+
+// Draw picks up to count winners without replacement. For the i-th pick, the
+// SHA-256 of "seed:commitment:i" read as a big-endian integer, modulo the total
+// weight of the remaining entries, selects the entry whose cumulative weight
+// range contains it, walking the entries in committed order.
+func Draw(entries []Entry, commitment, seed string, count int) []Entry {
+	remaining := slices.Clone(entries)
+	total := new(big.Int)
+	for _, entry := range remaining {
+		total.Add(total, entry.Weight)
+	}
+
+	winners := make([]Entry, 0, min(count, len(remaining)))
+	for i := 0; i < count && len(remaining) > 0; i++ {
+		digest := sha256.Sum256([]byte(seed + ":" + commitment + ":" + strconv.Itoa(i)))
+		target := new(big.Int).Mod(new(big.Int).SetBytes(digest[:]), total)
+
+		cumulative := new(big.Int)
+		for j, entry := range remaining {
+			cumulative.Add(cumulative, entry.Weight)
+			if target.Cmp(cumulative) < 0 {
+				winners = append(winners, entry)
+				total.Sub(total, entry.Weight)
+				remaining = slices.Delete(remaining, j, j+1)
+				break
+			}
+		}
+	}
+
+	return winners
+}
+
+const selectGiveaways = `
+	SELECT g.id, g.owner, g.title, g.mode, g.currency, g.window_start, g.window_end, g.winner_count,
+	       g.status, g.commitment, g.target_slot, g.seed_slot, g.seed, g.committed_at, g.drawn_at, g.created_at,
+	       (SELECT COUNT(*) FROM giveaway_entries e WHERE e.giveaway_id = g.id)
+	FROM giveaways g
+`
+
+// Find returns the giveaway with its winners, or ErrGiveawayNotFound.
+func Find(db Database, giveawayID string) (*Giveaway, error) {
+	giveaways, err := list(db, selectGiveaways+` WHERE g.id = $1;`, giveawayID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(giveaways) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrGiveawayNotFound, giveawayID)
+	}
+
+	return &giveaways[0], nil
+}
+
+// List returns the owner's giveaways with their winners, newest first.
+func List(db Database, owner string) ([]Giveaway, error) {
+	return list(db, selectGiveaways+` WHERE g.owner = $1 ORDER BY g.created_at DESC;`, owner)
+}
+
+// Entries returns the committed entries of the giveaway in committed order.
+func Entries(db Database, giveawayID string) ([]Entry, error) {
+	rows, err := db.Query(`SELECT address, weight FROM giveaway_entries WHERE giveaway_id = $1 ORDER BY position;`, giveawayID)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]Entry, 0, 16)
+	for rows.Next() {
+		var address, weight string
+		if err = rows.Scan(&address, &weight); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		value, ok := new(big.Int).SetString(weight, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid weight %q for %s", weight, address)
+		}
+		entries = append(entries, Entry{Address: address, Weight: value})
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return entries, nil
+}
+
+func list(db Database, query string, args ...any) ([]Giveaway, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	giveaways := make([]Giveaway, 0, 2)
+	for rows.Next() {
+		var (
+			g                    Giveaway
+			targetSlot, seedSlot *int64
+		)
+
+		err = rows.Scan(
+			&g.ID, &g.Owner, &g.Title, &g.Mode, &g.Currency, &g.WindowStart, &g.WindowEnd, &g.WinnerCount,
+			&g.Status, &g.Commitment, &targetSlot, &seedSlot, &g.Seed, &g.CommittedAt, &g.DrawnAt, &g.CreatedAt,
+			&g.EntryCount,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		g.TargetSlot, g.SeedSlot = slot(targetSlot), slot(seedSlot)
+		giveaways = append(giveaways, g)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	rows.Close()
+
+	for i := range giveaways {
+		if giveaways[i].Winners, err = winners(db, giveaways[i].ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return giveaways, nil
+}
+
+func winners(db Database, giveawayID string) ([]string, error) {
+	rows, err := db.Query(`SELECT address FROM giveaway_winners WHERE giveaway_id = $1 ORDER BY rank;`, giveawayID)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	winners := make([]string, 0, 4)
+	for rows.Next() {
+		var address string
+		if err = rows.Scan(&address); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		winners = append(winners, address)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return winners, nil
+}
+
+func slot(value *int64) *uint64 {
+	if value == nil {
+		return nil
+	}
+
+	converted := uint64(*value)
+	return &converted
+}
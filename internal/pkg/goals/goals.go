@@ -0,0 +1,326 @@
+package goals
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+	"twitch-crypto-donations/internal/pkg/amount"
+	"twitch-crypto-donations/internal/pkg/environment"
+	"twitch-crypto-donations/internal/pkg/obsservice"
+	"twitch-crypto-donations/internal/pkg/pricing"
+)
+
+// FiatDecimals is the precision of fiat goal targets.
+const FiatDecimals = 2
+
+var ErrGoalNotFound = errors.New("donation goal not found")
+
+type Database interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+type Executor interface {
+	QueryRow(query string, args ...any) *sql.Row
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+type ObsService interface {
+	WebhookGoalProgress(wallet string, request obsservice.GoalEvent) (any, string, error)
+	WebhookGoalReached(wallet string, request obsservice.GoalEvent) (any, string, error)
+}
+
+// Goal is a streamer's funding target, counted either in a currency or in a
+// fiat currency from donation valuations. Target and Raised are decimal
+// amounts of that unit.
+type Goal struct {
+	ID           string
+	Owner        string
+	Title        string
+	Currency     *string
+	FiatCurrency *string
+	Target       string
+	Raised       string
+	Deadline     *time.Time
+	Active       bool
+	ReachedAt    *time.Time
+	CreatedAt    time.Time
+}
+
+// Unit is the currency symbol or fiat code the goal is counted in.
+func (g Goal) Unit() string {
+	if g.Currency != nil {
+		return *g.Currency
+	}
+
+	return *g.FiatCurrency
+}
+
+// Percent is the raised share of the target, which passes 100 once the goal is
+// overfunded.
+func (g Goal) Percent() float64 {
+	target, ok := new(big.Rat).SetString(g.Target)
+	if !ok || target.Sign() <= 0 {
+		return 0
+	}
+
+	raised, ok := new(big.Rat).SetString(g.Raised)
+	if !ok {
+		return 0
+	}
+
+	percent, _ := raised.Mul(raised, big.NewRat(100, 1)).Quo(raised, target).Float64()
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(percent, 'f', 2, 64), 64)
+
+	return rounded
+}
+
+// Contribution is a confirmed donation offered to the receiver's active goal.
+type Contribution struct {
+	DonationID string
+	Receiver   string
+	Currency   string
+	Units      uint64
+	Decimals   uint8
+	Valuation  *pricing.Valuation
+}
+
+// Progress is the state of a goal after a donation counted towards it. Reached
+// is set only for the donation that completed the goal.
+type Progress struct {
+	Goal        Goal
+	Contributed string
+	Reached     bool
+}
+
+type Tracker struct {
+	obsService ObsService
+	fiat       string
+}
+
+func New(obsService ObsService, fiat environment.FiatCurrency) *Tracker {
+	return &Tracker{obsService: obsService, fiat: pricing.Fiat(fiat)}
+}
+
+// FiatCurrencies are the fiat currencies donations are valued in, and so the
+// ones fiat goals can be counted in.
+func (t *Tracker) FiatCurrencies() []string {
+	return pricing.FiatCurrencies(t.fiat)
+}
+
+// Contribute counts the donation towards the receiver's active goal. It returns
+// nil when there is no active goal before its deadline, or when the donation is
+// in another currency or has no valuation in the goal's fiat currency.
+func (t *Tracker) Contribute(db Executor, c Contribution) (*Progress, error) {
+	const goalQuery = `
+		SELECT id, currency, fiat_currency
+		FROM donation_goals
+		WHERE owner = $1 AND active AND (deadline IS NULL OR deadline > NOW())
+		FOR UPDATE;
+	`
+
+	var (
+		goalID                 string
+		currency, fiatCurrency *string
+	)
+
+	err := db.QueryRow(goalQuery, c.Receiver).Scan(&goalID, &currency, &fiatCurrency)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to load active goal: %w", err)
+	}
+
+	var contributed string
+	switch {
+	case currency != nil && *currency == c.Currency:
+		contributed = amount.Format(c.Units, c.Decimals)
+	case fiatCurrency != nil && c.Valuation != nil && *fiatCurrency == pricing.USD:
+		contributed = c.Valuation.USD
+	case fiatCurrency != nil && c.Valuation != nil && *fiatCurrency == c.Valuation.FiatCurrency:
+		contributed = c.Valuation.Fiat
+	default:
+		return nil, nil
+	}
+
+	const insertQuery = `
+		INSERT INTO donation_goal_contributions (goal_id, donation_id, amount)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (goal_id, donation_id) DO NOTHING;
+	`
+
+	if _, err = db.Exec(insertQuery, goalID, c.DonationID, contributed); err != nil {
+		return nil, fmt.Errorf("failed to save goal contribution: %w", err)
+	}
+
+	reached, err := MarkReached(db, goalID)
+	if err != nil {
+		return nil, err
+	}
+
+	goal, err := Find(db, goalID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Progress{Goal: *goal, Contributed: contributed, Reached: reached}, nil
+}
+
+// Notify pushes a goal-progress event to the goal owner's overlay, followed by
+// goal-reached when the donation completed the goal.
+func (t *Tracker) Notify(progress Progress, username *string) error {
+	event := obsservice.GoalEvent{
+		GoalID:   progress.Goal.ID,
+		Title:    progress.Goal.Title,
+		Currency: progress.Goal.Unit(),
+		Progress: progress.Goal.Percent(),
+		Username: username,
+		Deadline: progress.Goal.Deadline,
+	}
+	event.Raised, _ = strconv.ParseFloat(progress.Goal.Raised, 64)
+	event.Target, _ = strconv.ParseFloat(progress.Goal.Target, 64)
+	if value, err := strconv.ParseFloat(progress.Contributed, 64); err == nil {
+		event.Amount = &value
+	}
+
+	if _, _, err := t.obsService.WebhookGoalProgress(progress.Goal.Owner, event); err != nil {
+		return fmt.Errorf("failed to send goal progress: %w", err)
+	}
+
+	if !progress.Reached {
+		return nil
+	}
+
+	if _, _, err := t.obsService.WebhookGoalReached(progress.Goal.Owner, event); err != nil {
+		return fmt.Errorf("failed to send goal reached: %w", err)
+	}
+
+	return nil
+}
+
+// MarkReached records when the goal's contributions first covered its target,
+// and clears it again when the target was raised above them. It reports whether
+// the goal has just been reached.
+func MarkReached(db Executor, goalID string) (bool, error) {
+	const updateQuery = `
+		WITH raised AS (
+			SELECT COALESCE(SUM(c.amount), 0) AS total
+			FROM donation_goal_contributions c
+			JOIN donations d ON d.id = c.donation_id
+			WHERE c.goal_id = $1 AND d.state <> 'reverted'
+		)
+		UPDATE donation_goals g
+		SET reached_at = CASE WHEN r.total >= g.target THEN COALESCE(g.reached_at, NOW()) END
+		FROM raised r
+		WHERE g.id = $1
+		RETURNING g.reached_at IS NOT NULL AND g.reached_at = NOW();
+	`
+
+	var reached bool
+	if err := db.QueryRow(updateQuery, goalID).Scan(&reached); err != nil {
+		return false, fmt.Errorf("failed to update goal %s: %w", goalID, err)
+	}
+
+	return reached, nil
+}
+
+const selectGoals = `
+	SELECT g.id, g.owner, g.title, g.currency, g.fiat_currency, g.target,
+	       COALESCE(SUM(c.amount) FILTER (WHERE d.state <> 'reverted'), 0),
+	       g.deadline, g.active, g.reached_at, g.created_at
+	FROM donation_goals g
+	LEFT JOIN donation_goal_contributions c ON c.goal_id = g.id
+	LEFT JOIN donations d ON d.id = c.donation_id
+`
+
+// Find returns the goal with its progress, or ErrGoalNotFound.
+func Find(db Executor, goalID string) (*Goal, error) {
+	query := selectGoals + ` WHERE g.id = $1 GROUP BY g.id;`
+
+	goal, err := scan(db.QueryRow(query, goalID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrGoalNotFound, goalID)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &goal, nil
+}
+
+// List returns the owner's goals with their progress, the active goal first.
+func List(db Database, owner string) ([]Goal, error) {
+	return list(db, selectGoals+` WHERE g.owner = $1 GROUP BY g.id ORDER BY g.active DESC, g.created_at DESC;`, owner)
+}
+
+// Active returns the active goal of the streamer with the given username, or
+// ErrGoalNotFound.
+func Active(db Database, username string) (*Goal, error) {
+	query := selectGoals + `
+		JOIN users u ON u.wallet = g.owner
+		WHERE u.username = $1 AND g.active
+		GROUP BY g.id;
+	`
+
+	goals, err := list(db, query, username)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(goals) == 0 {
+		return nil, fmt.Errorf("%w: no active goal for %s", ErrGoalNotFound, username)
+	}
+
+	return &goals[0], nil
+}
+
+func list(db Database, query string, args ...any) ([]Goal, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	goals := make([]Goal, 0, 2)
+	for rows.Next() {
+		goal, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		goals = append(goals, goal)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return goals, nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scan(row scanner) (Goal, error) {
+	var goal Goal
+	err := row.Scan(
+		&goal.ID, &goal.Owner, &goal.Title, &goal.Currency, &goal.FiatCurrency, &goal.Target, &goal.Raised,
+		&goal.Deadline, &goal.Active, &goal.ReachedAt, &goal.CreatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Goal{}, err
+	}
+
+	if err != nil {
+		return Goal{}, fmt.Errorf("failed to scan row: %w", err)
+	}
+
+	goal.Target, goal.Raised = amount.Trim(goal.Target), amount.Trim(goal.Raised)
+
+	return goal, nil
+}
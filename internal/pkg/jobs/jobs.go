@@ -0,0 +1,70 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type Logger interface {
+	Info(msg string, ctx ...interface{})
+}
+
+// Func is one run of a periodic job. Returned errors are logged and the job is
+// retried at its next tick.
+type Func func(ctx context.Context) error
+
+type job struct {
+	name     string
+	interval time.Duration
+	run      Func
+}
+
+// Runner runs registered jobs in-process, each on its own interval. It is a
+// server background task: jobs start when the server starts and Run returns
+// once every job has finished after the server's context is cancelled.
+type Runner struct {
+	logger Logger
+	jobs   []job
+}
+
+func NewRunner(logger Logger) *Runner {
+	return &Runner{logger: logger}
+}
+
+// Register adds a job run once when the runner starts and then every interval.
+// Jobs are started in registration order and must be registered before Run.
+func (r *Runner) Register(name string, interval time.Duration, run Func) {
+	r.jobs = append(r.jobs, job{name: name, interval: interval, run: run})
+}
+
+func (r *Runner) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	for _, j := range r.jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.loop(ctx, j)
+		}()
+	}
+
+	wg.Wait()
+}
+
+func (r *Runner) loop(ctx context.Context, j job) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := j.run(ctx); err != nil && ctx.Err() == nil {
+			r.logger.Info("scheduled job failed", "job", j.name, "error", err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -1,7 +1,9 @@
 package jwt
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 	"twitch-crypto-donations/internal/pkg/environment"
 
@@ -9,43 +11,179 @@ import (
 )
 
 type UserClaims struct {
-	Address string `json:"address"`
+	Address   string `json:"address"`
+	SessionID string `json:"sid"`
 	jwt.RegisteredClaims
 }
 
+// Manager signs and verifies access tokens. With HS256 both use JWT_SECRET.
+// With EdDSA or ES256 tokens are signed with the private key of the signing
+// key file and carry its kid; they verify against that key or any of the
+// verification keys, so a retired signing key keeps verifying the tokens it
+// issued while other services pick up the new one from the JWKS.
 type Manager struct {
-	tokenExpiration environment.TokenExpirationHours
+	tokenExpiration time.Duration
+	algorithm       string
 	jwtSecret       environment.JwtSecret
+	signingKey      *Key
+	keys            []*Key
 }
 
 func New(
-	tokenExpiration environment.TokenExpirationHours,
+	tokenExpiration environment.AccessTokenTTLMinutes,
 	jwtSecret environment.JwtSecret,
-) *Manager {
-	return &Manager{
-		tokenExpiration: tokenExpiration,
+	algorithm environment.JwtAlgorithm,
+	signingKeyFile environment.JwtSigningKeyFile,
+	verificationKeyFiles environment.JwtVerificationKeyFiles,
+) (*Manager, error) {
+	m := &Manager{
+		tokenExpiration: time.Duration(tokenExpiration) * time.Minute,
+		algorithm:       strings.TrimSpace(string(algorithm)),
 		jwtSecret:       jwtSecret,
 	}
+
+	switch m.algorithm {
+	case "", AlgorithmHS256:
+		m.algorithm = AlgorithmHS256
+		if m.jwtSecret == "" {
+			return nil, errors.New("JWT_SECRET is required for HS256 tokens")
+		}
+
+		return m, nil
+	case AlgorithmEdDSA, AlgorithmES256:
+	default:
+		return nil, fmt.Errorf("unsupported JWT algorithm %q, expected %s, %s or %s", m.algorithm, AlgorithmHS256, AlgorithmEdDSA, AlgorithmES256)
+	}
+
+	if strings.TrimSpace(string(signingKeyFile)) == "" {
+		return nil, fmt.Errorf("JWT_SIGNING_KEY_FILE is required for %s tokens", m.algorithm)
+	}
+
+	signingKey, err := LoadKey(strings.TrimSpace(string(signingKeyFile)))
+	if err != nil {
+		return nil, err
+	}
+
+	if signingKey.Private == nil {
+		return nil, fmt.Errorf("signing key file %s holds no private key", signingKeyFile)
+	}
+
+	if signingKey.Algorithm != m.algorithm {
+		return nil, fmt.Errorf("signing key is a %s key but JWT_ALGORITHM is %s", signingKey.Algorithm, m.algorithm)
+	}
+
+	m.signingKey = signingKey
+	m.keys = append(m.keys, signingKey)
+
+	for _, path := range strings.Split(string(verificationKeyFiles), ",") {
+		if path = strings.TrimSpace(path); path == "" {
+			continue
+		}
+
+		key, err := LoadKey(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if m.key(key.ID) == nil {
+			m.keys = append(m.keys, key)
+		}
+	}
+
+	return m, nil
 }
 
-func (m *Manager) GenerateJwt(address string) (string, error) {
-	expirationTime := time.Now().UTC().Add(time.Hour * time.Duration(m.tokenExpiration))
+// GenerateJwt issues a short-lived access token for the address within the
+// session, and returns it with its expiration time.
+func (m *Manager) GenerateJwt(address, sessionID string) (string, time.Time, error) {
+	now := time.Now().UTC()
+	expirationTime := now.Add(m.tokenExpiration)
 
 	claims := &UserClaims{
-		Address: address,
+		Address:   address,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			IssuedAt:  jwt.NewNumericDate(now),
 			Subject:   address,
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	var (
+		tokenString string
+		err         error
+	)
+
+	if m.signingKey == nil {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		tokenString, err = token.SignedString([]byte(m.jwtSecret))
+	} else {
+		token := jwt.NewWithClaims(jwt.GetSigningMethod(m.signingKey.Algorithm), claims)
+		token.Header["kid"] = m.signingKey.ID
+		tokenString, err = token.SignedString(m.signingKey.Private)
+	}
 
-	tokenString, err := token.SignedString([]byte(m.jwtSecret))
 	if err != nil {
-		return "", fmt.Errorf("failed to sign token: %w", err)
+		return "", time.Time{}, fmt.Errorf("failed to sign token: %w", err)
+	}
+
+	return tokenString, expirationTime, nil
+}
+
+// ParseJwt verifies the token's signature and expiry and returns its claims.
+// Asymmetric tokens must name a known key in their kid header and be signed
+// with that key's algorithm.
+func (m *Manager) ParseJwt(tokenString string) (*UserClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &UserClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if m.signingKey == nil {
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Method.Alg())
+			}
+
+			return []byte(m.jwtSecret), nil
+		}
+
+		kid, _ := token.Header["kid"].(string)
+		key := m.key(kid)
+		if key == nil {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+
+		if token.Method.Alg() != key.Algorithm {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Method.Alg())
+		}
+
+		return key.Public, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*UserClaims)
+	if !ok || !token.Valid {
+		return nil, errors.New("invalid token claims")
+	}
+
+	return claims, nil
+}
+
+// JWKS lists the public keys tokens verify against, the signing key first.
+// It is empty with HS256, whose secret cannot be published.
+func (m *Manager) JWKS() []JWK {
+	keys := make([]JWK, 0, len(m.keys))
+	for _, key := range m.keys {
+		keys = append(keys, key.JWK())
+	}
+
+	return keys
+}
+
+func (m *Manager) key(id string) *Key {
+	for _, key := range m.keys {
+		if key.ID == id {
+			return key
+		}
 	}
 
-	return tokenString, nil
+	return nil
 }
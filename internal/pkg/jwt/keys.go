@@ -0,0 +1,158 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+)
+
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmEdDSA = "EdDSA"
+	AlgorithmES256 = "ES256"
+)
+
+// Key is an asymmetric key tokens are verified with, and signed with when the
+// private half is loaded. Its ID is the RFC 7638 thumbprint of its JWK, so the
+// same key always gets the same kid.
+type Key struct {
+	ID        string
+	Algorithm string
+	Public    crypto.PublicKey
+	Private   crypto.Signer
+}
+
+// JWK is the public half of a key as published in the JWKS.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	Y         string `json:"y,omitempty"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+}
+
+// LoadKey reads the first PEM block of the file. A PKCS #8 or SEC 1 private key
+// loads both halves; a PKIX public key loads only the public half. Ed25519 keys
+// sign with EdDSA, P-256 keys with ES256.
+func LoadKey(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file %s: %w", path, err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("key file %s is not PEM encoded", path)
+	}
+
+	var parsed any
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		parsed, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("key file %s has unsupported PEM block %q", path, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse key file %s: %w", path, err)
+	}
+
+	key, err := newKey(parsed)
+	if err != nil {
+		return nil, fmt.Errorf("key file %s: %w", path, err)
+	}
+
+	return key, nil
+}
+
+func newKey(parsed any) (*Key, error) {
+	key := &Key{}
+
+	switch k := parsed.(type) {
+	case ed25519.PrivateKey:
+		key.Algorithm, key.Public, key.Private = AlgorithmEdDSA, k.Public(), k
+	case ed25519.PublicKey:
+		key.Algorithm, key.Public = AlgorithmEdDSA, k
+	case *ecdsa.PrivateKey:
+		key.Algorithm, key.Public, key.Private = AlgorithmES256, &k.PublicKey, k
+	case *ecdsa.PublicKey:
+		key.Algorithm, key.Public = AlgorithmES256, k
+	default:
+		return nil, fmt.Errorf("unsupported key type %T, expected Ed25519 or ECDSA P-256", parsed)
+	}
+
+	if public, ok := key.Public.(*ecdsa.PublicKey); ok && public.Curve != elliptic.P256() {
+		return nil, errors.New("unsupported ECDSA curve, expected P-256")
+	}
+
+	thumbprint, err := key.thumbprint()
+	if err != nil {
+		return nil, err
+	}
+	key.ID = thumbprint
+
+	return key, nil
+}
+
+func (k *Key) JWK() JWK {
+	jwk := JWK{KeyID: k.ID, Algorithm: k.Algorithm, Use: "sig"}
+
+	switch public := k.Public.(type) {
+	case ed25519.PublicKey:
+		jwk.KeyType, jwk.Curve, jwk.X = "OKP", "Ed25519", encode(public)
+	case *ecdsa.PublicKey:
+		size := (public.Curve.Params().BitSize + 7) / 8
+		jwk.KeyType, jwk.Curve = "EC", "P-256"
+		jwk.X, jwk.Y = encode(public.X.FillBytes(make([]byte, size))), encode(public.Y.FillBytes(make([]byte, size)))
+	}
+
+	return jwk
+}
+
+// thumbprint hashes the required JWK members in lexicographic order, without
+// whitespace, as RFC 7638 specifies.
+func (k *Key) thumbprint() (string, error) {
+	jwk := k.JWK()
+
+	var members any
+	if jwk.KeyType == "OKP" {
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Curve, jwk.KeyType, jwk.X}
+	} else {
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{jwk.Curve, jwk.KeyType, jwk.X, jwk.Y}
+	}
+
+	canonical, err := json.Marshal(members)
+	if err != nil {
+		return "", fmt.Errorf("failed to compute key thumbprint: %w", err)
+	}
+
+	digest := sha256.Sum256(canonical)
+	return encode(digest[:]), nil
+}
+
+func encode(value []byte) string {
+	return base64.RawURLEncoding.EncodeToString(value)
+}
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"twitch-crypto-donations/internal/pkg/apikeys"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	ApiKeyHeader = "X-API-Key"
+	ApiKeyIDKey  = "api_key"
+	ScopesKey    = "scopes"
+)
+
+type ApiKeyAuthenticator interface {
+	Authenticate(secret string) (*apikeys.Key, error)
+}
+
+type ApiKeyMiddleware struct {
+	keys   ApiKeyAuthenticator
+	logger Logger
+}
+
+func NewApiKeyMiddleware(keys ApiKeyAuthenticator, logger Logger) *ApiKeyMiddleware {
+	return &ApiKeyMiddleware{
+		keys:   keys,
+		logger: logger,
+	}
+}
+
+// Request authenticates requests carrying an X-API-Key header as the key's
+// owner, and hands every other request to fallback, normally the JWT
+// middleware. Routes behind it must check scopes with RequireScope.
+func (m *ApiKeyMiddleware) Request(fallback gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		secret := c.GetHeader(ApiKeyHeader)
+		if secret == "" {
+			fallback(c)
+			return
+		}
+
+		key, err := m.keys.Authenticate(secret)
+		if errors.Is(err, apikeys.ErrInvalidKey) || errors.Is(err, apikeys.ErrKeyRevoked) || errors.Is(err, apikeys.ErrKeyExpired) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": fmt.Sprintf("Invalid API key: %v", err)})
+			return
+		}
+
+		if err != nil {
+			m.logger.Info("failed to check api key", "error", err.Error())
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check api key"})
+			return
+		}
+
+		c.Set(AddressKey, key.Owner)
+		c.Set(ApiKeyIDKey, key.ID)
+		c.Set(ScopesKey, key.Scopes)
+
+		m.logger.Info("api key", "address", key.Owner, "key", key.ID)
+
+		c.Next()
+	}
+}
+
+// RequireScope rejects requests authenticated with an API key that lacks the
+// scope. Requests authenticated with a JWT act as the wallet itself and pass.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, exists := c.Get(ScopesKey)
+		if !exists {
+			c.Next()
+			return
+		}
+
+		scopes, _ := value.([]string)
+		if !slices.Contains(scopes, scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("API key lacks the %s scope", scope)})
+			return
+		}
+
+		c.Next()
+	}
+}
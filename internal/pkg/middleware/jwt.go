@@ -4,27 +4,36 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
-	"twitch-crypto-donations/internal/pkg/environment"
 	_jwt "twitch-crypto-donations/internal/pkg/jwt"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v4"
 )
 
 const (
 	AddressKey = "address"
 	ClaimsKey  = "claims"
+	SessionKey = "session"
 )
 
+type TokenParser interface {
+	ParseJwt(tokenString string) (*_jwt.UserClaims, error)
+}
+
+type SessionChecker interface {
+	Active(sessionID, address string) (bool, error)
+}
+
 type JwtMiddleware struct {
-	jwtSecret environment.JwtSecret
-	logger    Logger
+	tokens   TokenParser
+	sessions SessionChecker
+	logger   Logger
 }
 
-func NewJwtMiddleware(jwtSecret environment.JwtSecret, logger Logger) *JwtMiddleware {
+func NewJwtMiddleware(tokens TokenParser, sessions SessionChecker, logger Logger) *JwtMiddleware {
 	return &JwtMiddleware{
-		jwtSecret: jwtSecret,
-		logger:    logger,
+		tokens:   tokens,
+		sessions: sessions,
+		logger:   logger,
 	}
 }
 
@@ -42,29 +51,37 @@ func (m *JwtMiddleware) Request() gin.HandlerFunc {
 			return
 		}
 
-		tokenString := parts[1]
-		token, err := jwt.ParseWithClaims(tokenString, &_jwt.UserClaims{}, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Method.Alg())
-			}
-
-			return []byte(m.jwtSecret), nil
-		})
-
+		claims, err := m.tokens.ParseJwt(parts[1])
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": fmt.Sprintf("Invalid token: %v", err)})
 			return
 		}
 
-		if claims, ok := token.Claims.(*_jwt.UserClaims); ok && token.Valid {
-			c.Set(AddressKey, claims.Address)
-			c.Set(ClaimsKey, claims)
+		// Access tokens outlive a logout by up to their lifetime, so every
+		// request checks that the session they belong to is still active.
+		if claims.SessionID == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+			return
+		}
 
-			m.logger.Info("user claims", "address", claims.Address)
+		active, err := m.sessions.Active(claims.SessionID, claims.Address)
+		if err != nil {
+			m.logger.Info("failed to check session", "session", claims.SessionID, "error", err.Error())
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check session"})
+			return
+		}
 
-			c.Next()
-		} else {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+		if !active {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Session has been revoked"})
+			return
 		}
+
+		c.Set(AddressKey, claims.Address)
+		c.Set(ClaimsKey, claims)
+		c.Set(SessionKey, claims.SessionID)
+
+		m.logger.Info("user claims", "address", claims.Address)
+
+		c.Next()
 	}
 }
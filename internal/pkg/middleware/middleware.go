@@ -80,6 +80,7 @@ func (m *Middleware[RequestBody, ResponseBody]) Handle(ctx *gin.Context) {
 		PathParams: pathParams,
 		Headers:    ctx.Request.Header,
 		Context:    contextValues,
+		Queries:    queries,
 	}
 
 	responseCode := http.StatusInternalServerError
@@ -0,0 +1,114 @@
+package mints
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"twitch-crypto-donations/internal/pkg/environment"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+const NativeSymbol = "SOL"
+
+type Mint struct {
+	Symbol   string
+	Address  solana.PublicKey
+	Decimals uint8
+}
+
+func (m Mint) IsNative() bool {
+	return m.Address.IsZero()
+}
+
+type Registry struct {
+	bySymbol  map[string]Mint
+	byAddress map[solana.PublicKey]Mint
+}
+
+func New(acceptedMints environment.AcceptedMints) (*Registry, error) {
+	native := Mint{Symbol: NativeSymbol, Decimals: 9}
+
+	registry := &Registry{
+		bySymbol:  map[string]Mint{NativeSymbol: native},
+		byAddress: make(map[solana.PublicKey]Mint),
+	}
+
+	for _, entry := range strings.Split(string(acceptedMints), ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		mint, err := parseMint(entry)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, exists := registry.bySymbol[mint.Symbol]; exists {
+			return nil, fmt.Errorf("duplicate mint symbol %s", mint.Symbol)
+		}
+
+		registry.bySymbol[mint.Symbol] = mint
+		registry.byAddress[mint.Address] = mint
+	}
+
+	return registry, nil
+}
+
+func (r *Registry) BySymbol(symbol string) (Mint, bool) {
+	mint, ok := r.bySymbol[strings.ToUpper(symbol)]
+	return mint, ok
+}
+
+func (r *Registry) ByAddress(address string) (Mint, bool) {
+	if address == "" {
+		return r.bySymbol[NativeSymbol], true
+	}
+
+	key, err := solana.PublicKeyFromBase58(address)
+	if err != nil {
+		return Mint{}, false
+	}
+
+	mint, ok := r.byAddress[key]
+	return mint, ok
+}
+
+func (r *Registry) Tokens() []Mint {
+	tokens := make([]Mint, 0, len(r.byAddress))
+	for _, mint := range r.byAddress {
+		tokens = append(tokens, mint)
+	}
+
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].Symbol < tokens[j].Symbol
+	})
+
+	return tokens
+}
+
+func parseMint(entry string) (Mint, error) {
+	parts := strings.Split(entry, ":")
+	if len(parts) != 3 {
+		return Mint{}, fmt.Errorf("invalid mint entry %q, expected SYMBOL:ADDRESS:DECIMALS", entry)
+	}
+
+	symbol := strings.ToUpper(strings.TrimSpace(parts[0]))
+	if symbol == "" || symbol == NativeSymbol {
+		return Mint{}, fmt.Errorf("invalid mint symbol in entry %q", entry)
+	}
+
+	address, err := solana.PublicKeyFromBase58(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return Mint{}, fmt.Errorf("invalid mint address in entry %q: %w", entry, err)
+	}
+
+	decimals, err := strconv.ParseUint(strings.TrimSpace(parts[2]), 10, 8)
+	if err != nil {
+		return Mint{}, fmt.Errorf("invalid mint decimals in entry %q: %w", entry, err)
+	}
+
+	return Mint{Symbol: symbol, Address: address, Decimals: uint8(decimals)}, nil
+}
@@ -1,5 +1,7 @@
 package obsservice
 
+import "time"
+
 type AlertEvent struct {
 	Channel    string   `json:"channel"`
 	Username   *string  `json:"username"`
@@ -12,6 +14,9 @@ type AlertEvent struct {
 	VoiceUrl          *string `json:"voice_url"`
 	ImageUrl          *string `json:"image_url"`
 	GifUrl            *string `json:"gif_url"`
+
+	// Test marks an alert sent to check the overlay setup, not paid by a donation.
+	Test bool `json:"test,omitempty"`
 }
 
 type AlertSettings struct {
@@ -51,6 +56,61 @@ type MediaEvent struct {
 	Mute       *bool  `json:"mute"`
 }
 
+type GoalEvent struct {
+	Channel  string     `json:"channel"`
+	GoalID   string     `json:"goal_id"`
+	Title    string     `json:"title"`
+	Currency string     `json:"currency"`
+	Raised   float64    `json:"raised"`
+	Target   float64    `json:"target"`
+	Progress float64    `json:"progress"`
+	Deadline *time.Time `json:"deadline"`
+	Username *string    `json:"username"`
+	Amount   *float64   `json:"amount"`
+}
+
+type TimerEvent struct {
+	Channel          string     `json:"channel"`
+	Active           bool       `json:"active"`
+	Paused           bool       `json:"paused"`
+	EndsAt           *time.Time `json:"ends_at"`
+	RemainingSeconds int64      `json:"remaining_seconds"`
+	AddedSeconds     int64      `json:"added_seconds"`
+	Username         *string    `json:"username"`
+}
+
+type ChallengeEvent struct {
+	Channel     string   `json:"channel"`
+	ChallengeID string   `json:"challenge_id"`
+	Title       string   `json:"title"`
+	Description *string  `json:"description"`
+	Currency    string   `json:"currency"`
+	Pledged     float64  `json:"pledged"`
+	Threshold   float64  `json:"threshold"`
+	Progress    float64  `json:"progress"`
+	Status      string   `json:"status"`
+	Username    *string  `json:"username"`
+	Amount      *float64 `json:"amount"`
+}
+
+type PollResultsEvent struct {
+	Channel  string              `json:"channel"`
+	PollID   string              `json:"poll_id"`
+	Title    string              `json:"title"`
+	Currency string              `json:"currency"`
+	ClosesAt time.Time           `json:"closes_at"`
+	Total    float64             `json:"total"`
+	Options  []PollOptionResults `json:"options"`
+}
+
+type PollOptionResults struct {
+	ID      string  `json:"id"`
+	Label   string  `json:"label"`
+	Amount  float64 `json:"amount"`
+	Votes   int64   `json:"votes"`
+	Percent float64 `json:"percent"`
+}
+
 type SkipRequest struct {
 	Channel    string `json:"channel"`
 	WidgetType string `json:"widget_type"`
@@ -160,6 +160,202 @@ func (s *ObsService) WebhookMedia(wallet string, request MediaEvent) (any, strin
 	return response, channel, err
 }
 
+func (s *ObsService) WebhookGoalProgress(wallet string, request GoalEvent) (any, string, error) {
+	url := fmt.Sprintf("%s/webhooks/goal-progress", s.obsDomain)
+
+	channel, webhookSecret, ok := s.getChannelInfo(wallet)
+	if ok {
+		request.Channel = channel
+	}
+
+	timestamp, nonce, signature, err := s.generateSignature(webhookSecret, request)
+	if err != nil {
+		return "", "", err
+	}
+
+	var response any
+	err = s.httpClient.
+		WithLogger(s.logger).
+		Post(url).
+		WithJSON(request).
+		WithHeaders(map[string]string{
+			"x-signature": signature,
+			"x-nonce":     nonce,
+			"x-timestamp": timestamp,
+		}).
+		DecodeResponseJSON().
+		Parse(&response)
+	return response, channel, err
+}
+
+func (s *ObsService) WebhookGoalReached(wallet string, request GoalEvent) (any, string, error) {
+	url := fmt.Sprintf("%s/webhooks/goal-reached", s.obsDomain)
+
+	channel, webhookSecret, ok := s.getChannelInfo(wallet)
+	if ok {
+		request.Channel = channel
+	}
+
+	timestamp, nonce, signature, err := s.generateSignature(webhookSecret, request)
+	if err != nil {
+		return "", "", err
+	}
+
+	var response any
+	err = s.httpClient.
+		WithLogger(s.logger).
+		Post(url).
+		WithJSON(request).
+		WithHeaders(map[string]string{
+			"x-signature": signature,
+			"x-nonce":     nonce,
+			"x-timestamp": timestamp,
+		}).
+		DecodeResponseJSON().
+		Parse(&response)
+	return response, channel, err
+}
+
+func (s *ObsService) WebhookTimer(wallet string, request TimerEvent) (any, string, error) {
+	url := fmt.Sprintf("%s/webhooks/timer", s.obsDomain)
+
+	channel, webhookSecret, ok := s.getChannelInfo(wallet)
+	if ok {
+		request.Channel = channel
+	}
+
+	timestamp, nonce, signature, err := s.generateSignature(webhookSecret, request)
+	if err != nil {
+		return "", "", err
+	}
+
+	var response any
+	err = s.httpClient.
+		WithLogger(s.logger).
+		Post(url).
+		WithJSON(request).
+		WithHeaders(map[string]string{
+			"x-signature": signature,
+			"x-nonce":     nonce,
+			"x-timestamp": timestamp,
+		}).
+		DecodeResponseJSON().
+		Parse(&response)
+	return response, channel, err
+}
+
+func (s *ObsService) WebhookPollResults(wallet string, request PollResultsEvent) (any, string, error) {
+	url := fmt.Sprintf("%s/webhooks/poll-results", s.obsDomain)
+
+	channel, webhookSecret, ok := s.getChannelInfo(wallet)
+	if ok {
+		request.Channel = channel
+	}
+
+	timestamp, nonce, signature, err := s.generateSignature(webhookSecret, request)
+	if err != nil {
+		return "", "", err
+	}
+
+	var response any
+	err = s.httpClient.
+		WithLogger(s.logger).
+		Post(url).
+		WithJSON(request).
+		WithHeaders(map[string]string{
+			"x-signature": signature,
+			"x-nonce":     nonce,
+			"x-timestamp": timestamp,
+		}).
+		DecodeResponseJSON().
+		Parse(&response)
+	return response, channel, err
+}
+
+func (s *ObsService) WebhookChallengeCreated(wallet string, request ChallengeEvent) (any, string, error) {
+	url := fmt.Sprintf("%s/webhooks/challenge-created", s.obsDomain)
+
+	channel, webhookSecret, ok := s.getChannelInfo(wallet)
+	if ok {
+		request.Channel = channel
+	}
+
+	timestamp, nonce, signature, err := s.generateSignature(webhookSecret, request)
+	if err != nil {
+		return "", "", err
+	}
+
+	var response any
+	err = s.httpClient.
+		WithLogger(s.logger).
+		Post(url).
+		WithJSON(request).
+		WithHeaders(map[string]string{
+			"x-signature": signature,
+			"x-nonce":     nonce,
+			"x-timestamp": timestamp,
+		}).
+		DecodeResponseJSON().
+		Parse(&response)
+	return response, channel, err
+}
+
+func (s *ObsService) WebhookChallengeFunded(wallet string, request ChallengeEvent) (any, string, error) {
+	url := fmt.Sprintf("%s/webhooks/challenge-funded", s.obsDomain)
+
+	channel, webhookSecret, ok := s.getChannelInfo(wallet)
+	if ok {
+		request.Channel = channel
+	}
+
+	timestamp, nonce, signature, err := s.generateSignature(webhookSecret, request)
+	if err != nil {
+		return "", "", err
+	}
+
+	var response any
+	err = s.httpClient.
+		WithLogger(s.logger).
+		Post(url).
+		WithJSON(request).
+		WithHeaders(map[string]string{
+			"x-signature": signature,
+			"x-nonce":     nonce,
+			"x-timestamp": timestamp,
+		}).
+		DecodeResponseJSON().
+		Parse(&response)
+	return response, channel, err
+}
+
+func (s *ObsService) WebhookChallengeResolved(wallet string, request ChallengeEvent) (any, string, error) {
+	url := fmt.Sprintf("%s/webhooks/challenge-resolved", s.obsDomain)
+
+	channel, webhookSecret, ok := s.getChannelInfo(wallet)
+	if ok {
+		request.Channel = channel
+	}
+
+	timestamp, nonce, signature, err := s.generateSignature(webhookSecret, request)
+	if err != nil {
+		return "", "", err
+	}
+
+	var response any
+	err = s.httpClient.
+		WithLogger(s.logger).
+		Post(url).
+		WithJSON(request).
+		WithHeaders(map[string]string{
+			"x-signature": signature,
+			"x-nonce":     nonce,
+			"x-timestamp": timestamp,
+		}).
+		DecodeResponseJSON().
+		Parse(&response)
+	return response, channel, err
+}
+
 func (s *ObsService) WebhookSkip(wallet string, request MediaEvent) (any, error) {
 	url := fmt.Sprintf("%s/webhooks/skip", s.obsDomain)
 
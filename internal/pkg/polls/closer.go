@@ -0,0 +1,128 @@
+package polls
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"twitch-crypto-donations/internal/pkg/environment"
+)
+
+type Database interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+type Notifier interface {
+	Notify(poll Poll) error
+}
+
+type Logger interface {
+	Info(msg string, ctx ...interface{})
+}
+
+// Closer pushes the results of polls once their closing time passes. Results
+// are marked sent only after the overlay accepted them, so a restart retries
+// polls that closed while the service was down. Polls closed for over a day are
+// no longer retried.
+type Closer struct {
+	db        Database
+	notifier  Notifier
+	logger    Logger
+	interval  time.Duration
+	batchSize int
+}
+
+func NewCloser(db Database, notifier Notifier, logger Logger, interval environment.WatcherPollIntervalSeconds) *Closer {
+	return &Closer{
+		db:        db,
+		notifier:  notifier,
+		logger:    logger,
+		interval:  time.Duration(interval) * time.Second,
+		batchSize: 100,
+	}
+}
+
+func (c *Closer) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		c.Poll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Closer) Poll(ctx context.Context) {
+	closed, err := c.closed()
+	if err != nil {
+		c.logger.Info("poll closer failed to load polls", "error", err.Error())
+		return
+	}
+
+	for _, pollID := range closed {
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err = c.close(pollID); err != nil {
+			c.logger.Info("poll closer failed to send results", "poll", pollID, "error", err.Error())
+		}
+	}
+}
+
+func (c *Closer) close(pollID string) error {
+	poll, err := Find(c.db, pollID)
+	if err != nil {
+		return err
+	}
+
+	if err = c.notifier.Notify(*poll); err != nil {
+		return err
+	}
+
+	const updateQuery = `UPDATE polls SET results_sent_at = NOW(), updated_at = NOW() WHERE id = $1;`
+
+	if _, err = c.db.Exec(updateQuery, pollID); err != nil {
+		return fmt.Errorf("failed to mark poll %s results sent: %w", pollID, err)
+	}
+
+	return nil
+}
+
+func (c *Closer) closed() ([]string, error) {
+	const pollsQuery = `
+		SELECT id
+		FROM polls
+		WHERE closes_at <= NOW() AND closes_at > NOW() - INTERVAL '1 day' AND results_sent_at IS NULL
+		ORDER BY closes_at
+		LIMIT $1;
+	`
+
+	rows, err := c.db.Query(pollsQuery, c.batchSize)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	closed := make([]string, 0, 4)
+	for rows.Next() {
+		var pollID string
+		if err = rows.Scan(&pollID); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		closed = append(closed, pollID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return closed, nil
+}
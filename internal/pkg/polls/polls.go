@@ -0,0 +1,345 @@
+package polls
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+	"twitch-crypto-donations/internal/pkg/amount"
+	"twitch-crypto-donations/internal/pkg/environment"
+	"twitch-crypto-donations/internal/pkg/obsservice"
+	"twitch-crypto-donations/internal/pkg/pricing"
+)
+
+// FiatDecimals is the precision of votes in fiat polls.
+const FiatDecimals = 2
+
+var (
+	ErrPollNotFound     = errors.New("poll not found")
+	ErrOptionNotFound   = errors.New("poll option not found")
+	ErrPollClosed       = errors.New("poll is closed")
+	ErrCurrencyMismatch = errors.New("donation currency does not match the poll")
+)
+
+type Executor interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+type ObsService interface {
+	WebhookPollResults(wallet string, request obsservice.PollResultsEvent) (any, string, error)
+}
+
+// Poll asks donors to pick an option when donating. Votes are weighted by the
+// donated amount, counted either in a currency or in a fiat currency from
+// donation valuations.
+type Poll struct {
+	ID           string
+	Owner        string
+	Title        string
+	Currency     *string
+	FiatCurrency *string
+	ClosesAt     time.Time
+	CreatedAt    time.Time
+	Options      []Option
+}
+
+// Option is a poll choice with the decimal amount and number of votes it got.
+type Option struct {
+	ID     string
+	Label  string
+	Amount string
+	Votes  int64
+}
+
+// Unit is the currency symbol or fiat code votes are counted in.
+func (p Poll) Unit() string {
+	if p.Currency != nil {
+		return *p.Currency
+	}
+
+	return *p.FiatCurrency
+}
+
+func (p Poll) Closed(now time.Time) bool {
+	return !p.ClosesAt.After(now)
+}
+
+// Total is the decimal amount voted across all options.
+func (p Poll) Total() string {
+	total := new(big.Rat)
+	for _, option := range p.Options {
+		if value, ok := new(big.Rat).SetString(option.Amount); ok {
+			total.Add(total, value)
+		}
+	}
+
+	return amount.Trim(total.FloatString(18))
+}
+
+// Percent is the option's share of the voted amount.
+func (p Poll) Percent(option Option) float64 {
+	total, ok := new(big.Rat).SetString(p.Total())
+	if !ok || total.Sign() <= 0 {
+		return 0
+	}
+
+	value, ok := new(big.Rat).SetString(option.Amount)
+	if !ok {
+		return 0
+	}
+
+	percent, _ := value.Mul(value, big.NewRat(100, 1)).Quo(value, total).Float64()
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(percent, 'f', 2, 64), 64)
+
+	return rounded
+}
+
+// Vote is a confirmed donation's choice in one of the receiver's polls.
+type Vote struct {
+	OptionID   string
+	DonationID string
+	Receiver   string
+	Currency   string
+	Units      uint64
+	Decimals   uint8
+	Valuation  *pricing.Valuation
+}
+
+type Tracker struct {
+	obsService ObsService
+	fiat       string
+}
+
+func New(obsService ObsService, fiat environment.FiatCurrency) *Tracker {
+	return &Tracker{obsService: obsService, fiat: pricing.Fiat(fiat)}
+}
+
+// FiatCurrencies are the fiat currencies donations are valued in, and so the
+// ones fiat polls can be counted in.
+func (t *Tracker) FiatCurrencies() []string {
+	return pricing.FiatCurrencies(t.fiat)
+}
+
+// Validate checks that the option belongs to an open poll of the receiver that
+// counts donations in the given currency.
+func (t *Tracker) Validate(db Executor, receiver, optionID, currency string) error {
+	const optionQuery = `
+		SELECT p.currency, p.closes_at <= NOW()
+		FROM poll_options o
+		JOIN polls p ON p.id = o.poll_id
+		WHERE o.id = $1 AND p.owner = $2;
+	`
+
+	var (
+		pollCurrency *string
+		closed       bool
+	)
+
+	err := db.QueryRow(optionQuery, optionID, receiver).Scan(&pollCurrency, &closed)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %s", ErrOptionNotFound, optionID)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to load poll option: %w", err)
+	}
+
+	if closed {
+		return ErrPollClosed
+	}
+
+	if pollCurrency != nil && *pollCurrency != currency {
+		return fmt.Errorf("%w: poll counts %s", ErrCurrencyMismatch, *pollCurrency)
+	}
+
+	return nil
+}
+
+// Record counts the donation towards the chosen option. A donation votes once
+// per poll; votes arriving after the poll closed, or without a valuation in a
+// fiat poll's currency, are dropped.
+func (t *Tracker) Record(db Executor, vote Vote) error {
+	const pollQuery = `
+		SELECT p.id, p.currency, p.fiat_currency
+		FROM poll_options o
+		JOIN polls p ON p.id = o.poll_id
+		WHERE o.id = $1 AND p.owner = $2 AND p.closes_at > NOW()
+		FOR SHARE OF p;
+	`
+
+	var (
+		pollID                 string
+		currency, fiatCurrency *string
+	)
+
+	err := db.QueryRow(pollQuery, vote.OptionID, vote.Receiver).Scan(&pollID, &currency, &fiatCurrency)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to load poll: %w", err)
+	}
+
+	var weight string
+	switch {
+	case currency != nil && *currency == vote.Currency:
+		weight = amount.Format(vote.Units, vote.Decimals)
+	case fiatCurrency != nil && vote.Valuation != nil && *fiatCurrency == pricing.USD:
+		weight = vote.Valuation.USD
+	case fiatCurrency != nil && vote.Valuation != nil && *fiatCurrency == vote.Valuation.FiatCurrency:
+		weight = vote.Valuation.Fiat
+	default:
+		return nil
+	}
+
+	const insertQuery = `
+		INSERT INTO poll_votes (poll_id, option_id, donation_id, amount)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (poll_id, donation_id) DO NOTHING;
+	`
+
+	if _, err = db.Exec(insertQuery, pollID, vote.OptionID, vote.DonationID, weight); err != nil {
+		return fmt.Errorf("failed to save poll vote: %w", err)
+	}
+
+	return nil
+}
+
+// Notify pushes the final results of a closed poll to the owner's overlay.
+func (t *Tracker) Notify(poll Poll) error {
+	event := obsservice.PollResultsEvent{
+		PollID:   poll.ID,
+		Title:    poll.Title,
+		Currency: poll.Unit(),
+		ClosesAt: poll.ClosesAt,
+		Options:  make([]obsservice.PollOptionResults, 0, len(poll.Options)),
+	}
+	event.Total, _ = strconv.ParseFloat(poll.Total(), 64)
+
+	for _, option := range poll.Options {
+		result := obsservice.PollOptionResults{
+			ID:      option.ID,
+			Label:   option.Label,
+			Votes:   option.Votes,
+			Percent: poll.Percent(option),
+		}
+		result.Amount, _ = strconv.ParseFloat(option.Amount, 64)
+		event.Options = append(event.Options, result)
+	}
+
+	if _, _, err := t.obsService.WebhookPollResults(poll.Owner, event); err != nil {
+		return fmt.Errorf("failed to send poll results: %w", err)
+	}
+
+	return nil
+}
+
+const selectPolls = `
+	SELECT p.id, p.owner, p.title, p.currency, p.fiat_currency, p.closes_at, p.created_at
+	FROM polls p
+`
+
+// Find returns the poll with its live results, or ErrPollNotFound.
+func Find(db Executor, pollID string) (*Poll, error) {
+	polls, err := list(db, selectPolls+` WHERE p.id = $1;`, pollID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(polls) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrPollNotFound, pollID)
+	}
+
+	return &polls[0], nil
+}
+
+// List returns the owner's polls with their results, newest first.
+func List(db Executor, owner string) ([]Poll, error) {
+	return list(db, selectPolls+` WHERE p.owner = $1 ORDER BY p.created_at DESC;`, owner)
+}
+
+// ListByUsername returns the streamer's open polls followed by the most recently
+// closed ones, up to limit polls.
+func ListByUsername(db Executor, username string, limit int) ([]Poll, error) {
+	query := selectPolls + `
+		JOIN users u ON u.wallet = p.owner
+		WHERE u.username = $1
+		ORDER BY p.closes_at > NOW() DESC, p.closes_at DESC
+		LIMIT $2;
+	`
+
+	return list(db, query, username, limit)
+}
+
+func list(db Executor, query string, args ...any) ([]Poll, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	polls := make([]Poll, 0, 2)
+	for rows.Next() {
+		var poll Poll
+		err = rows.Scan(&poll.ID, &poll.Owner, &poll.Title, &poll.Currency, &poll.FiatCurrency, &poll.ClosesAt, &poll.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		polls = append(polls, poll)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	rows.Close()
+
+	for i := range polls {
+		if polls[i].Options, err = options(db, polls[i].ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return polls, nil
+}
+
+func options(db Executor, pollID string) ([]Option, error) {
+	const optionsQuery = `
+		SELECT o.id, o.label,
+		       COALESCE(SUM(v.amount) FILTER (WHERE d.state <> 'reverted'), 0),
+		       COUNT(d.id) FILTER (WHERE d.state <> 'reverted')
+		FROM poll_options o
+		LEFT JOIN poll_votes v ON v.option_id = o.id
+		LEFT JOIN donations d ON d.id = v.donation_id
+		WHERE o.poll_id = $1
+		GROUP BY o.id
+		ORDER BY o.position;
+	`
+
+	rows, err := db.Query(optionsQuery, pollID)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	options := make([]Option, 0, 4)
+	for rows.Next() {
+		var option Option
+		if err = rows.Scan(&option.ID, &option.Label, &option.Amount, &option.Votes); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		option.Amount = amount.Trim(option.Amount)
+		options = append(options, option)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return options, nil
+}
@@ -0,0 +1,52 @@
+package pricing
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"time"
+	"twitch-crypto-donations/internal/pkg/environment"
+)
+
+// CachingProvider keeps quotes from another provider for a fixed time to stay
+// within the upstream rate limits.
+type CachingProvider struct {
+	provider PriceProvider
+	ttl      time.Duration
+
+	mu     sync.Mutex
+	quotes map[string]Quote
+}
+
+func NewCache(provider PriceProvider, ttl environment.PriceCacheTTLSeconds) *CachingProvider {
+	return &CachingProvider{
+		provider: provider,
+		ttl:      time.Duration(ttl) * time.Second,
+		quotes:   make(map[string]Quote),
+	}
+}
+
+func (c *CachingProvider) Quote(ctx context.Context, symbol, fiat string) (Quote, error) {
+	key := quoteKey(symbol, fiat)
+
+	c.mu.Lock()
+	quote, ok := c.quotes[key]
+	c.mu.Unlock()
+
+	if ok && time.Since(quote.PricedAt) < c.ttl {
+		quote.Price = new(big.Rat).Set(quote.Price)
+		return quote, nil
+	}
+
+	quote, err := c.provider.Quote(ctx, symbol, fiat)
+	if err != nil {
+		return Quote{}, err
+	}
+
+	c.mu.Lock()
+	c.quotes[key] = quote
+	c.mu.Unlock()
+
+	quote.Price = new(big.Rat).Set(quote.Price)
+	return quote, nil
+}
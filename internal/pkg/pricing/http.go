@@ -0,0 +1,92 @@
+package pricing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"twitch-crypto-donations/internal/pkg/environment"
+	"twitch-crypto-donations/internal/pkg/http"
+)
+
+type HttpClient interface {
+	Get(url string) *http.RequestBuilder
+	WithLogger(logger http.Logger) *http.Client
+}
+
+// HTTPProvider fetches spot prices from a CoinGecko compatible
+// /simple/price endpoint.
+type HTTPProvider struct {
+	httpClient HttpClient
+	logger     Logger
+	baseURL    string
+	coinIDs    map[string]string
+}
+
+// NewHTTP maps registry symbols to the provider's coin ids, given in the form
+// SYMBOL:ID separated by semicolons, for example "SOL:solana;USDC:usd-coin".
+func NewHTTP(httpClient HttpClient, logger Logger, apiURL environment.PriceAPIURL, coinIDs environment.PriceCoinIDs) (*HTTPProvider, error) {
+	provider := &HTTPProvider{
+		httpClient: httpClient,
+		logger:     logger,
+		baseURL:    strings.TrimRight(string(apiURL), "/"),
+		coinIDs:    make(map[string]string),
+	}
+
+	for _, entry := range strings.Split(string(coinIDs), ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		symbol, id, ok := strings.Cut(entry, ":")
+		if !ok || symbol == "" || id == "" {
+			return nil, fmt.Errorf("invalid price coin id %q, expected SYMBOL:ID", entry)
+		}
+
+		provider.coinIDs[strings.ToUpper(symbol)] = id
+	}
+
+	return provider, nil
+}
+
+func (p *HTTPProvider) Quote(ctx context.Context, symbol, fiat string) (Quote, error) {
+	id, ok := p.coinIDs[strings.ToUpper(symbol)]
+	if !ok {
+		return Quote{}, fmt.Errorf("%w: no coin id configured for %s", ErrPriceUnavailable, symbol)
+	}
+
+	vsCurrency := strings.ToLower(fiat)
+	query := url.Values{"ids": {id}, "vs_currencies": {vsCurrency}}
+
+	var response map[string]map[string]json.Number
+	err := p.httpClient.
+		WithLogger(p.logger).
+		Get(fmt.Sprintf("%s/simple/price?%s", p.baseURL, query.Encode())).
+		WithContext(ctx).
+		WithDefaultRetry().
+		DecodeResponseJSON().
+		Parse(&response)
+	if err != nil {
+		return Quote{}, fmt.Errorf("failed to fetch price of %s: %w", symbol, err)
+	}
+
+	value, ok := response[id][vsCurrency]
+	if !ok {
+		return Quote{}, fmt.Errorf("%w: %s has no %s price", ErrPriceUnavailable, symbol, fiat)
+	}
+
+	price, err := parsePrice(value.String())
+	if err != nil {
+		return Quote{}, err
+	}
+
+	return Quote{
+		Symbol:   strings.ToUpper(symbol),
+		Fiat:     strings.ToUpper(fiat),
+		Price:    price,
+		PricedAt: time.Now().UTC(),
+	}, nil
+}
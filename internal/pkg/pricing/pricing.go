@@ -0,0 +1,125 @@
+package pricing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+	"twitch-crypto-donations/internal/pkg/environment"
+)
+
+const USD = "USD"
+
+var ErrPriceUnavailable = errors.New("price unavailable")
+
+type Quote struct {
+	Symbol   string
+	Fiat     string
+	Price    *big.Rat
+	PricedAt time.Time
+}
+
+type PriceProvider interface {
+	Quote(ctx context.Context, symbol, fiat string) (Quote, error)
+}
+
+type Logger interface {
+	Info(msg string, ctx ...interface{})
+}
+
+type Valuation struct {
+	USD          string
+	Fiat         string
+	FiatCurrency string
+	PricedAt     time.Time
+}
+
+type Valuer struct {
+	provider PriceProvider
+	fiat     string
+	logger   Logger
+}
+
+func NewValuer(provider PriceProvider, fiat environment.FiatCurrency, logger Logger) *Valuer {
+	return &Valuer{provider: provider, fiat: Fiat(fiat), logger: logger}
+}
+
+// Fiat is the configured fiat currency in upper case, USD when unset.
+func Fiat(fiat environment.FiatCurrency) string {
+	currency := strings.ToUpper(strings.TrimSpace(string(fiat)))
+	if currency == "" {
+		return USD
+	}
+
+	return currency
+}
+
+// FiatCurrencies are the fiat currencies donations are valued in: USD and the
+// configured fiat currency.
+func FiatCurrencies(fiat string) []string {
+	if fiat == USD {
+		return []string{USD}
+	}
+
+	return []string{USD, fiat}
+}
+
+// Value prices an amount of base units in USD and the configured fiat currency.
+// Pricing is best effort: when the USD quote is unavailable the failure is logged
+// and nil is returned so that the donation itself is never blocked on it. A
+// missing fiat quote falls back to the USD value.
+func (v *Valuer) Value(ctx context.Context, symbol string, units uint64, decimals uint8) *Valuation {
+	usd, err := v.convert(ctx, symbol, USD, units, decimals)
+	if err != nil {
+		v.logger.Info("failed to price donation", "symbol", symbol, "fiat", USD, "error", err.Error())
+		return nil
+	}
+
+	valuation := &Valuation{USD: usd.value, Fiat: usd.value, FiatCurrency: USD, PricedAt: usd.pricedAt}
+	if v.fiat == USD {
+		return valuation
+	}
+
+	fiat, err := v.convert(ctx, symbol, v.fiat, units, decimals)
+	if err != nil {
+		v.logger.Info("failed to price donation", "symbol", symbol, "fiat", v.fiat, "error", err.Error())
+		return valuation
+	}
+
+	valuation.Fiat, valuation.FiatCurrency = fiat.value, v.fiat
+
+	return valuation
+}
+
+type converted struct {
+	value    string
+	pricedAt time.Time
+}
+
+func (v *Valuer) convert(ctx context.Context, symbol, fiat string, units uint64, decimals uint8) (converted, error) {
+	quote, err := v.provider.Quote(ctx, strings.ToUpper(symbol), fiat)
+	if err != nil {
+		return converted{}, err
+	}
+
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	value := new(big.Rat).SetFrac(new(big.Int).SetUint64(units), scale)
+	value.Mul(value, quote.Price)
+
+	return converted{value: value.FloatString(2), pricedAt: quote.PricedAt}, nil
+}
+
+func parsePrice(value string) (*big.Rat, error) {
+	price, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+	if !ok || price.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid price %q", value)
+	}
+
+	return price, nil
+}
+
+func quoteKey(symbol, fiat string) string {
+	return strings.ToUpper(symbol) + "/" + strings.ToUpper(fiat)
+}
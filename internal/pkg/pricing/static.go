@@ -0,0 +1,56 @@
+package pricing
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+	"twitch-crypto-donations/internal/pkg/environment"
+)
+
+// StaticProvider serves fixed prices, for local development and offline use.
+type StaticProvider struct {
+	prices map[string]*big.Rat
+}
+
+// NewStatic parses prices in the form SYMBOL:FIAT:PRICE separated by semicolons,
+// for example "SOL:USD:150;USDC:USD:1".
+func NewStatic(prices environment.StaticPrices) (*StaticProvider, error) {
+	provider := &StaticProvider{prices: make(map[string]*big.Rat)}
+
+	for _, entry := range strings.Split(string(prices), ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.Split(entry, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid static price %q, expected SYMBOL:FIAT:PRICE", entry)
+		}
+
+		price, err := parsePrice(parts[2])
+		if err != nil {
+			return nil, err
+		}
+
+		provider.prices[quoteKey(parts[0], parts[1])] = price
+	}
+
+	return provider, nil
+}
+
+func (p *StaticProvider) Quote(_ context.Context, symbol, fiat string) (Quote, error) {
+	price, ok := p.prices[quoteKey(symbol, fiat)]
+	if !ok {
+		return Quote{}, fmt.Errorf("%w: no static price for %s in %s", ErrPriceUnavailable, symbol, fiat)
+	}
+
+	return Quote{
+		Symbol:   strings.ToUpper(symbol),
+		Fiat:     strings.ToUpper(fiat),
+		Price:    new(big.Rat).Set(price),
+		PricedAt: time.Now().UTC(),
+	}, nil
+}
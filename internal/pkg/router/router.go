@@ -2,17 +2,59 @@ package router
 
 import (
 	"fmt"
+	"twitch-crypto-donations/internal/app/acceptcollabinvite"
+	"twitch-crypto-donations/internal/app/builddonationtransaction"
+	"twitch-crypto-donations/internal/app/closepoll"
+	"twitch-crypto-donations/internal/app/createapikey"
+	"twitch-crypto-donations/internal/app/createchallenge"
+	"twitch-crypto-donations/internal/app/createcollabgroup"
+	"twitch-crypto-donations/internal/app/creategiveaway"
+	"twitch-crypto-donations/internal/app/creategoal"
+	"twitch-crypto-donations/internal/app/createpaymentrequest"
+	"twitch-crypto-donations/internal/app/createpoll"
+	"twitch-crypto-donations/internal/app/deletegoal"
 	"twitch-crypto-donations/internal/app/donationsanalytics"
 	"twitch-crypto-donations/internal/app/donationshistory"
+	"twitch-crypto-donations/internal/app/getchallenges"
 	"twitch-crypto-donations/internal/app/getdefaultobssettings"
+	"twitch-crypto-donations/internal/app/getdonation"
+	"twitch-crypto-donations/internal/app/getdonationrules"
+	"twitch-crypto-donations/internal/app/getgiveaway"
+	"twitch-crypto-donations/internal/app/getgoal"
+	"twitch-crypto-donations/internal/app/getjwks"
+	"twitch-crypto-donations/internal/app/getpaymentrequest"
+	"twitch-crypto-donations/internal/app/getpolls"
+	"twitch-crypto-donations/internal/app/getrevenuesplits"
 	"twitch-crypto-donations/internal/app/getstreamerinfo"
+	"twitch-crypto-donations/internal/app/getsubathon"
+	"twitch-crypto-donations/internal/app/listapikeys"
+	"twitch-crypto-donations/internal/app/listchallenges"
+	"twitch-crypto-donations/internal/app/listcollabgroups"
+	"twitch-crypto-donations/internal/app/listdonations"
+	"twitch-crypto-donations/internal/app/listevents"
+	"twitch-crypto-donations/internal/app/listgiveaways"
+	"twitch-crypto-donations/internal/app/listgoals"
+	"twitch-crypto-donations/internal/app/listpolls"
+	"twitch-crypto-donations/internal/app/logout"
+	"twitch-crypto-donations/internal/app/logoutall"
 	"twitch-crypto-donations/internal/app/noncegeneration"
 	"twitch-crypto-donations/internal/app/paymentconfirmation"
+	"twitch-crypto-donations/internal/app/refreshsession"
+	"twitch-crypto-donations/internal/app/resolvechallenge"
+	"twitch-crypto-donations/internal/app/revokeapikey"
 	"twitch-crypto-donations/internal/app/senddonate"
+	"twitch-crypto-donations/internal/app/sendtestalert"
+	"twitch-crypto-donations/internal/app/setdonationrules"
 	"twitch-crypto-donations/internal/app/setobswebhooks"
+	"twitch-crypto-donations/internal/app/setrevenuesplits"
 	"twitch-crypto-donations/internal/app/setuserinfo"
 	"twitch-crypto-donations/internal/app/signatureverification"
+	"twitch-crypto-donations/internal/app/startsubathon"
+	"twitch-crypto-donations/internal/app/stopsubathon"
 	"twitch-crypto-donations/internal/app/updatedefaultobssettings"
+	"twitch-crypto-donations/internal/app/updategoal"
+	"twitch-crypto-donations/internal/app/updatesubathon"
+	"twitch-crypto-donations/internal/pkg/apikeys"
 	"twitch-crypto-donations/internal/pkg/environment"
 	"twitch-crypto-donations/internal/pkg/middleware"
 
@@ -34,6 +76,47 @@ type Handlers struct {
 	DonationsHistory         *donationshistory.Handler
 	GetDefaultObsSettings    *getdefaultobssettings.Handler
 	UpdateDefaultObsSettings *updatedefaultobssettings.Handler
+	CreatePaymentRequest     *createpaymentrequest.Handler
+	GetPaymentRequest        *getpaymentrequest.Handler
+	BuildDonationTransaction *builddonationtransaction.Handler
+	ListDonations            *listdonations.Handler
+	GetDonation              *getdonation.Handler
+	ListEvents               *listevents.Handler
+	GetRevenueSplits         *getrevenuesplits.Handler
+	SetRevenueSplits         *setrevenuesplits.Handler
+	CreateCollabGroup        *createcollabgroup.Handler
+	ListCollabGroups         *listcollabgroups.Handler
+	AcceptCollabInvite       *acceptcollabinvite.Handler
+	GetDonationRules         *getdonationrules.Handler
+	SetDonationRules         *setdonationrules.Handler
+	CreateGoal               *creategoal.Handler
+	ListGoals                *listgoals.Handler
+	UpdateGoal               *updategoal.Handler
+	DeleteGoal               *deletegoal.Handler
+	GetGoal                  *getgoal.Handler
+	StartSubathon            *startsubathon.Handler
+	UpdateSubathon           *updatesubathon.Handler
+	StopSubathon             *stopsubathon.Handler
+	GetSubathon              *getsubathon.Handler
+	CreatePoll               *createpoll.Handler
+	ListPolls                *listpolls.Handler
+	ClosePoll                *closepoll.Handler
+	GetPolls                 *getpolls.Handler
+	CreateGiveaway           *creategiveaway.Handler
+	ListGiveaways            *listgiveaways.Handler
+	GetGiveaway              *getgiveaway.Handler
+	CreateChallenge          *createchallenge.Handler
+	ListChallenges           *listchallenges.Handler
+	ResolveChallenge         *resolvechallenge.Handler
+	GetChallenges            *getchallenges.Handler
+	RefreshSession           *refreshsession.Handler
+	Logout                   *logout.Handler
+	LogoutAll                *logoutall.Handler
+	GetJwks                  *getjwks.Handler
+	CreateApiKey             *createapikey.Handler
+	ListApiKeys              *listapikeys.Handler
+	RevokeApiKey             *revokeapikey.Handler
+	SendTestAlert            *sendtestalert.Handler
 }
 
 func New(
@@ -42,6 +125,7 @@ func New(
 	routePrefix environment.RoutePrefix,
 	swaggerPath environment.SwaggerPath,
 	jwtMiddleware *middleware.JwtMiddleware,
+	apiKeyMiddleware *middleware.ApiKeyMiddleware,
 	middlewares ...gin.HandlerFunc,
 ) *gin.Engine {
 	engine.StaticFile("/swagger.yml", string(swaggerPath))
@@ -51,15 +135,64 @@ func New(
 		ginSwagger.DefaultModelsExpandDepth(-1),
 	))
 
+	wellKnown := engine.Group("/.well-known")
+	wellKnown.Use(middlewares...)
+	{
+		wellKnown.GET("/jwks.json", middleware.New(handlers.GetJwks).Handle)
+	}
+
 	secure := engine.Group(fmt.Sprintf("%s/secure", routePrefix))
 	secure.Use(middlewares...)
 	secure.Use(jwtMiddleware.Request())
 	{
-		secure.GET("/donations-analytics", middleware.New(handlers.DonationsAnalytics).Handle)
-		secure.PUT("/me", middleware.New(handlers.SetUserInfo).Handle)
 		secure.GET("/me", middleware.New(handlers.GetStreamerInfo).Handle)
-		secure.GET("/donations-history", middleware.New(handlers.DonationsHistory).Handle)
-		secure.PUT("/update-default-obs-settings", middleware.New(handlers.UpdateDefaultObsSettings).Handle)
+		secure.GET("/events", middleware.New(handlers.ListEvents).Handle)
+		secure.GET("/revenue-splits", middleware.New(handlers.GetRevenueSplits).Handle)
+		secure.PUT("/revenue-splits", middleware.New(handlers.SetRevenueSplits).Handle)
+		secure.POST("/collab-groups", middleware.New(handlers.CreateCollabGroup).Handle)
+		secure.GET("/collab-groups", middleware.New(handlers.ListCollabGroups).Handle)
+		secure.POST("/collab-groups/:id/accept", middleware.New(handlers.AcceptCollabInvite).Handle)
+		secure.POST("/goals", middleware.New(handlers.CreateGoal).Handle)
+		secure.GET("/goals", middleware.New(handlers.ListGoals).Handle)
+		secure.PUT("/goals/:id", middleware.New(handlers.UpdateGoal).Handle)
+		secure.DELETE("/goals/:id", middleware.New(handlers.DeleteGoal).Handle)
+		secure.POST("/subathon", middleware.New(handlers.StartSubathon).Handle)
+		secure.PUT("/subathon", middleware.New(handlers.UpdateSubathon).Handle)
+		secure.DELETE("/subathon", middleware.New(handlers.StopSubathon).Handle)
+		secure.POST("/polls", middleware.New(handlers.CreatePoll).Handle)
+		secure.GET("/polls", middleware.New(handlers.ListPolls).Handle)
+		secure.POST("/polls/:id/close", middleware.New(handlers.ClosePoll).Handle)
+		secure.POST("/giveaways", middleware.New(handlers.CreateGiveaway).Handle)
+		secure.GET("/giveaways", middleware.New(handlers.ListGiveaways).Handle)
+		secure.POST("/challenges", middleware.New(handlers.CreateChallenge).Handle)
+		secure.GET("/challenges", middleware.New(handlers.ListChallenges).Handle)
+		secure.POST("/challenges/:id/resolve", middleware.New(handlers.ResolveChallenge).Handle)
+		secure.POST("/auth/logout-all", middleware.New(handlers.LogoutAll).Handle)
+		secure.POST("/api-keys", middleware.New(handlers.CreateApiKey).Handle)
+		secure.GET("/api-keys", middleware.New(handlers.ListApiKeys).Handle)
+		secure.DELETE("/api-keys/:id", middleware.New(handlers.RevokeApiKey).Handle)
+	}
+
+	// Routes bots and integrations may call with an API key as well as with a
+	// JWT, each behind the scope a key needs for it. Every other secure route
+	// takes JWTs only.
+	scoped := engine.Group(fmt.Sprintf("%s/secure", routePrefix))
+	scoped.Use(middlewares...)
+	scoped.Use(apiKeyMiddleware.Request(jwtMiddleware.Request()))
+	{
+		donationsRead := middleware.RequireScope(apikeys.ScopeDonationsRead)
+		scoped.GET("/donations-analytics", donationsRead, middleware.New(handlers.DonationsAnalytics).Handle)
+		scoped.GET("/donations-history", donationsRead, middleware.New(handlers.DonationsHistory).Handle)
+		scoped.GET("/donations", donationsRead, middleware.New(handlers.ListDonations).Handle)
+		scoped.GET("/donations/:id", donationsRead, middleware.New(handlers.GetDonation).Handle)
+
+		alertsWrite := middleware.RequireScope(apikeys.ScopeAlertsWrite)
+		scoped.POST("/alerts/test", alertsWrite, middleware.New(handlers.SendTestAlert).Handle)
+
+		settingsWrite := middleware.RequireScope(apikeys.ScopeSettingsWrite)
+		scoped.PUT("/me", settingsWrite, middleware.New(handlers.SetUserInfo).Handle)
+		scoped.PUT("/update-default-obs-settings", settingsWrite, middleware.New(handlers.UpdateDefaultObsSettings).Handle)
+		scoped.PUT("/donation-rules", settingsWrite, middleware.New(handlers.SetDonationRules).Handle)
 	}
 
 	api := engine.Group(string(routePrefix))
@@ -69,9 +202,20 @@ func New(
 		api.GET("/streamer-info/:username", middleware.New(handlers.GetStreamerInfo).Handle)
 		api.POST("/generate-nonce", middleware.New(handlers.NonceGenerator).Handle)
 		api.POST("/verify-signature", middleware.New(handlers.SignatureVerification).Handle)
+		api.POST("/auth/refresh", middleware.New(handlers.RefreshSession).Handle)
+		api.POST("/auth/logout", middleware.New(handlers.Logout).Handle)
 		api.POST("/set-obs-webhooks", middleware.New(handlers.SetObsWebhooks).Handle)
 		api.POST("/send-donate", middleware.New(handlers.SendDonate).Handle)
 		api.POST("/confirm-payment", middleware.New(handlers.PaymentConfirmation).Handle)
+		api.POST("/payment-requests", middleware.New(handlers.CreatePaymentRequest).Handle)
+		api.GET("/payment-requests/:reference", middleware.New(handlers.GetPaymentRequest).Handle)
+		api.POST("/donation-transactions", middleware.New(handlers.BuildDonationTransaction).Handle)
+		api.GET("/donation-rules/:address", middleware.New(handlers.GetDonationRules).Handle)
+		api.GET("/goals/:username", middleware.New(handlers.GetGoal).Handle)
+		api.GET("/subathon/:username", middleware.New(handlers.GetSubathon).Handle)
+		api.GET("/polls/:username", middleware.New(handlers.GetPolls).Handle)
+		api.GET("/giveaways/:id", middleware.New(handlers.GetGiveaway).Handle)
+		api.GET("/challenges/:username", middleware.New(handlers.GetChallenges).Handle)
 	}
 
 	return engine
@@ -1,32 +1,88 @@
 package server
 
 import (
+	"context"
 	"errors"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// shutdownTimeout bounds how long in-flight requests and background tasks get
+// to finish once a shutdown signal arrives.
+const shutdownTimeout = 15 * time.Second
+
+type BackgroundTask interface {
+	Run(ctx context.Context)
+}
+
 type Server struct {
 	engine     *gin.Engine
 	listenPort string
+	tasks      []BackgroundTask
 }
 
-func New(engine *gin.Engine, listenPort string) *Server {
+func New(engine *gin.Engine, listenPort string, tasks []BackgroundTask) *Server {
 	return &Server{
 		listenPort: listenPort,
 		engine:     engine,
+		tasks:      tasks,
 	}
 }
 
+// ServerHTTP serves until SIGINT or SIGTERM, then stops accepting requests,
+// cancels the background tasks and waits for both to finish.
 func (s *Server) ServerHTTP() {
-	err := s.engine.Run(":" + s.listenPort)
-	if err != nil {
-		if !errors.Is(err, http.ErrServerClosed) {
+	signals, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var tasks sync.WaitGroup
+	for _, task := range s.tasks {
+		tasks.Add(1)
+		go func() {
+			defer tasks.Done()
+			task.Run(ctx)
+		}()
+	}
+
+	httpServer := &http.Server{Addr: ":" + s.listenPort, Handler: s.engine}
+	go func() {
+		err := httpServer.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
 			log.Fatalf("Server failed to start: %v", err)
 		}
+	}()
+
+	<-signals.Done()
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancelShutdown()
+
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Server forced to shut down: %v", err)
+	}
+
+	cancel()
+
+	stopped := make(chan struct{})
+	go func() {
+		tasks.Wait()
+		close(stopped)
+	}()
 
+	select {
+	case <-stopped:
 		log.Println("Server gracefully shut down.")
+	case <-shutdownCtx.Done():
+		log.Println("Server shut down before background tasks finished.")
 	}
 }
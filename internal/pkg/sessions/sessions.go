@@ -0,0 +1,278 @@
+package sessions
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+	"twitch-crypto-donations/internal/pkg/environment"
+
+	"github.com/google/uuid"
+)
+
+// revokedRetention is how long revoked sessions are kept, so a refresh token
+// replayed shortly after logout is reported as revoked rather than unknown.
+const revokedRetention = 24 * time.Hour
+
+var (
+	ErrSessionNotFound     = errors.New("refresh token is invalid")
+	ErrSessionRevoked      = errors.New("session has been revoked")
+	ErrSessionExpired      = errors.New("session has expired")
+	ErrRefreshTokenReused  = errors.New("refresh token has already been used, session revoked")
+	errRefreshTokenMissing = errors.New("refresh token is required")
+)
+
+type Database interface {
+	QueryRow(query string, args ...any) *sql.Row
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+type TokenIssuer interface {
+	GenerateJwt(address, sessionID string) (string, time.Time, error)
+}
+
+// Tokens are the credentials of a session: a short-lived access token and the
+// refresh token that replaces both once it expires.
+type Tokens struct {
+	SessionID             string
+	AccessToken           string
+	AccessTokenExpiresAt  time.Time
+	RefreshToken          string
+	RefreshTokenExpiresAt time.Time
+}
+
+// Manager keeps wallet sessions. Refresh tokens are stored as SHA-256 hashes
+// and rotate on every use; presenting a refresh token that was already rotated
+// out revokes the session, since it means the token was copied. A session
+// expires once it goes unused for the refresh token lifetime.
+type Manager struct {
+	db         Database
+	issuer     TokenIssuer
+	refreshTTL time.Duration
+}
+
+func New(db Database, issuer TokenIssuer, refreshTTL environment.RefreshTokenTTLHours) *Manager {
+	return &Manager{
+		db:         db,
+		issuer:     issuer,
+		refreshTTL: time.Duration(refreshTTL) * time.Hour,
+	}
+}
+
+// Create starts a session for the address after it signed in.
+func (m *Manager) Create(address, userAgent string) (*Tokens, error) {
+	refreshToken, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	sessionID := uuid.NewString()
+	expiresAt := now.Add(m.refreshTTL)
+
+	const insertQuery = `
+		INSERT INTO sessions (id, address, refresh_token_hash, user_agent, expires_at, last_used_at, created_at, updated_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $6, $6);
+	`
+
+	if _, err = m.db.Exec(insertQuery, sessionID, address, hash(refreshToken), userAgent, expiresAt, now); err != nil {
+		return nil, fmt.Errorf("failed to save session: %w", err)
+	}
+
+	return m.tokens(sessionID, address, refreshToken, expiresAt)
+}
+
+// Refresh rotates the refresh token and issues a new access token.
+func (m *Manager) Refresh(refreshToken string) (*Tokens, error) {
+	if refreshToken == "" {
+		return nil, errRefreshTokenMissing
+	}
+
+	next, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	expiresAt := now.Add(m.refreshTTL)
+
+	const rotateQuery = `
+		UPDATE sessions
+		SET previous_refresh_token_hash = refresh_token_hash, refresh_token_hash = $2,
+		    expires_at = $3, last_used_at = $4, updated_at = $4
+		WHERE refresh_token_hash = $1 AND revoked_at IS NULL AND expires_at > $4
+		RETURNING id, address;
+	`
+
+	var sessionID, address string
+	err = m.db.QueryRow(rotateQuery, hash(refreshToken), hash(next), expiresAt, now).Scan(&sessionID, &address)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, m.rejected(refreshToken, now)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+
+	return m.tokens(sessionID, address, next, expiresAt)
+}
+
+// rejected tells why a refresh token was not rotated, revoking its session
+// when the token had already been rotated out.
+func (m *Manager) rejected(refreshToken string, now time.Time) error {
+	const sessionQuery = `
+		SELECT id, refresh_token_hash = $1, revoked_at IS NOT NULL, expires_at <= $2
+		FROM sessions
+		WHERE refresh_token_hash = $1 OR previous_refresh_token_hash = $1;
+	`
+
+	var (
+		sessionID                 string
+		current, revoked, expired bool
+	)
+
+	err := m.db.QueryRow(sessionQuery, hash(refreshToken), now).Scan(&sessionID, &current, &revoked, &expired)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrSessionNotFound
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to load session: %w", err)
+	}
+
+	switch {
+	case revoked:
+		return ErrSessionRevoked
+	case !current:
+		const revokeQuery = `UPDATE sessions SET revoked_at = $2, updated_at = $2 WHERE id = $1;`
+
+		if _, err = m.db.Exec(revokeQuery, sessionID, now); err != nil {
+			return fmt.Errorf("failed to revoke session: %w", err)
+		}
+		return ErrRefreshTokenReused
+	case expired:
+		return ErrSessionExpired
+	default:
+		return ErrSessionNotFound
+	}
+}
+
+// Revoke ends the session the refresh token belongs to. Revoking an already
+// revoked session succeeds.
+func (m *Manager) Revoke(refreshToken string) error {
+	if refreshToken == "" {
+		return errRefreshTokenMissing
+	}
+
+	const revokeQuery = `
+		UPDATE sessions
+		SET revoked_at = COALESCE(revoked_at, $2), updated_at = $2
+		WHERE refresh_token_hash = $1;
+	`
+
+	result, err := m.db.Exec(revokeQuery, hash(refreshToken), time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	if affected == 0 {
+		return ErrSessionNotFound
+	}
+
+	return nil
+}
+
+// RevokeAll ends every active session of the address and returns how many
+// there were.
+func (m *Manager) RevokeAll(address string) (int64, error) {
+	const revokeQuery = `
+		UPDATE sessions
+		SET revoked_at = $2, updated_at = $2
+		WHERE address = $1 AND revoked_at IS NULL;
+	`
+
+	result, err := m.db.Exec(revokeQuery, address, time.Now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	return affected, nil
+}
+
+// Active reports whether the session exists for the address and is neither
+// revoked nor expired.
+func (m *Manager) Active(sessionID, address string) (bool, error) {
+	const sessionQuery = `
+		SELECT revoked_at IS NULL AND expires_at > $3
+		FROM sessions
+		WHERE id = $1 AND address = $2;
+	`
+
+	var active bool
+	err := m.db.QueryRow(sessionQuery, sessionID, address, time.Now().UTC()).Scan(&active)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("failed to load session: %w", err)
+	}
+
+	return active, nil
+}
+
+// Cleanup deletes expired sessions and those revoked for longer than a day.
+func (m *Manager) Cleanup(_ context.Context) error {
+	const deleteQuery = `DELETE FROM sessions WHERE expires_at < $1 OR revoked_at < $2;`
+
+	now := time.Now().UTC()
+	if _, err := m.db.Exec(deleteQuery, now, now.Add(-revokedRetention)); err != nil {
+		return fmt.Errorf("cleanup failed: %w", err)
+	}
+
+	return nil
+}
+
+func (m *Manager) tokens(sessionID, address, refreshToken string, refreshExpiresAt time.Time) (*Tokens, error) {
+	accessToken, accessExpiresAt, err := m.issuer.GenerateJwt(address, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate JWT: %w", err)
+	}
+
+	return &Tokens{
+		SessionID:             sessionID,
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessExpiresAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: refreshExpiresAt,
+	}, nil
+}
+
+func newRefreshToken() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+func hash(refreshToken string) string {
+	digest := sha256.Sum256([]byte(refreshToken))
+	return hex.EncodeToString(digest[:])
+}
@@ -0,0 +1,220 @@
+package siws
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"twitch-crypto-donations/internal/pkg/environment"
+
+	"github.com/mr-tron/base58"
+)
+
+// Version is the only message version the parser accepts.
+const Version = "1"
+
+// ClockSkew is how far in the future a message may claim to be issued, to
+// tolerate clients whose clock runs slightly ahead.
+const ClockSkew = 30 * time.Second
+
+const (
+	header         = " wants you to sign in with your Solana account:"
+	uriTag         = "URI: "
+	versionTag     = "Version: "
+	chainIDTag     = "Chain ID: "
+	nonceTag       = "Nonce: "
+	issuedAtTag    = "Issued At: "
+	expirationTag  = "Expiration Time: "
+	minNonceLength = 8
+)
+
+var (
+	ErrMalformed       = errors.New("malformed sign-in message")
+	ErrDomainMismatch  = errors.New("sign-in message is for a different domain")
+	ErrURIMismatch     = errors.New("sign-in message is for a different URI")
+	ErrChainMismatch   = errors.New("sign-in message is for a different chain")
+	ErrAddressMismatch = errors.New("sign-in message is for a different address")
+	ErrNotYetValid     = errors.New("sign-in message is issued in the future")
+	ErrExpired         = errors.New("sign-in message has expired")
+)
+
+// Message is a Sign-In With Solana message. Its text form follows the SIWS
+// (and EIP-4361) layout, with the statement optional and the expiration time
+// required:
+//
+//	example.com wants you to sign in with your Solana account:
+//	<base58 address>
+//
+//	<statement>
+//
+//	URI: https://example.com
+//	Version: 1
+//	Chain ID: mainnet
+//	Nonce: <alphanumeric nonce>
+//	Issued At: <RFC 3339 time>
+//	Expiration Time: <RFC 3339 time>
+type Message struct {
+	Domain         string
+	Address        string
+	Statement      string
+	URI            string
+	Version        string
+	ChainID        string
+	Nonce          string
+	IssuedAt       time.Time
+	ExpirationTime time.Time
+}
+
+func (m Message) String() string {
+	var b strings.Builder
+	b.WriteString(m.Domain + header + "\n")
+	b.WriteString(m.Address + "\n")
+	b.WriteString("\n")
+	if m.Statement != "" {
+		b.WriteString(m.Statement + "\n")
+		b.WriteString("\n")
+	}
+	b.WriteString(uriTag + m.URI + "\n")
+	b.WriteString(versionTag + m.Version + "\n")
+	b.WriteString(chainIDTag + m.ChainID + "\n")
+	b.WriteString(nonceTag + m.Nonce + "\n")
+	b.WriteString(issuedAtTag + m.IssuedAt.UTC().Format(time.RFC3339) + "\n")
+	b.WriteString(expirationTag + m.ExpirationTime.UTC().Format(time.RFC3339))
+
+	return b.String()
+}
+
+// Parse reads a message in the layout above. Every field must be present, in
+// order, with nothing before or after it; optional SIWS fields this service
+// never issues (Not Before, Request ID, Resources) are rejected.
+func Parse(text string) (Message, error) {
+	lines := strings.Split(text, "\n")
+
+	var m Message
+	if len(lines) != 9 && len(lines) != 11 {
+		return Message{}, fmt.Errorf("%w: unexpected number of lines", ErrMalformed)
+	}
+
+	domain, ok := strings.CutSuffix(lines[0], header)
+	if !ok || domain == "" || strings.ContainsAny(domain, " \t") {
+		return Message{}, fmt.Errorf("%w: invalid header", ErrMalformed)
+	}
+	m.Domain = domain
+
+	m.Address = lines[1]
+	if key, err := base58.Decode(m.Address); err != nil || len(key) != ed25519.PublicKeySize {
+		return Message{}, fmt.Errorf("%w: invalid address", ErrMalformed)
+	}
+
+	if lines[2] != "" {
+		return Message{}, fmt.Errorf("%w: missing blank line after address", ErrMalformed)
+	}
+
+	fields := lines[3:]
+	if len(lines) == 11 {
+		if lines[3] == "" || lines[4] != "" {
+			return Message{}, fmt.Errorf("%w: invalid statement", ErrMalformed)
+		}
+		m.Statement, fields = lines[3], lines[5:]
+	}
+
+	values := make([]string, 0, 6)
+	for i, tag := range []string{uriTag, versionTag, chainIDTag, nonceTag, issuedAtTag, expirationTag} {
+		value, ok := strings.CutPrefix(fields[i], tag)
+		if !ok || value == "" {
+			return Message{}, fmt.Errorf("%w: expected %q field", ErrMalformed, strings.TrimSuffix(tag, ": "))
+		}
+		values = append(values, value)
+	}
+	m.URI, m.Version, m.ChainID, m.Nonce = values[0], values[1], values[2], values[3]
+
+	if m.Version != Version {
+		return Message{}, fmt.Errorf("%w: unsupported version %q", ErrMalformed, m.Version)
+	}
+
+	if !alphanumeric(m.Nonce) || len(m.Nonce) < minNonceLength {
+		return Message{}, fmt.Errorf("%w: nonce must be at least %d alphanumeric characters", ErrMalformed, minNonceLength)
+	}
+
+	var err error
+	if m.IssuedAt, err = time.Parse(time.RFC3339, values[4]); err != nil {
+		return Message{}, fmt.Errorf("%w: invalid issued-at time", ErrMalformed)
+	}
+
+	if m.ExpirationTime, err = time.Parse(time.RFC3339, values[5]); err != nil {
+		return Message{}, fmt.Errorf("%w: invalid expiration time", ErrMalformed)
+	}
+
+	if !m.ExpirationTime.After(m.IssuedAt) {
+		return Message{}, fmt.Errorf("%w: expiration time is not after issued-at time", ErrMalformed)
+	}
+
+	return m, nil
+}
+
+func alphanumeric(value string) bool {
+	for _, r := range value {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Issuer builds the sign-in messages of this service and checks that a signed
+// message was issued for it.
+type Issuer struct {
+	domain  string
+	uri     string
+	chainID string
+}
+
+func New(domain environment.SIWSDomain, uri environment.SIWSURI, chainID environment.SIWSChainID) *Issuer {
+	return &Issuer{domain: string(domain), uri: string(uri), chainID: string(chainID)}
+}
+
+func (i *Issuer) Message(address, statement, nonce string, issuedAt, expiresAt time.Time) Message {
+	return Message{
+		Domain:         i.domain,
+		Address:        address,
+		Statement:      statement,
+		URI:            i.uri,
+		Version:        Version,
+		ChainID:        i.chainID,
+		Nonce:          nonce,
+		IssuedAt:       issuedAt.UTC().Truncate(time.Second),
+		ExpirationTime: expiresAt.UTC().Truncate(time.Second),
+	}
+}
+
+// Validate checks that the message was issued by this service to the address
+// and is valid at now.
+func (i *Issuer) Validate(m Message, address string, now time.Time) error {
+	if m.Domain != i.domain {
+		return fmt.Errorf("%w: %s", ErrDomainMismatch, m.Domain)
+	}
+
+	if m.URI != i.uri {
+		return fmt.Errorf("%w: %s", ErrURIMismatch, m.URI)
+	}
+
+	if m.ChainID != i.chainID {
+		return fmt.Errorf("%w: %s", ErrChainMismatch, m.ChainID)
+	}
+
+	if m.Address != address {
+		return ErrAddressMismatch
+	}
+
+	if m.IssuedAt.After(now.Add(ClockSkew)) {
+		return ErrNotYetValid
+	}
+
+	if !now.Before(m.ExpirationTime) {
+		return ErrExpired
+	}
+
+	return nil
+}
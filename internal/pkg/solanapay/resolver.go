@@ -0,0 +1,800 @@
+package solanapay
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+	"twitch-crypto-donations/internal/pkg/amount"
+	"twitch-crypto-donations/internal/pkg/chain"
+	"twitch-crypto-donations/internal/pkg/donationrules"
+	"twitch-crypto-donations/internal/pkg/donations"
+	"twitch-crypto-donations/internal/pkg/environment"
+	"twitch-crypto-donations/internal/pkg/goals"
+	"twitch-crypto-donations/internal/pkg/obsservice"
+	"twitch-crypto-donations/internal/pkg/pricing"
+	"twitch-crypto-donations/internal/pkg/splits"
+	"twitch-crypto-donations/internal/pkg/subathon"
+	"twitch-crypto-donations/internal/pkg/txverifier"
+
+	"github.com/AlekSi/pointer"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+const (
+	StatusPending = "pending"
+	StatusSettled = "settled"
+	StatusExpired = "expired"
+)
+
+var (
+	ErrRequestNotPending = errors.New("payment request is not pending")
+	ErrSignatureUsed     = errors.New("transaction signature was already used")
+)
+
+type Database interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+	Exec(query string, args ...any) (sql.Result, error)
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+type RpcClient interface {
+	GetSignaturesForAddressWithOpts(ctx context.Context, account solana.PublicKey, opts *rpc.GetSignaturesForAddressOpts) ([]*rpc.TransactionSignature, error)
+}
+
+type ChainRegistry interface {
+	Verifier(id chain.ID) (chain.PaymentVerifier, error)
+	Asset(id chain.ID, symbol string) (chain.Asset, bool)
+}
+
+type ObsService interface {
+	WebhookAlert(wallet string, request obsservice.AlertEvent) (any, string, error)
+	WebhookMedia(wallet string, request obsservice.MediaEvent) (any, string, error)
+}
+
+type Logger interface {
+	Info(msg string, ctx ...interface{})
+}
+
+type Valuer interface {
+	Value(ctx context.Context, symbol string, units uint64, decimals uint8) *pricing.Valuation
+}
+
+type GoalTracker interface {
+	Contribute(db goals.Executor, contribution goals.Contribution) (*goals.Progress, error)
+	Notify(progress goals.Progress, username *string) error
+}
+
+type SubathonTimer interface {
+	Extend(db subathon.Executor, donation subathon.Donation) (*subathon.Extension, error)
+	Notify(owner string, timer *subathon.Timer, added int64, username *string) error
+}
+
+type RuleRegistry interface {
+	Allow(receiver, currency string, units uint64, decimals uint8, features donationrules.Features) (donationrules.Features, error)
+}
+
+type Resolver struct {
+	db         Database
+	rpcClient  RpcClient
+	chains     ChainRegistry
+	obsService ObsService
+	valuer     Valuer
+	goals      GoalTracker
+	timers     SubathonTimer
+	rules      RuleRegistry
+	logger     Logger
+	interval   time.Duration
+}
+
+type paymentRequest struct {
+	reference      string
+	donationID     *string
+	receiver       string
+	senderUsername string
+	amount         string
+	feeAmount      *string
+	currency       string
+	message        *string
+	durationMs     *int64
+	alertEvent     *alertOptions
+	mediaEvent     *mediaOptions
+	expiresAt      time.Time
+}
+
+type alertOptions struct {
+	Enable            bool    `json:"enable"`
+	NotificationSound *string `json:"notification_sound"`
+	VoiceUrl          *string `json:"voice_url"`
+	ImageUrl          *string `json:"image_url"`
+	GifUrl            *string `json:"gif_url"`
+}
+
+type mediaOptions struct {
+	Enable     bool   `json:"enable"`
+	YoutubeUrl string `json:"youtube_url"`
+	StartTime  *int64 `json:"start_time"`
+	EndTime    *int64 `json:"end_time"`
+	AutoPlay   *bool  `json:"auto_play"`
+	Controls   *bool  `json:"controls"`
+	Mute       *bool  `json:"mute"`
+}
+
+func NewResolver(
+	db Database,
+	rpcClient RpcClient,
+	chains ChainRegistry,
+	obsService ObsService,
+	valuer Valuer,
+	goals GoalTracker,
+	timers SubathonTimer,
+	rules RuleRegistry,
+	logger Logger,
+	interval environment.WatcherPollIntervalSeconds,
+) *Resolver {
+	return &Resolver{
+		db:         db,
+		rpcClient:  rpcClient,
+		chains:     chains,
+		obsService: obsService,
+		valuer:     valuer,
+		goals:      goals,
+		timers:     timers,
+		rules:      rules,
+		logger:     logger,
+		interval:   time.Duration(interval) * time.Second,
+	}
+}
+
+func (r *Resolver) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.Poll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Resolver) Poll(ctx context.Context) {
+	requests, err := r.pendingRequests()
+	if err != nil {
+		r.logger.Info("payment request resolver failed to load requests", "error", err.Error())
+		return
+	}
+
+	for _, request := range requests {
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err = r.resolve(ctx, request); err != nil {
+			r.logger.Info("payment request resolver failed to resolve request",
+				"reference", request.reference,
+				"error", err.Error(),
+			)
+		}
+	}
+}
+
+func (r *Resolver) resolve(ctx context.Context, request paymentRequest) error {
+	reference, err := solana.PublicKeyFromBase58(request.reference)
+	if err != nil {
+		return fmt.Errorf("invalid reference: %w", err)
+	}
+
+	signatures, err := r.rpcClient.GetSignaturesForAddressWithOpts(ctx, reference, &rpc.GetSignaturesForAddressOpts{
+		Commitment: rpc.CommitmentConfirmed,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to fetch signatures: %w", err)
+	}
+
+	slices.Reverse(signatures)
+
+	for _, signature := range signatures {
+		if signature.Err != nil {
+			continue
+		}
+
+		result, err := r.verify(ctx, request, signature.Signature.String())
+
+		if mismatch, ok := chain.Explain(err); ok {
+			r.logger.Info("payment request transaction does not match",
+				"reference", request.reference,
+				"signature", signature.Signature.String(),
+				"reason", mismatch.Reason,
+			)
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		err = r.settle(ctx, request, signature.Signature.String(), result, false)
+		if errors.Is(err, ErrRequestNotPending) {
+			return nil
+		}
+
+		return err
+	}
+
+	if time.Now().UTC().After(request.expiresAt) {
+		return r.expire(ctx, request)
+	}
+
+	return nil
+}
+
+// Confirm settles the pending payment request of a donation with a transaction
+// the donor submitted for confirmation directly. The transaction must already
+// be verified against the donation's legs and memo.
+func (r *Resolver) Confirm(ctx context.Context, donationID, signature string, result *chain.Result) error {
+	request, err := r.donationRequest(donationID)
+	if err != nil {
+		return err
+	}
+
+	return r.settle(ctx, *request, signature, result, true)
+}
+
+func (r *Resolver) verify(ctx context.Context, request paymentRequest, signature string) (*chain.Result, error) {
+	verifier, err := r.chains.Verifier(chain.Solana)
+	if err != nil {
+		return nil, err
+	}
+
+	asset, ok := r.chains.Asset(chain.Solana, request.currency)
+	if !ok {
+		return nil, fmt.Errorf("unsupported currency %s", request.currency)
+	}
+
+	legs, err := r.legs(request, asset)
+	if err != nil {
+		return nil, err
+	}
+
+	transfers := make([]chain.Transfer, 0, len(legs))
+	for _, leg := range legs {
+		transfers = append(transfers, chain.Transfer{
+			Recipient: leg.Recipient,
+			Token:     asset.Token,
+			Amount:    leg.Amount,
+		})
+	}
+
+	return verifier.Verify(ctx, signature, transfers)
+}
+
+// legs returns the transfers recorded for the request's donation, falling back
+// to a single streamer transfer net of fee for requests without recorded legs.
+func (r *Resolver) legs(request paymentRequest, asset chain.Asset) ([]splits.Leg, error) {
+	if request.donationID != nil {
+		legs, err := splits.Load(r.db, *request.donationID)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(legs) > 0 {
+			return legs, nil
+		}
+	}
+
+	units, err := amount.Parse(request.amount, asset.Decimals)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount %q: %w", request.amount, err)
+	}
+
+	if request.feeAmount != nil {
+		fee, err := amount.Parse(*request.feeAmount, asset.Decimals)
+		if err != nil || fee > units {
+			return nil, fmt.Errorf("invalid fee amount %q", *request.feeAmount)
+		}
+		units -= fee
+	}
+
+	return []splits.Leg{{Recipient: request.receiver, Kind: splits.KindStreamer, Amount: units}}, nil
+}
+
+// settle claims the signature and confirms the request's donation in a single
+// transaction. A signature already claimed by another donation expires the
+// donation when found by reference, but is rejected when the donor submitted it
+// directly, since anyone could submit a signature they do not own.
+func (r *Resolver) settle(ctx context.Context, request paymentRequest, signature string, result *chain.Result, direct bool) error {
+	if request.message == nil {
+		ignore := []string{TransferMemo}
+		if request.donationID != nil {
+			ignore = append(ignore, *request.donationID)
+		}
+		request.message = txverifier.Message(result.Memos, ignore...)
+	}
+
+	request, err := r.restrict(request)
+	if err != nil {
+		return err
+	}
+
+	valuation := r.value(ctx, request)
+
+	var (
+		goal      *goals.Progress
+		extension *subathon.Extension
+	)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Locking the pending request first serializes the resolver with a direct
+	// confirmation of the same donation.
+	const lockQuery = `SELECT reference FROM payment_requests WHERE reference = $1 AND status = $2 FOR UPDATE;`
+
+	var locked string
+	err = tx.QueryRow(lockQuery, request.reference, StatusPending).Scan(&locked)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrRequestNotPending
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to lock payment request: %w", err)
+	}
+
+	const claimQuery = `
+		INSERT INTO used_signatures (signature, sender_address, receiver)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (signature) DO NOTHING;
+	`
+
+	claim, err := tx.Exec(claimQuery, signature, result.Sender, request.receiver)
+	if err != nil {
+		return fmt.Errorf("failed to claim transaction signature: %w", err)
+	}
+
+	affected, err := claim.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to claim transaction signature: %w", err)
+	}
+
+	if affected == 0 {
+		if direct {
+			return ErrSignatureUsed
+		}
+
+		return r.discard(tx, request)
+	}
+
+	const updateQuery = `
+		UPDATE payment_requests
+		SET status = $2, tx_signature = $3, settled_at = NOW()
+		WHERE reference = $1;
+	`
+
+	if _, err = tx.Exec(updateQuery, request.reference, StatusSettled, signature); err != nil {
+		return fmt.Errorf("failed to update payment request: %w", err)
+	}
+
+	if err = r.saveDonation(tx, request, signature, result); err != nil {
+		return err
+	}
+
+	if request.donationID != nil {
+		if err = donations.AttachPayment(tx, *request.donationID, signature, result); err != nil {
+			return err
+		}
+
+		seen := "transaction found by reference"
+		if direct {
+			seen = "transaction confirmed by donor"
+		}
+
+		if err = donations.Transition(tx, *request.donationID, donations.StatePaymentSeen, seen); err != nil {
+			return err
+		}
+
+		err = donations.Transition(tx, *request.donationID, donations.StateConfirmed, "transfer verified at confirmed commitment")
+		if err != nil {
+			return err
+		}
+
+		if valuation != nil {
+			if err = donations.AttachValuation(tx, *request.donationID, *valuation); err != nil {
+				return err
+			}
+		}
+
+		if goal, err = r.contribute(tx, request, valuation); err != nil {
+			return err
+		}
+
+		if extension, err = r.extend(tx, request); err != nil {
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	state, reason := donations.StateAlertDelivered, "overlay events delivered"
+	if err = r.sendEvents(request); err != nil {
+		state, reason = donations.StateAlertFailed, err.Error()
+	}
+
+	if goal != nil {
+		if err = r.goals.Notify(*goal, &request.senderUsername); err != nil {
+			r.logger.Info("payment request resolver failed to send goal events", "reference", request.reference, "error", err.Error())
+		}
+	}
+
+	if extension != nil {
+		err = r.timers.Notify(request.receiver, &extension.Timer, extension.Seconds, &request.senderUsername)
+		if err != nil {
+			r.logger.Info("payment request resolver failed to send timer update", "reference", request.reference, "error", err.Error())
+		}
+	}
+
+	if request.donationID != nil {
+		return donations.Transition(r.db, *request.donationID, state, reason)
+	}
+
+	return nil
+}
+
+// restrict drops the overlay features the paid amount does not cover under
+// the receiver's donation rules. Rules may have changed since the request was
+// created, and a paid request can no longer be rejected.
+func (r *Resolver) restrict(request paymentRequest) (paymentRequest, error) {
+	asset, ok := r.chains.Asset(chain.Solana, request.currency)
+	if !ok {
+		return request, fmt.Errorf("unsupported currency %s", request.currency)
+	}
+
+	units, err := amount.Parse(request.amount, asset.Decimals)
+	if err != nil {
+		return request, fmt.Errorf("invalid amount %q: %w", request.amount, err)
+	}
+
+	media := request.mediaEvent != nil && request.mediaEvent.Enable
+	requested := donationrules.Features{
+		Media: media,
+		Alert: request.alertEvent == nil && !media || request.alertEvent != nil && request.alertEvent.Enable,
+	}
+	requested.Voice = requested.Alert && request.alertEvent != nil && request.alertEvent.VoiceUrl != nil
+
+	if media {
+		requested.MediaSeconds = donationrules.MediaSeconds(request.mediaEvent.StartTime, request.mediaEvent.EndTime, request.durationMs)
+	}
+
+	allowed, err := r.rules.Allow(request.receiver, asset.Symbol, units, asset.Decimals, requested)
+	if err != nil {
+		return request, err
+	}
+
+	if requested.Media && !allowed.Media {
+		request.mediaEvent = nil
+		if request.alertEvent == nil {
+			request.alertEvent = &alertOptions{Enable: false}
+		}
+	}
+
+	if requested.Alert && !allowed.Alert {
+		request.alertEvent = &alertOptions{Enable: false}
+	} else if requested.Voice && !allowed.Voice {
+		alert := *request.alertEvent
+		alert.VoiceUrl = nil
+		request.alertEvent = &alert
+	}
+
+	return request, nil
+}
+
+// discard expires a request whose transaction was already claimed by another
+// donation. The foreign signature is kept off the request and its donation, so
+// lookups by signature only find the donation that owns it.
+func (r *Resolver) discard(tx *sql.Tx, request paymentRequest) error {
+	const updateQuery = `UPDATE payment_requests SET status = $2 WHERE reference = $1;`
+
+	if _, err := tx.Exec(updateQuery, request.reference, StatusExpired); err != nil {
+		return fmt.Errorf("failed to expire payment request: %w", err)
+	}
+
+	if request.donationID != nil {
+		err := donations.Transition(tx, *request.donationID, donations.StateExpired, "transaction signature was already used by another donation")
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Resolver) value(ctx context.Context, request paymentRequest) *pricing.Valuation {
+	if request.donationID == nil {
+		return nil
+	}
+
+	asset, ok := r.chains.Asset(chain.Solana, request.currency)
+	if !ok {
+		return nil
+	}
+
+	units, err := amount.Parse(request.amount, asset.Decimals)
+	if err != nil {
+		return nil
+	}
+
+	return r.valuer.Value(ctx, asset.Symbol, units, asset.Decimals)
+}
+
+// contribute counts the request's donation towards the receiver's active goal.
+func (r *Resolver) contribute(tx *sql.Tx, request paymentRequest, valuation *pricing.Valuation) (*goals.Progress, error) {
+	asset, ok := r.chains.Asset(chain.Solana, request.currency)
+	if !ok {
+		return nil, fmt.Errorf("unsupported currency %s", request.currency)
+	}
+
+	units, err := amount.Parse(request.amount, asset.Decimals)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount %q: %w", request.amount, err)
+	}
+
+	return r.goals.Contribute(tx, goals.Contribution{
+		DonationID: *request.donationID,
+		Receiver:   request.receiver,
+		Currency:   asset.Symbol,
+		Units:      units,
+		Decimals:   asset.Decimals,
+		Valuation:  valuation,
+	})
+}
+
+func (r *Resolver) saveDonation(tx *sql.Tx, request paymentRequest, signature string, result *chain.Result) error {
+	asset, ok := r.chains.Asset(chain.Solana, request.currency)
+	if !ok {
+		return fmt.Errorf("unsupported currency %s", request.currency)
+	}
+
+	units, err := amount.Parse(request.amount, asset.Decimals)
+	if err != nil {
+		return fmt.Errorf("invalid amount %q: %w", request.amount, err)
+	}
+
+	layout := "alert"
+	if request.mediaEvent != nil && request.mediaEvent.Enable {
+		layout = "media"
+	}
+
+	var audioURL, imageURL *string
+	if request.alertEvent != nil {
+		audioURL = request.alertEvent.VoiceUrl
+		if request.alertEvent.ImageUrl != nil {
+			imageURL = request.alertEvent.ImageUrl
+		} else {
+			imageURL = request.alertEvent.GifUrl
+		}
+	}
+
+	var durationMs *float64
+	if request.durationMs != nil {
+		durationMs = pointer.ToFloat64(float64(*request.durationMs))
+	}
+
+	const insertQuery = `
+		INSERT INTO donations_history
+		(receiver, amount, decimals, sender_username, currency, text, audio_url, image_url, duration_ms, layout, channel, sender_address, tx_signature, donation_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, (SELECT channel FROM users WHERE wallet = $1), $11, $12, $13);
+	`
+
+	_, err = tx.Exec(insertQuery,
+		request.receiver, strconv.FormatUint(units, 10), asset.Decimals,
+		request.senderUsername, request.currency, request.message,
+		audioURL, imageURL, durationMs,
+		layout,
+		result.Sender, signature,
+		request.donationID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save donation history: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Resolver) sendEvents(request paymentRequest) error {
+	value, err := strconv.ParseFloat(request.amount, 64)
+	if err != nil {
+		return fmt.Errorf("failed to format amount: %w", err)
+	}
+
+	errs := make([]error, 0, 2)
+	if request.mediaEvent != nil && request.mediaEvent.Enable {
+		_, _, err = r.obsService.WebhookMedia(request.receiver, obsservice.MediaEvent{
+			Username:   pointer.ToString(request.senderUsername),
+			Amount:     pointer.ToFloat64(value),
+			Currency:   pointer.ToString(request.currency),
+			Message:    request.message,
+			DurationMs: request.durationMs,
+			YoutubeUrl: request.mediaEvent.YoutubeUrl,
+			StartTime:  request.mediaEvent.StartTime,
+			EndTime:    request.mediaEvent.EndTime,
+			AutoPlay:   request.mediaEvent.AutoPlay,
+			Controls:   request.mediaEvent.Controls,
+			Mute:       request.mediaEvent.Mute,
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to send media: %w", err))
+		}
+	}
+
+	if request.alertEvent == nil && request.mediaEvent != nil && request.mediaEvent.Enable {
+		return errors.Join(errs...)
+	}
+
+	if request.alertEvent != nil && !request.alertEvent.Enable {
+		return errors.Join(errs...)
+	}
+
+	alert := obsservice.AlertEvent{
+		Username:   pointer.ToString(request.senderUsername),
+		Amount:     pointer.ToFloat64(value),
+		Currency:   pointer.ToString(request.currency),
+		Message:    request.message,
+		DurationMs: request.durationMs,
+	}
+
+	if request.alertEvent != nil {
+		alert.NotificationSound = request.alertEvent.NotificationSound
+		alert.VoiceUrl = request.alertEvent.VoiceUrl
+		alert.ImageUrl = request.alertEvent.ImageUrl
+		alert.GifUrl = request.alertEvent.GifUrl
+	}
+
+	if _, _, err = r.obsService.WebhookAlert(request.receiver, alert); err != nil {
+		errs = append(errs, fmt.Errorf("failed to send alert: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (r *Resolver) expire(ctx context.Context, request paymentRequest) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	const updateQuery = `UPDATE payment_requests SET status = $2 WHERE reference = $1 AND status = $3;`
+
+	if _, err = tx.Exec(updateQuery, request.reference, StatusExpired, StatusPending); err != nil {
+		return fmt.Errorf("failed to expire payment request: %w", err)
+	}
+
+	if request.donationID != nil {
+		if err = donations.Transition(tx, *request.donationID, donations.StateExpired, "payment request expired unpaid"); err != nil {
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+const requestColumns = `reference, donation_id, receiver, sender_username, amount, fee_amount, currency, message, duration_ms, alert_event, media_event, expires_at`
+
+func (r *Resolver) pendingRequests() ([]paymentRequest, error) {
+	const query = `
+		SELECT ` + requestColumns + `
+		FROM payment_requests
+		WHERE status = $1
+		ORDER BY created_at;
+	`
+
+	rows, err := r.db.Query(query, StatusPending)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	requests := make([]paymentRequest, 0)
+	for rows.Next() {
+		request, err := scanRequest(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		requests = append(requests, *request)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return requests, nil
+}
+
+func (r *Resolver) donationRequest(donationID string) (*paymentRequest, error) {
+	const query = `
+		SELECT ` + requestColumns + `
+		FROM payment_requests
+		WHERE donation_id = $1 AND status = $2;
+	`
+
+	request, err := scanRequest(r.db.QueryRow(query, donationID, StatusPending))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRequestNotPending
+	}
+
+	return request, err
+}
+
+func scanRequest(row interface{ Scan(dest ...any) error }) (*paymentRequest, error) {
+	var (
+		request                paymentRequest
+		alertEvent, mediaEvent []byte
+	)
+
+	err := row.Scan(
+		&request.reference, &request.donationID, &request.receiver, &request.senderUsername,
+		&request.amount, &request.feeAmount, &request.currency, &request.message, &request.durationMs,
+		&alertEvent, &mediaEvent, &request.expiresAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan row: %w", err)
+	}
+
+	if alertEvent != nil {
+		if err = json.Unmarshal(alertEvent, &request.alertEvent); err != nil {
+			return nil, fmt.Errorf("invalid alert options for %s: %w", request.reference, err)
+		}
+	}
+
+	if mediaEvent != nil {
+		if err = json.Unmarshal(mediaEvent, &request.mediaEvent); err != nil {
+			return nil, fmt.Errorf("invalid media options for %s: %w", request.reference, err)
+		}
+	}
+
+	return &request, nil
+}
+
+// extend adds the request's donation time to the receiver's subathon timer.
+func (r *Resolver) extend(tx *sql.Tx, request paymentRequest) (*subathon.Extension, error) {
+	asset, ok := r.chains.Asset(chain.Solana, request.currency)
+	if !ok {
+		return nil, fmt.Errorf("unsupported currency %s", request.currency)
+	}
+
+	units, err := amount.Parse(request.amount, asset.Decimals)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount %q: %w", request.amount, err)
+	}
+
+	return r.timers.Extend(tx, subathon.Donation{
+		DonationID: *request.donationID,
+		Receiver:   request.receiver,
+		Currency:   asset.Symbol,
+		Units:      units,
+		Decimals:   asset.Decimals,
+	})
+}
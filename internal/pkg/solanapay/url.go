@@ -0,0 +1,43 @@
+package solanapay
+
+import (
+	"net/url"
+	"strings"
+)
+
+// TransferMemo is the memo attached to transfer requests. Wallets write it
+// on-chain as is, so it is not donation text.
+const TransferMemo = "KapachiPay donation"
+
+type TransferRequest struct {
+	Recipient string
+	Amount    string
+	SplToken  string
+	Reference string
+	Label     string
+	Message   string
+	Memo      string
+}
+
+func (r TransferRequest) URL() string {
+	params := make([]string, 0, 6)
+	add := func(key, value string) {
+		if value != "" {
+			params = append(params, key+"="+strings.ReplaceAll(url.QueryEscape(value), "+", "%20"))
+		}
+	}
+
+	add("amount", r.Amount)
+	add("spl-token", r.SplToken)
+	add("reference", r.Reference)
+	add("label", r.Label)
+	add("message", r.Message)
+	add("memo", r.Memo)
+
+	link := "solana:" + r.Recipient
+	if len(params) > 0 {
+		link += "?" + strings.Join(params, "&")
+	}
+
+	return link
+}
@@ -0,0 +1,236 @@
+package splits
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"twitch-crypto-donations/internal/pkg/environment"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+type Kind string
+
+const (
+	KindStreamer    Kind = "streamer"
+	KindSplit       Kind = "split"
+	KindPlatformFee Kind = "platform_fee"
+)
+
+const MaxBps = 10000
+
+var ErrInvalidSplit = errors.New("invalid revenue split")
+
+type Database interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+type Executor interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// Share is a streamer's standing arrangement to forward part of every donation
+// to another wallet, in basis points of the amount left after the platform fee.
+type Share struct {
+	Recipient string
+	Bps       uint64
+	Label     string
+}
+
+type Leg struct {
+	Recipient string
+	Kind      Kind
+	Label     string
+	Amount    uint64
+}
+
+type Policy struct {
+	db        Database
+	feeBps    uint64
+	feeWallet string
+}
+
+func New(db Database, feeBps environment.PlatformFeeBps, feeWallet environment.PlatformFeeWallet) (*Policy, error) {
+	if feeBps < 0 || feeBps > MaxBps {
+		return nil, fmt.Errorf("invalid platform fee of %d basis points", feeBps)
+	}
+
+	if feeWallet != "" {
+		if _, err := solana.PublicKeyFromBase58(string(feeWallet)); err != nil {
+			return nil, fmt.Errorf("invalid platform fee wallet: %w", err)
+		}
+	}
+
+	policy := &Policy{db: db, feeWallet: string(feeWallet)}
+	if feeWallet != "" {
+		policy.feeBps = uint64(feeBps)
+	}
+
+	return policy, nil
+}
+
+func (p *Policy) FeeBps() uint64 {
+	return p.feeBps
+}
+
+func (p *Policy) FeeWallet() string {
+	return p.feeWallet
+}
+
+// Plan splits a gross donation to receiver into the legs the transaction must
+// contain: the streamer first, then every configured share, then the platform fee.
+func (p *Policy) Plan(receiver string, gross uint64) ([]Leg, error) {
+	shares, err := p.Shares(receiver)
+	if err != nil {
+		return nil, err
+	}
+
+	return Split(receiver, gross, shares, p.feeBps, p.feeWallet)
+}
+
+func (p *Policy) Shares(receiver string) ([]Share, error) {
+	const query = `
+		SELECT recipient, bps, label
+		FROM revenue_splits
+		WHERE receiver = $1
+		ORDER BY id;
+	`
+
+	rows, err := p.db.Query(query, receiver)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	shares := make([]Share, 0)
+	for rows.Next() {
+		var s Share
+		if err = rows.Scan(&s.Recipient, &s.Bps, &s.Label); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		shares = append(shares, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return shares, nil
+}
+
+func Split(receiver string, gross uint64, shares []Share, feeBps uint64, feeWallet string) ([]Leg, error) {
+	var fee uint64
+	if feeWallet != "" {
+		fee = Proportion(gross, feeBps)
+	}
+
+	net := gross - fee
+	streamer := net
+
+	legs := []Leg{{Recipient: receiver, Kind: KindStreamer}}
+	for _, share := range shares {
+		value := Proportion(net, share.Bps)
+		if value > streamer {
+			return nil, fmt.Errorf("%w: shares of %s exceed the donation", ErrInvalidSplit, receiver)
+		}
+
+		streamer -= value
+		if value > 0 {
+			legs = append(legs, Leg{Recipient: share.Recipient, Kind: KindSplit, Label: share.Label, Amount: value})
+		}
+	}
+
+	if fee > 0 {
+		legs = append(legs, Leg{Recipient: feeWallet, Kind: KindPlatformFee, Label: "platform fee", Amount: fee})
+	}
+
+	if streamer == 0 {
+		return nil, fmt.Errorf("%w: amount does not cover the platform fee and revenue splits", ErrInvalidSplit)
+	}
+
+	legs[0].Amount = streamer
+
+	return legs, nil
+}
+
+func Gross(legs []Leg) uint64 {
+	var total uint64
+	for _, leg := range legs {
+		total += leg.Amount
+	}
+
+	return total
+}
+
+func Fee(legs []Leg) uint64 {
+	var total uint64
+	for _, leg := range legs {
+		if leg.Kind == KindPlatformFee {
+			total += leg.Amount
+		}
+	}
+
+	return total
+}
+
+func Save(db Executor, donationID string, legs []Leg) error {
+	const insertQuery = `
+		INSERT INTO donation_legs (donation_id, recipient, kind, label, amount)
+		VALUES ($1, $2, $3, $4, $5);
+	`
+
+	for _, leg := range legs {
+		_, err := db.Exec(insertQuery, donationID, leg.Recipient, string(leg.Kind), leg.Label, strconv.FormatUint(leg.Amount, 10))
+		if err != nil {
+			return fmt.Errorf("failed to save donation leg: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func Load(db Database, donationID string) ([]Leg, error) {
+	const query = `
+		SELECT recipient, kind, label, amount
+		FROM donation_legs
+		WHERE donation_id = $1
+		ORDER BY id;
+	`
+
+	rows, err := db.Query(query, donationID)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	legs := make([]Leg, 0, 2)
+	for rows.Next() {
+		var (
+			leg   Leg
+			value string
+		)
+		if err = rows.Scan(&leg.Recipient, &leg.Kind, &leg.Label, &value); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		if leg.Amount, err = strconv.ParseUint(value, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid leg amount %q: %w", value, err)
+		}
+
+		legs = append(legs, leg)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return legs, nil
+}
+
+func Proportion(value, bps uint64) uint64 {
+	hi, lo := bits.Mul64(value, bps)
+	result, _ := bits.Div64(hi, lo, MaxBps)
+	return result
+}
@@ -0,0 +1,316 @@
+package subathon
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+	"twitch-crypto-donations/internal/pkg/amount"
+	"twitch-crypto-donations/internal/pkg/obsservice"
+)
+
+var ErrTimerNotFound = errors.New("subathon timer not found")
+
+type Executor interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+type ObsService interface {
+	WebhookTimer(wallet string, request obsservice.TimerEvent) (any, string, error)
+}
+
+// Rate is the time a whole unit of a currency adds to the timer. Amounts
+// below a unit add their share, rounded down to whole seconds.
+type Rate struct {
+	Currency       string
+	SecondsPerUnit string
+}
+
+// Timer is a streamer's subathon countdown. A running timer ends at EndsAt; a
+// paused one keeps PausedRemaining seconds until it is resumed. Time added by
+// donations stops once InitialSeconds and AddedSeconds reach CapSeconds.
+type Timer struct {
+	ID              string
+	Owner           string
+	InitialSeconds  int64
+	AddedSeconds    int64
+	CapSeconds      *int64
+	EndsAt          *time.Time
+	PausedRemaining *int64
+	StartedAt       time.Time
+	Rates           []Rate
+}
+
+func (t Timer) Paused() bool {
+	return t.EndsAt == nil
+}
+
+// Remaining is the countdown left at now, in whole seconds.
+func (t Timer) Remaining(now time.Time) int64 {
+	if t.EndsAt == nil {
+		return *t.PausedRemaining
+	}
+
+	remaining := t.EndsAt.Sub(now)
+	if remaining <= 0 {
+		return 0
+	}
+
+	return int64(math.Ceil(remaining.Seconds()))
+}
+
+// Donation is a confirmed donation offered to the receiver's timer.
+type Donation struct {
+	DonationID string
+	Receiver   string
+	Currency   string
+	Units      uint64
+	Decimals   uint8
+}
+
+// Extension is the timer after a donation added Seconds to it.
+type Extension struct {
+	Timer   Timer
+	Seconds int64
+}
+
+type Tracker struct {
+	obsService ObsService
+}
+
+func New(obsService ObsService) *Tracker {
+	return &Tracker{obsService: obsService}
+}
+
+// Extend adds the donation's time to the receiver's running or paused timer.
+// Each donation is counted once per timer, so replaying a confirmation after a
+// restart adds nothing. It returns nil when there is no timer, the timer has
+// run out, the currency has no rate or the cap is reached.
+func (t *Tracker) Extend(db Executor, donation Donation) (*Extension, error) {
+	const timerQuery = `
+		SELECT t.id, t.initial_seconds, t.added_seconds, t.cap_seconds, r.seconds_per_unit
+		FROM subathon_timers t
+		JOIN subathon_rates r ON r.timer_id = t.id AND r.currency = $2
+		WHERE t.owner = $1 AND (t.ends_at IS NULL OR t.ends_at > NOW())
+		FOR UPDATE OF t;
+	`
+
+	var (
+		timerID        string
+		initial, added int64
+		capSeconds     *int64
+		rate           string
+	)
+
+	err := db.QueryRow(timerQuery, donation.Receiver, donation.Currency).Scan(&timerID, &initial, &added, &capSeconds, &rate)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to load subathon timer: %w", err)
+	}
+
+	seconds := Seconds(donation.Units, donation.Decimals, rate)
+	if capSeconds != nil {
+		seconds = min(seconds, *capSeconds-initial-added)
+	}
+
+	if seconds <= 0 {
+		return nil, nil
+	}
+
+	const insertQuery = `
+		INSERT INTO subathon_extensions (timer_id, donation_id, seconds)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (timer_id, donation_id) DO NOTHING;
+	`
+
+	result, err := db.Exec(insertQuery, timerID, donation.DonationID, seconds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save subathon extension: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to save subathon extension: %w", err)
+	}
+
+	if affected == 0 {
+		return nil, nil
+	}
+
+	// Only one of ends_at and paused_remaining is set; the other stays NULL.
+	const updateQuery = `
+		UPDATE subathon_timers
+		SET added_seconds = added_seconds + $2::BIGINT,
+		    ends_at = ends_at + $2::BIGINT * INTERVAL '1 second',
+		    paused_remaining = paused_remaining + $2::BIGINT,
+		    updated_at = NOW()
+		WHERE id = $1;
+	`
+
+	if _, err = db.Exec(updateQuery, timerID, seconds); err != nil {
+		return nil, fmt.Errorf("failed to extend subathon timer: %w", err)
+	}
+
+	timer, err := Find(db, donation.Receiver)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Extension{Timer: *timer, Seconds: seconds}, nil
+}
+
+// Revert takes the time a reverted donation added back off its timer. Seconds
+// of the returned extension are negative. It returns nil when the donation did
+// not extend a timer.
+func (t *Tracker) Revert(db Executor, donationID string) (*Extension, error) {
+	const deleteQuery = `
+		DELETE FROM subathon_extensions
+		WHERE donation_id = $1
+		RETURNING timer_id, seconds;
+	`
+
+	var (
+		timerID string
+		seconds int64
+	)
+
+	err := db.QueryRow(deleteQuery, donationID).Scan(&timerID, &seconds)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove subathon extension: %w", err)
+	}
+
+	// A paused timer cannot go below zero; a running one may end right away.
+	const updateQuery = `
+		UPDATE subathon_timers
+		SET added_seconds = GREATEST(added_seconds - $2::BIGINT, 0),
+		    ends_at = ends_at - $2::BIGINT * INTERVAL '1 second',
+		    paused_remaining = GREATEST(paused_remaining - $2::BIGINT, 0),
+		    updated_at = NOW()
+		WHERE id = $1
+		RETURNING owner;
+	`
+
+	var owner string
+	if err = db.QueryRow(updateQuery, timerID, seconds).Scan(&owner); err != nil {
+		return nil, fmt.Errorf("failed to shorten subathon timer: %w", err)
+	}
+
+	timer, err := Find(db, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Extension{Timer: *timer, Seconds: -seconds}, nil
+}
+
+// Notify pushes the timer state to the owner's overlay. A nil timer tells the
+// overlay the subathon was stopped.
+func (t *Tracker) Notify(owner string, timer *Timer, added int64, username *string) error {
+	event := obsservice.TimerEvent{
+		AddedSeconds: added,
+		Username:     username,
+	}
+
+	if timer != nil {
+		event.Active = true
+		event.Paused = timer.Paused()
+		event.EndsAt = timer.EndsAt
+		event.RemainingSeconds = timer.Remaining(time.Now())
+	}
+
+	if _, _, err := t.obsService.WebhookTimer(owner, event); err != nil {
+		return fmt.Errorf("failed to send timer update: %w", err)
+	}
+
+	return nil
+}
+
+// ValidRate reports whether secondsPerUnit is a positive decimal number.
+func ValidRate(secondsPerUnit string) bool {
+	rate, ok := new(big.Rat).SetString(secondsPerUnit)
+	return ok && rate.Sign() > 0
+}
+
+// Seconds is the time units of a currency add at secondsPerUnit, rounded down.
+func Seconds(units uint64, decimals uint8, secondsPerUnit string) int64 {
+	rate, ok := new(big.Rat).SetString(secondsPerUnit)
+	if !ok || rate.Sign() <= 0 {
+		return 0
+	}
+
+	value, ok := new(big.Rat).SetString(amount.Format(units, decimals))
+	if !ok {
+		return 0
+	}
+
+	seconds := new(big.Int).Quo(value.Mul(value, rate).Num(), value.Denom())
+	if !seconds.IsInt64() {
+		return math.MaxInt64
+	}
+
+	return seconds.Int64()
+}
+
+const selectTimer = `
+	SELECT t.id, t.owner, t.initial_seconds, t.added_seconds, t.cap_seconds,
+	       t.ends_at, t.paused_remaining, t.started_at
+	FROM subathon_timers t
+`
+
+// Find returns the owner's timer with its rates, or ErrTimerNotFound.
+func Find(db Executor, owner string) (*Timer, error) {
+	return find(db, selectTimer+` WHERE t.owner = $1;`, owner)
+}
+
+// FindByUsername returns the timer of the streamer with the given username, or
+// ErrTimerNotFound.
+func FindByUsername(db Executor, username string) (*Timer, error) {
+	return find(db, selectTimer+` JOIN users u ON u.wallet = t.owner WHERE u.username = $1;`, username)
+}
+
+func find(db Executor, query string, arg string) (*Timer, error) {
+	var timer Timer
+	err := db.QueryRow(query, arg).Scan(
+		&timer.ID, &timer.Owner, &timer.InitialSeconds, &timer.AddedSeconds, &timer.CapSeconds,
+		&timer.EndsAt, &timer.PausedRemaining, &timer.StartedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrTimerNotFound, arg)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to load subathon timer: %w", err)
+	}
+
+	rows, err := db.Query(`SELECT currency, seconds_per_unit FROM subathon_rates WHERE timer_id = $1 ORDER BY currency;`, timer.ID)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	timer.Rates = make([]Rate, 0, 2)
+	for rows.Next() {
+		var rate Rate
+		if err = rows.Scan(&rate.Currency, &rate.SecondsPerUnit); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		timer.Rates = append(timer.Rates, rate)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return &timer, nil
+}
@@ -0,0 +1,193 @@
+package txbuilder
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"twitch-crypto-donations/internal/pkg/txverifier"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+const (
+	systemTransferInstruction       = 2
+	tokenTransferCheckedInstruction = 12
+	associatedTokenCreateIdempotent = 1
+)
+
+type RpcClient interface {
+	GetLatestBlockhash(ctx context.Context, commitment rpc.CommitmentType) (*rpc.GetLatestBlockhashResult, error)
+	GetAccountInfo(ctx context.Context, account solana.PublicKey) (*rpc.GetAccountInfoResult, error)
+}
+
+type Leg struct {
+	Recipient solana.PublicKey
+	Amount    uint64
+}
+
+type Donation struct {
+	Sender    solana.PublicKey
+	Mint      solana.PublicKey
+	Decimals  uint8
+	Legs      []Leg
+	Memo      string
+	Reference solana.PublicKey
+}
+
+type Transaction struct {
+	Payload              string
+	Blockhash            string
+	LastValidBlockHeight uint64
+}
+
+type Builder struct {
+	rpcClient RpcClient
+}
+
+func New(rpcClient RpcClient) *Builder {
+	return &Builder{rpcClient: rpcClient}
+}
+
+func (b *Builder) Build(ctx context.Context, donation Donation) (*Transaction, error) {
+	legs := donation.Legs
+	if len(legs) == 0 {
+		return nil, fmt.Errorf("donation has no transfer legs")
+	}
+
+	instructions := make([]solana.Instruction, 0, 2*len(legs)+1)
+	if donation.Mint.IsZero() {
+		for i, leg := range legs {
+			instructions = append(instructions, systemTransfer(donation.Sender, leg, referenceFor(i, donation.Reference)))
+		}
+	} else {
+		tokenProgram, err := b.tokenProgram(ctx, donation.Mint)
+		if err != nil {
+			return nil, err
+		}
+
+		source, err := txverifier.AssociatedTokenAddress(donation.Sender, donation.Mint, tokenProgram)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive sender token account: %w", err)
+		}
+
+		for i, leg := range legs {
+			destination, err := txverifier.AssociatedTokenAddress(leg.Recipient, donation.Mint, tokenProgram)
+			if err != nil {
+				return nil, fmt.Errorf("failed to derive recipient token account: %w", err)
+			}
+
+			instructions = append(instructions,
+				createAssociatedTokenAccount(donation.Sender, destination, leg.Recipient, donation.Mint, tokenProgram),
+				tokenTransfer(tokenProgram, source, donation.Mint, destination, donation.Sender, leg.Amount, donation.Decimals, referenceFor(i, donation.Reference)),
+			)
+		}
+	}
+
+	if donation.Memo != "" {
+		instructions = append(instructions, solana.NewInstruction(
+			solana.MemoProgramID,
+			solana.AccountMetaSlice{solana.Meta(donation.Sender).SIGNER()},
+			[]byte(donation.Memo),
+		))
+	}
+
+	latest, err := b.rpcClient.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch recent blockhash: %w", err)
+	}
+
+	tx, err := solana.NewTransaction(instructions, latest.Value.Blockhash, solana.TransactionPayer(donation.Sender))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build transaction: %w", err)
+	}
+
+	payload, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize transaction: %w", err)
+	}
+
+	return &Transaction{
+		Payload:              base64.StdEncoding.EncodeToString(payload),
+		Blockhash:            latest.Value.Blockhash.String(),
+		LastValidBlockHeight: latest.Value.LastValidBlockHeight,
+	}, nil
+}
+
+func (b *Builder) tokenProgram(ctx context.Context, mint solana.PublicKey) (solana.PublicKey, error) {
+	account, err := b.rpcClient.GetAccountInfo(ctx, mint)
+	if err != nil {
+		return solana.PublicKey{}, fmt.Errorf("failed to fetch mint account %s: %w", mint, err)
+	}
+
+	owner := account.Value.Owner
+	if !owner.Equals(solana.TokenProgramID) && !owner.Equals(solana.Token2022ProgramID) {
+		return solana.PublicKey{}, fmt.Errorf("mint %s is not owned by a token program", mint)
+	}
+
+	return owner, nil
+}
+
+func referenceFor(leg int, reference solana.PublicKey) *solana.PublicKey {
+	if leg != 0 || reference.IsZero() {
+		return nil
+	}
+
+	return &reference
+}
+
+func systemTransfer(sender solana.PublicKey, leg Leg, reference *solana.PublicKey) solana.Instruction {
+	data := make([]byte, 12)
+	binary.LittleEndian.PutUint32(data[:4], systemTransferInstruction)
+	binary.LittleEndian.PutUint64(data[4:], leg.Amount)
+
+	accounts := solana.AccountMetaSlice{
+		solana.Meta(sender).WRITE().SIGNER(),
+		solana.Meta(leg.Recipient).WRITE(),
+	}
+	if reference != nil {
+		accounts = append(accounts, solana.Meta(*reference))
+	}
+
+	return solana.NewInstruction(solana.SystemProgramID, accounts, data)
+}
+
+func tokenTransfer(
+	program, source, mint, destination, owner solana.PublicKey,
+	amount uint64,
+	decimals uint8,
+	reference *solana.PublicKey,
+) solana.Instruction {
+	data := make([]byte, 10)
+	data[0] = tokenTransferCheckedInstruction
+	binary.LittleEndian.PutUint64(data[1:9], amount)
+	data[9] = decimals
+
+	accounts := solana.AccountMetaSlice{
+		solana.Meta(source).WRITE(),
+		solana.Meta(mint),
+		solana.Meta(destination).WRITE(),
+		solana.Meta(owner).SIGNER(),
+	}
+	if reference != nil {
+		accounts = append(accounts, solana.Meta(*reference))
+	}
+
+	return solana.NewInstruction(program, accounts, data)
+}
+
+func createAssociatedTokenAccount(payer, account, owner, mint, program solana.PublicKey) solana.Instruction {
+	return solana.NewInstruction(
+		solana.SPLAssociatedTokenAccountProgramID,
+		solana.AccountMetaSlice{
+			solana.Meta(payer).WRITE().SIGNER(),
+			solana.Meta(account).WRITE(),
+			solana.Meta(owner),
+			solana.Meta(mint),
+			solana.Meta(solana.SystemProgramID),
+			solana.Meta(program),
+		},
+		[]byte{associatedTokenCreateIdempotent},
+	)
+}
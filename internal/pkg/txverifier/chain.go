@@ -0,0 +1,103 @@
+package txverifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"twitch-crypto-donations/internal/pkg/chain"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+const blockhashSlots = 1500
+
+// Verify implements chain.PaymentVerifier for Solana.
+func (v *Verifier) Verify(ctx context.Context, reference string, expected []chain.Transfer) (*chain.Result, error) {
+	transfers := make([]Transfer, 0, len(expected))
+	for _, t := range expected {
+		transfers = append(transfers, Transfer{Sender: t.Sender, Recipient: t.Recipient, Mint: t.Token, Amount: t.Amount})
+	}
+
+	result, err := v.VerifyTransfers(ctx, reference, transfers)
+	if err != nil {
+		return nil, err
+	}
+
+	return &chain.Result{
+		Height:    result.Slot,
+		Blockhash: result.Blockhash,
+		Sender:    result.Sender,
+		Token:     result.Mint,
+		Amount:    result.Amount,
+		Memos:     result.Memos,
+	}, nil
+}
+
+// Status checks the transaction at finalized commitment first. A transaction
+// the cluster no longer knows even at confirmed commitment was on an abandoned fork.
+func (v *Verifier) Status(ctx context.Context, reference string) (chain.Status, error) {
+	sig, err := solana.SignatureFromBase58(reference)
+	if err != nil {
+		return "", fmt.Errorf("invalid signature format: %w", err)
+	}
+
+	tx, err := v.FetchTransactionAt(ctx, sig, rpc.CommitmentFinalized)
+	if err == nil {
+		if tx.Meta.Err != nil {
+			return chain.StatusFailed, nil
+		}
+		return chain.StatusFinalized, nil
+	}
+
+	if !errors.Is(err, rpc.ErrNotFound) {
+		return "", fmt.Errorf("failed to fetch finalized transaction: %w", err)
+	}
+
+	tx, err = v.FetchTransactionAt(ctx, sig, rpc.CommitmentConfirmed)
+	if errors.Is(err, rpc.ErrNotFound) {
+		return chain.StatusNotFound, nil
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch confirmed transaction: %w", err)
+	}
+
+	if tx.Meta.Err != nil {
+		return chain.StatusFailed, nil
+	}
+
+	return chain.StatusConfirmed, nil
+}
+
+// Expired reports a transaction as unable to land once the finalized bank no
+// longer accepts its blockhash; a blockhash from an abandoned fork is not
+// accepted either. Donations recorded without a blockhash fall back to the slot
+// they were seen at: a blockhash expires after 150 blocks, and blockhashSlots
+// is more slots than 150 blocks take at any realistic skip rate.
+func (v *Verifier) Expired(ctx context.Context, height uint64, blockhash string) (bool, error) {
+	if blockhash != "" {
+		hash, err := solana.HashFromBase58(blockhash)
+		if err != nil {
+			return false, fmt.Errorf("invalid blockhash: %w", err)
+		}
+
+		valid, err := v.rpcClient.IsBlockhashValid(ctx, hash, rpc.CommitmentFinalized)
+		if err != nil {
+			return false, fmt.Errorf("failed to check blockhash: %w", err)
+		}
+
+		return !valid.Value, nil
+	}
+
+	slot, err := v.rpcClient.GetSlot(ctx, rpc.CommitmentFinalized)
+	if err != nil {
+		return false, fmt.Errorf("failed to fetch finalized slot: %w", err)
+	}
+
+	return slot > height+blockhashSlots, nil
+}
+
+func (v *Verifier) Explain(err error) (*chain.Mismatch, bool) {
+	return chain.Explain(err)
+}
@@ -0,0 +1,127 @@
+package txverifier
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// Incoming is what one sender paid one recipient in one currency within a
+// transaction. Blockhash is the transaction's recent blockhash.
+type Incoming struct {
+	Sender    string
+	Recipient string
+	Mint      string
+	Amount    uint64
+	Memos     []string
+	Accounts  []string
+	Blockhash string
+}
+
+// IncomingTransfers sums the native and token transfers a transaction made to
+// any of recipients, by sender, recipient and mint. Transfers a recipient made
+// to itself are ignored.
+func (v *Verifier) IncomingTransfers(ctx context.Context, sig solana.Signature, recipients []string, mints []string) ([]Incoming, error) {
+	wallets := make(map[solana.PublicKey]bool, len(recipients))
+	for _, recipient := range recipients {
+		wallet, err := solana.PublicKeyFromBase58(recipient)
+		if err != nil {
+			return nil, fmt.Errorf("invalid recipient address: %w", err)
+		}
+		wallets[wallet] = true
+	}
+
+	tx, err := v.FetchTransaction(ctx, sig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch transaction %s: %w", sig, err)
+	}
+
+	if tx.Meta.Err != nil {
+		return nil, nil
+	}
+
+	message, err := decodeMessage(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	type key struct {
+		sender    solana.PublicKey
+		recipient solana.PublicKey
+		mint      string
+	}
+
+	totals := make(map[key]uint64)
+	order := make([]key, 0, 1)
+	add := func(k key, amount uint64) {
+		if _, exists := totals[k]; !exists {
+			order = append(order, k)
+		}
+		totals[k] += amount
+	}
+
+	for _, transfer := range systemTransfers(message, tx.Meta) {
+		if wallets[transfer.to] && !transfer.from.Equals(transfer.to) {
+			add(key{sender: transfer.from, recipient: transfer.to}, transfer.lamports)
+		}
+	}
+
+	type destination struct {
+		owner solana.PublicKey
+		mint  solana.PublicKey
+	}
+
+	destinations := make(map[solana.PublicKey]destination)
+	for _, mint := range mints {
+		mintKey, err := solana.PublicKeyFromBase58(mint)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mint address: %w", err)
+		}
+
+		for wallet := range wallets {
+			for _, program := range tokenPrograms {
+				account, err := AssociatedTokenAddress(wallet, mintKey, program)
+				if err != nil {
+					return nil, fmt.Errorf("failed to derive recipient token account: %w", err)
+				}
+				destinations[account] = destination{owner: wallet, mint: mintKey}
+			}
+		}
+	}
+
+	for _, transfer := range tokenTransfers(message, tx.Meta) {
+		to, ok := destinations[transfer.destination]
+		if !ok || transfer.authority.Equals(to.owner) {
+			continue
+		}
+
+		if !transfer.mint.IsZero() && !transfer.mint.Equals(to.mint) {
+			continue
+		}
+
+		add(key{sender: transfer.authority, recipient: to.owner, mint: to.mint.String()}, transfer.amount)
+	}
+
+	memos := memos(message, tx.Meta)
+
+	accounts := make([]string, 0, len(message.AccountKeys))
+	for _, account := range message.AccountKeys {
+		accounts = append(accounts, account.String())
+	}
+
+	incoming := make([]Incoming, 0, len(order))
+	for _, k := range order {
+		incoming = append(incoming, Incoming{
+			Sender:    k.sender.String(),
+			Recipient: k.recipient.String(),
+			Mint:      k.mint,
+			Amount:    totals[k],
+			Memos:     memos,
+			Accounts:  accounts,
+			Blockhash: message.RecentBlockhash.String(),
+		})
+	}
+
+	return incoming, nil
+}
@@ -0,0 +1,72 @@
+package txverifier
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// MaxMessageLength caps the donation text taken from a memo, in characters,
+// matching the message limit of the donation endpoints.
+const MaxMessageLength = 500
+
+var memoPrograms = []solana.PublicKey{
+	solana.MemoProgramID,
+	solana.MustPublicKeyFromBase58("Memo1UhkJRfHyvLMcVucJwxXeuD728EqVDDwQDxFMNo"),
+}
+
+func memos(message solana.Message, meta *rpc.TransactionMeta) []string {
+	instructions := allInstructions(message, meta)
+
+	out := make([]string, 0, 1)
+	for _, instruction := range instructions {
+		program, err := message.Program(instruction.ProgramIDIndex)
+		if err != nil {
+			continue
+		}
+
+		for _, memoProgram := range memoPrograms {
+			if program.Equals(memoProgram) {
+				out = append(out, string(instruction.Data))
+				break
+			}
+		}
+	}
+
+	return out
+}
+
+// Message returns the donation text carried by a transaction's memos: the first
+// memo that is valid UTF-8, not blank and not one of ignore, such as references
+// written by the app itself. Control characters are dropped and the text is cut
+// to MaxMessageLength characters.
+func Message(memos []string, ignore ...string) *string {
+	for _, memo := range memos {
+		if !utf8.ValidString(memo) || slices.Contains(ignore, memo) {
+			continue
+		}
+
+		text := strings.TrimSpace(strings.Map(func(r rune) rune {
+			if unicode.IsControl(r) && r != '\n' {
+				return -1
+			}
+			return r
+		}, memo))
+
+		if text == "" {
+			continue
+		}
+
+		if utf8.RuneCountInString(text) > MaxMessageLength {
+			text = strings.TrimSpace(string([]rune(text)[:MaxMessageLength]))
+		}
+
+		return &text
+	}
+
+	return nil
+}
@@ -0,0 +1,230 @@
+package txverifier
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+const (
+	tokenTransferInstruction        = 3
+	tokenTransferCheckedInstruction = 12
+)
+
+var tokenPrograms = []solana.PublicKey{solana.TokenProgramID, solana.Token2022ProgramID}
+
+type tokenTransfer struct {
+	program     solana.PublicKey
+	source      solana.PublicKey
+	destination solana.PublicKey
+	authority   solana.PublicKey
+	mint        solana.PublicKey
+	amount      uint64
+}
+
+func AssociatedTokenAddress(wallet, mint, tokenProgram solana.PublicKey) (solana.PublicKey, error) {
+	address, _, err := solana.FindProgramAddress(
+		[][]byte{wallet[:], tokenProgram[:], mint[:]},
+		solana.SPLAssociatedTokenAccountProgramID,
+	)
+	return address, err
+}
+
+func verifyTokenTransfer(
+	message solana.Message,
+	meta *rpc.TransactionMeta,
+	sender, recipient, mint solana.PublicKey,
+	expected uint64,
+) (uint64, solana.PublicKey, error) {
+	destinations := make(map[solana.PublicKey]solana.PublicKey, len(tokenPrograms))
+	sources := make(map[solana.PublicKey]solana.PublicKey, len(tokenPrograms))
+
+	for _, program := range tokenPrograms {
+		destination, err := AssociatedTokenAddress(recipient, mint, program)
+		if err != nil {
+			return 0, solana.PublicKey{}, fmt.Errorf("failed to derive recipient token account: %w", err)
+		}
+		destinations[program] = destination
+
+		if !sender.IsZero() {
+			source, err := AssociatedTokenAddress(sender, mint, program)
+			if err != nil {
+				return 0, solana.PublicKey{}, fmt.Errorf("failed to derive sender token account: %w", err)
+			}
+			sources[program] = source
+		}
+	}
+
+	var (
+		destinationIndex uint16
+		found            bool
+	)
+	for _, destination := range destinations {
+		if destinationIndex, found = accountIndex(message, destination); found {
+			break
+		}
+	}
+
+	if !found {
+		return 0, solana.PublicKey{}, &Mismatch{
+			Reason:   ReasonRecipientNotFound,
+			Message:  fmt.Sprintf("associated token account of %s for mint %s is not part of the transaction", recipient, mint),
+			Expected: expected,
+		}
+	}
+
+	var (
+		transferred uint64
+		payer       solana.PublicKey
+	)
+	for _, transfer := range tokenTransfers(message, meta) {
+		if !transfer.destination.Equals(destinations[transfer.program]) {
+			continue
+		}
+
+		if !transfer.mint.IsZero() && !transfer.mint.Equals(mint) {
+			continue
+		}
+
+		if !sender.IsZero() && !transfer.authority.Equals(sender) && !transfer.source.Equals(sources[transfer.program]) {
+			continue
+		}
+
+		if transferred == 0 {
+			payer = transfer.authority
+		}
+		transferred += transfer.amount
+	}
+
+	if transferred == 0 {
+		return 0, solana.PublicKey{}, &Mismatch{
+			Reason:   ReasonTransferNotFound,
+			Message:  fmt.Sprintf("no token transfer of mint %s to %s found in transaction", mint, recipient),
+			Expected: expected,
+		}
+	}
+
+	if transferred < expected {
+		return 0, solana.PublicKey{}, &Mismatch{
+			Reason:   ReasonInsufficientAmount,
+			Message:  fmt.Sprintf("transferred amount is lower than expected: expected %d base units, got %d", expected, transferred),
+			Expected: expected,
+			Actual:   transferred,
+		}
+	}
+
+	credited, err := tokenBalanceChange(meta, destinationIndex, mint)
+	if err != nil {
+		return 0, solana.PublicKey{}, err
+	}
+
+	if credited < int64(expected) {
+		return 0, solana.PublicKey{}, &Mismatch{
+			Reason:   ReasonBalanceMismatch,
+			Message:  fmt.Sprintf("recipient token balance increased by %d base units, expected at least %d", credited, expected),
+			Expected: expected,
+			Actual:   uint64(max(credited, 0)),
+		}
+	}
+
+	return transferred, payer, nil
+}
+
+func tokenTransfers(message solana.Message, meta *rpc.TransactionMeta) []tokenTransfer {
+	instructions := allInstructions(message, meta)
+
+	transfers := make([]tokenTransfer, 0, len(instructions))
+	for _, instruction := range instructions {
+		if transfer, ok := decodeTokenTransfer(message, instruction); ok {
+			transfers = append(transfers, transfer)
+		}
+	}
+
+	return transfers
+}
+
+func decodeTokenTransfer(message solana.Message, instruction solana.CompiledInstruction) (tokenTransfer, bool) {
+	program, err := message.Program(instruction.ProgramIDIndex)
+	if err != nil || !isTokenProgram(program) || len(instruction.Data) < 9 {
+		return tokenTransfer{}, false
+	}
+
+	var sourceIndex, mintIndex, destinationIndex, authorityIndex int
+	switch instruction.Data[0] {
+	case tokenTransferInstruction:
+		sourceIndex, mintIndex, destinationIndex, authorityIndex = 0, -1, 1, 2
+	case tokenTransferCheckedInstruction:
+		sourceIndex, mintIndex, destinationIndex, authorityIndex = 0, 1, 2, 3
+	default:
+		return tokenTransfer{}, false
+	}
+
+	if len(instruction.Accounts) <= authorityIndex {
+		return tokenTransfer{}, false
+	}
+
+	accounts := make([]solana.PublicKey, len(instruction.Accounts))
+	for i, index := range instruction.Accounts {
+		if accounts[i], err = message.Account(index); err != nil {
+			return tokenTransfer{}, false
+		}
+	}
+
+	transfer := tokenTransfer{
+		program:     program,
+		source:      accounts[sourceIndex],
+		destination: accounts[destinationIndex],
+		authority:   accounts[authorityIndex],
+		amount:      binary.LittleEndian.Uint64(instruction.Data[1:9]),
+	}
+
+	if mintIndex >= 0 {
+		transfer.mint = accounts[mintIndex]
+	}
+
+	return transfer, true
+}
+
+func isTokenProgram(program solana.PublicKey) bool {
+	for _, tokenProgram := range tokenPrograms {
+		if program.Equals(tokenProgram) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func tokenBalanceChange(meta *rpc.TransactionMeta, index uint16, mint solana.PublicKey) (int64, error) {
+	pre, err := tokenBalance(meta.PreTokenBalances, index, mint)
+	if err != nil {
+		return 0, err
+	}
+
+	post, err := tokenBalance(meta.PostTokenBalances, index, mint)
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(post) - int64(pre), nil
+}
+
+func tokenBalance(balances []rpc.TokenBalance, index uint16, mint solana.PublicKey) (uint64, error) {
+	for _, balance := range balances {
+		if balance.AccountIndex != index || !balance.Mint.Equals(mint) || balance.UiTokenAmount == nil {
+			continue
+		}
+
+		amount, err := strconv.ParseUint(balance.UiTokenAmount.Amount, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid token balance %q: %w", balance.UiTokenAmount.Amount, err)
+		}
+
+		return amount, nil
+	}
+
+	return 0, nil
+}
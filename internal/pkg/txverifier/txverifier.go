@@ -0,0 +1,412 @@
+package txverifier
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"time"
+	"twitch-crypto-donations/internal/pkg/chain"
+
+	"github.com/AlekSi/pointer"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+const (
+	systemTransferInstruction         = 2
+	systemTransferWithSeedInstruction = 11
+)
+
+const (
+	ReasonTransactionFailed  = chain.ReasonTransactionFailed
+	ReasonRecipientNotFound  = chain.ReasonRecipientNotFound
+	ReasonTransferNotFound   = chain.ReasonTransferNotFound
+	ReasonInsufficientAmount = chain.ReasonInsufficientAmount
+	ReasonBalanceMismatch    = chain.ReasonBalanceMismatch
+)
+
+type RpcClient interface {
+	GetTransaction(ctx context.Context, txSig solana.Signature, opts *rpc.GetTransactionOpts) (out *rpc.GetTransactionResult, err error)
+	IsBlockhashValid(ctx context.Context, blockHash solana.Hash, commitment rpc.CommitmentType) (out *rpc.IsValidBlockhashResult, err error)
+	GetSlot(ctx context.Context, commitment rpc.CommitmentType) (out uint64, err error)
+}
+
+type Transfer struct {
+	Sender    string
+	Recipient string
+	Mint      string
+	Amount    uint64
+}
+
+type Result struct {
+	Slot      uint64
+	Blockhash string
+	Sender    string
+	Mint      string
+	Amount    uint64
+	Memos     []string
+}
+
+type Mismatch = chain.Mismatch
+
+type Verifier struct {
+	rpcClient RpcClient
+	timeout   time.Duration
+}
+
+func New(rpcClient RpcClient) *Verifier {
+	return &Verifier{
+		rpcClient: rpcClient,
+		timeout:   15 * time.Second,
+	}
+}
+
+func (v *Verifier) GetTransaction(ctx context.Context, signature string) (*rpc.GetTransactionResult, error) {
+	sig, err := solana.SignatureFromBase58(signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature format: %w", err)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, v.timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-timeoutCtx.Done():
+			return nil, fmt.Errorf("transaction confirmation timeout after %s", v.timeout)
+		case <-ticker.C:
+			tx, err := v.FetchTransaction(timeoutCtx, sig)
+			if err != nil {
+				continue
+			}
+
+			return tx, nil
+		}
+	}
+}
+
+func (v *Verifier) FetchTransaction(ctx context.Context, sig solana.Signature) (*rpc.GetTransactionResult, error) {
+	return v.FetchTransactionAt(ctx, sig, rpc.CommitmentConfirmed)
+}
+
+func (v *Verifier) FetchTransactionAt(ctx context.Context, sig solana.Signature, commitment rpc.CommitmentType) (*rpc.GetTransactionResult, error) {
+	tx, err := v.rpcClient.GetTransaction(
+		ctx, sig,
+		&rpc.GetTransactionOpts{
+			Encoding:                       solana.EncodingBase64,
+			Commitment:                     commitment,
+			MaxSupportedTransactionVersion: pointer.ToUint64(0),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if tx == nil || tx.Meta == nil {
+		return nil, rpc.ErrNotFound
+	}
+
+	return tx, nil
+}
+
+func (v *Verifier) VerifyTransfer(ctx context.Context, signature string, expected Transfer) (*Result, error) {
+	return v.VerifyTransfers(ctx, signature, []Transfer{expected})
+}
+
+// VerifyTransfers checks that one transaction carries every expected transfer,
+// such as the streamer, revenue split and platform fee legs of a donation.
+// Legs paid to the same recipient are added up, since their transfers cannot be
+// told apart. The result reports the payer of the first leg and the total amount.
+func (v *Verifier) VerifyTransfers(ctx context.Context, signature string, expected []Transfer) (*Result, error) {
+	if len(expected) == 0 {
+		return nil, fmt.Errorf("no transfers to verify")
+	}
+
+	legs, err := parseTransfers(expected)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := v.GetTransaction(ctx, signature)
+	if err != nil {
+		return nil, err
+	}
+
+	if tx.Meta.Err != nil {
+		return nil, &Mismatch{
+			Reason:  ReasonTransactionFailed,
+			Message: fmt.Sprintf("transaction failed: %+v", tx.Meta.Err),
+		}
+	}
+
+	message, err := decodeMessage(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		total uint64
+		payer solana.PublicKey
+	)
+	for i, leg := range legs {
+		var (
+			transferred uint64
+			from        solana.PublicKey
+		)
+		if leg.mint.IsZero() {
+			transferred, from, err = verifyNativeTransfer(message, tx.Meta, leg.sender, leg.recipient, leg.amount)
+		} else {
+			transferred, from, err = verifyTokenTransfer(message, tx.Meta, leg.sender, leg.recipient, leg.mint, leg.amount)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if i == 0 {
+			payer = from
+		}
+		total += transferred
+	}
+
+	return &Result{
+		Slot:      tx.Slot,
+		Blockhash: message.RecentBlockhash.String(),
+		Sender:    payer.String(),
+		Mint:      expected[0].Mint,
+		Amount:    total,
+		Memos:     memos(message, tx.Meta),
+	}, nil
+}
+
+type parsedTransfer struct {
+	sender    solana.PublicKey
+	recipient solana.PublicKey
+	mint      solana.PublicKey
+	amount    uint64
+}
+
+func parseTransfers(expected []Transfer) ([]parsedTransfer, error) {
+	legs := make([]parsedTransfer, 0, len(expected))
+	index := make(map[parsedTransfer]int, len(expected))
+
+	for _, transfer := range expected {
+		var leg parsedTransfer
+
+		if transfer.Sender != "" {
+			key, err := solana.PublicKeyFromBase58(transfer.Sender)
+			if err != nil {
+				return nil, fmt.Errorf("invalid sender address: %w", err)
+			}
+			leg.sender = key
+		}
+
+		recipient, err := solana.PublicKeyFromBase58(transfer.Recipient)
+		if err != nil {
+			return nil, fmt.Errorf("invalid recipient address: %w", err)
+		}
+		leg.recipient = recipient
+
+		if transfer.Mint != "" {
+			leg.mint, err = solana.PublicKeyFromBase58(transfer.Mint)
+			if err != nil {
+				return nil, fmt.Errorf("invalid mint address: %w", err)
+			}
+		}
+
+		if i, ok := index[leg]; ok {
+			legs[i].amount += transfer.Amount
+			continue
+		}
+
+		index[leg] = len(legs)
+		leg.amount = transfer.Amount
+		legs = append(legs, leg)
+	}
+
+	return legs, nil
+}
+
+func verifyNativeTransfer(
+	message solana.Message,
+	meta *rpc.TransactionMeta,
+	sender, recipient solana.PublicKey,
+	expected uint64,
+) (uint64, solana.PublicKey, error) {
+	recipientIndex, ok := accountIndex(message, recipient)
+	if !ok {
+		return 0, solana.PublicKey{}, &Mismatch{
+			Reason:   ReasonRecipientNotFound,
+			Message:  fmt.Sprintf("recipient %s is not part of the transaction", recipient),
+			Expected: expected,
+		}
+	}
+
+	var (
+		transferred uint64
+		payer       solana.PublicKey
+	)
+	for _, transfer := range systemTransfers(message, meta) {
+		if !transfer.to.Equals(recipient) {
+			continue
+		}
+
+		if !sender.IsZero() && !transfer.from.Equals(sender) {
+			continue
+		}
+
+		if transferred == 0 {
+			payer = transfer.from
+		}
+		transferred += transfer.lamports
+	}
+
+	if transferred == 0 {
+		return 0, solana.PublicKey{}, &Mismatch{
+			Reason:   ReasonTransferNotFound,
+			Message:  fmt.Sprintf("no System Program transfer to %s found in transaction", recipient),
+			Expected: expected,
+		}
+	}
+
+	if transferred < expected {
+		return 0, solana.PublicKey{}, &Mismatch{
+			Reason:   ReasonInsufficientAmount,
+			Message:  fmt.Sprintf("transferred amount is lower than expected: expected %d lamports, got %d", expected, transferred),
+			Expected: expected,
+			Actual:   transferred,
+		}
+	}
+
+	credited := balanceChange(meta, recipientIndex)
+	if credited < int64(expected) {
+		return 0, solana.PublicKey{}, &Mismatch{
+			Reason:   ReasonBalanceMismatch,
+			Message:  fmt.Sprintf("recipient balance increased by %d lamports, expected at least %d", credited, expected),
+			Expected: expected,
+			Actual:   uint64(max(credited, 0)),
+		}
+	}
+
+	return transferred, payer, nil
+}
+
+func decodeMessage(tx *rpc.GetTransactionResult) (solana.Message, error) {
+	if tx.Transaction == nil {
+		return solana.Message{}, fmt.Errorf("transaction data is missing")
+	}
+
+	transaction, err := tx.Transaction.GetTransaction()
+	if err != nil {
+		return solana.Message{}, fmt.Errorf("failed to decode transaction: %w", err)
+	}
+
+	message := transaction.Message
+	if message.IsVersioned() && message.NumLookups() > 0 {
+		err = message.ResolveLookupsWith(tx.Meta.LoadedAddresses.Writable, tx.Meta.LoadedAddresses.ReadOnly)
+		if err != nil {
+			return solana.Message{}, fmt.Errorf("failed to resolve address lookup tables: %w", err)
+		}
+	}
+
+	return message, nil
+}
+
+func accountIndex(message solana.Message, account solana.PublicKey) (uint16, bool) {
+	for idx, key := range message.AccountKeys {
+		if key.Equals(account) {
+			return uint16(idx), true
+		}
+	}
+
+	return 0, false
+}
+
+type systemTransfer struct {
+	from     solana.PublicKey
+	to       solana.PublicKey
+	lamports uint64
+}
+
+func systemTransfers(message solana.Message, meta *rpc.TransactionMeta) []systemTransfer {
+	instructions := allInstructions(message, meta)
+
+	transfers := make([]systemTransfer, 0, len(instructions))
+	for _, instruction := range instructions {
+		if transfer, ok := decodeSystemTransfer(message, instruction); ok {
+			transfers = append(transfers, transfer)
+		}
+	}
+
+	return transfers
+}
+
+func allInstructions(message solana.Message, meta *rpc.TransactionMeta) []solana.CompiledInstruction {
+	instructions := make([]solana.CompiledInstruction, 0, len(message.Instructions))
+	instructions = append(instructions, message.Instructions...)
+
+	for _, inner := range meta.InnerInstructions {
+		for _, instruction := range inner.Instructions {
+			instructions = append(instructions, solana.CompiledInstruction{
+				ProgramIDIndex: instruction.ProgramIDIndex,
+				Accounts:       instruction.Accounts,
+				Data:           instruction.Data,
+			})
+		}
+	}
+
+	return instructions
+}
+
+func decodeSystemTransfer(message solana.Message, instruction solana.CompiledInstruction) (systemTransfer, bool) {
+	program, err := message.Program(instruction.ProgramIDIndex)
+	if err != nil || !program.Equals(solana.SystemProgramID) || len(instruction.Data) < 12 {
+		return systemTransfer{}, false
+	}
+
+	var fromIndex, toIndex int
+	switch binary.LittleEndian.Uint32(instruction.Data[:4]) {
+	case systemTransferInstruction:
+		fromIndex, toIndex = 0, 1
+	case systemTransferWithSeedInstruction:
+		fromIndex, toIndex = 0, 2
+	default:
+		return systemTransfer{}, false
+	}
+
+	if len(instruction.Accounts) <= toIndex {
+		return systemTransfer{}, false
+	}
+
+	from, err := message.Account(instruction.Accounts[fromIndex])
+	if err != nil {
+		return systemTransfer{}, false
+	}
+
+	to, err := message.Account(instruction.Accounts[toIndex])
+	if err != nil {
+		return systemTransfer{}, false
+	}
+
+	return systemTransfer{
+		from:     from,
+		to:       to,
+		lamports: binary.LittleEndian.Uint64(instruction.Data[4:12]),
+	}, true
+}
+
+func balanceChange(meta *rpc.TransactionMeta, index uint16) int64 {
+	if int(index) >= len(meta.PreBalances) || int(index) >= len(meta.PostBalances) {
+		return 0
+	}
+
+	change := int64(meta.PostBalances[index]) - int64(meta.PreBalances[index])
+	if index == 0 {
+		change += int64(meta.Fee)
+	}
+
+	return change
+}
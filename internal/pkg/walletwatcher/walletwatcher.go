@@ -0,0 +1,779 @@
+package walletwatcher
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"twitch-crypto-donations/internal/pkg/amount"
+	"twitch-crypto-donations/internal/pkg/donationrules"
+	"twitch-crypto-donations/internal/pkg/donations"
+	"twitch-crypto-donations/internal/pkg/environment"
+	"twitch-crypto-donations/internal/pkg/goals"
+	"twitch-crypto-donations/internal/pkg/mints"
+	"twitch-crypto-donations/internal/pkg/obsservice"
+	"twitch-crypto-donations/internal/pkg/pricing"
+	"twitch-crypto-donations/internal/pkg/solanapay"
+	"twitch-crypto-donations/internal/pkg/splits"
+	"twitch-crypto-donations/internal/pkg/subathon"
+	"twitch-crypto-donations/internal/pkg/txverifier"
+
+	"github.com/AlekSi/pointer"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type Database interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+	Exec(query string, args ...any) (sql.Result, error)
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+type RpcClient interface {
+	GetSignaturesForAddressWithOpts(ctx context.Context, account solana.PublicKey, opts *rpc.GetSignaturesForAddressOpts) ([]*rpc.TransactionSignature, error)
+}
+
+type TransferDetector interface {
+	IncomingTransfers(ctx context.Context, sig solana.Signature, recipients []string, mints []string) ([]txverifier.Incoming, error)
+}
+
+type SplitPolicy interface {
+	Plan(receiver string, gross uint64) ([]splits.Leg, error)
+	Shares(receiver string) ([]splits.Share, error)
+	FeeWallet() string
+}
+
+type MintRegistry interface {
+	Tokens() []mints.Mint
+	BySymbol(symbol string) (mints.Mint, bool)
+	ByAddress(address string) (mints.Mint, bool)
+}
+
+type ObsService interface {
+	WebhookAlert(wallet string, request obsservice.AlertEvent) (any, string, error)
+}
+
+type GoalTracker interface {
+	Contribute(db goals.Executor, contribution goals.Contribution) (*goals.Progress, error)
+	Notify(progress goals.Progress, username *string) error
+}
+
+type SubathonTimer interface {
+	Extend(db subathon.Executor, donation subathon.Donation) (*subathon.Extension, error)
+	Notify(owner string, timer *subathon.Timer, added int64, username *string) error
+}
+
+type RuleRegistry interface {
+	Allow(receiver, currency string, units uint64, decimals uint8, features donationrules.Features) (donationrules.Features, error)
+}
+
+type Logger interface {
+	Info(msg string, ctx ...interface{})
+}
+
+type Valuer interface {
+	Value(ctx context.Context, symbol string, units uint64, decimals uint8) *pricing.Valuation
+}
+
+type Watcher struct {
+	db          Database
+	rpcClient   RpcClient
+	detector    TransferDetector
+	mints       MintRegistry
+	splits      SplitPolicy
+	obsService  ObsService
+	valuer      Valuer
+	goals       GoalTracker
+	timers      SubathonTimer
+	rules       RuleRegistry
+	logger      Logger
+	interval    time.Duration
+	minAmounts  map[string]uint64
+	gracePeriod time.Duration
+	pageSize    int
+}
+
+type wallet struct {
+	address string
+	channel string
+}
+
+type checkpoint struct {
+	signature solana.Signature
+	found     bool
+}
+
+type donation struct {
+	id     string
+	sender string
+	amount uint64
+	mint   mints.Mint
+	legs   []splits.Leg
+	// flag explains why the transfer is not a valid donation; it is empty for
+	// a transfer that pays every leg of its split plan.
+	flag string
+	// alert is false when the amount is below the streamer's alert minimum.
+	alert     bool
+	message   *string
+	blockhash string
+	valuation *pricing.Valuation
+	goal      *goals.Progress
+	extension *subathon.Extension
+}
+
+func New(
+	db Database,
+	rpcClient RpcClient,
+	detector TransferDetector,
+	mints MintRegistry,
+	splits SplitPolicy,
+	obsService ObsService,
+	valuer Valuer,
+	goals GoalTracker,
+	timers SubathonTimer,
+	rules RuleRegistry,
+	logger Logger,
+	interval environment.WatcherPollIntervalSeconds,
+	minAmounts environment.WatcherMinAmounts,
+) (*Watcher, error) {
+	thresholds, err := parseMinAmounts(mints, minAmounts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Watcher{
+		db:          db,
+		rpcClient:   rpcClient,
+		detector:    detector,
+		mints:       mints,
+		splits:      splits,
+		obsService:  obsService,
+		valuer:      valuer,
+		goals:       goals,
+		timers:      timers,
+		rules:       rules,
+		logger:      logger,
+		interval:    time.Duration(interval) * time.Second,
+		minAmounts:  thresholds,
+		gracePeriod: time.Minute,
+		pageSize:    1000,
+	}, nil
+}
+
+// parseMinAmounts parses the minimum amounts a plain transfer needs to count as
+// a donation, in the form SYMBOL:AMOUNT separated by semicolons, for example
+// "SOL:0.01;USDC:1". The result is keyed by symbol, in base units.
+func parseMinAmounts(registry MintRegistry, minAmounts environment.WatcherMinAmounts) (map[string]uint64, error) {
+	thresholds := make(map[string]uint64)
+
+	for _, entry := range strings.Split(string(minAmounts), ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		symbol, value, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid watcher minimum %q, expected SYMBOL:AMOUNT", entry)
+		}
+
+		mint, ok := registry.BySymbol(strings.TrimSpace(symbol))
+		if !ok {
+			return nil, fmt.Errorf("invalid watcher minimum %q: unknown currency", entry)
+		}
+
+		units, err := amount.Parse(strings.TrimSpace(value), mint.Decimals)
+		if err != nil {
+			return nil, fmt.Errorf("invalid watcher minimum %q: %w", entry, err)
+		}
+
+		thresholds[mint.Symbol] = units
+	}
+
+	return thresholds, nil
+}
+
+func (w *Watcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.Poll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *Watcher) Poll(ctx context.Context) {
+	wallets, err := w.registeredWallets()
+	if err != nil {
+		w.logger.Info("wallet watcher failed to load wallets", "error", err.Error())
+		return
+	}
+
+	for _, wallet := range wallets {
+		addresses, err := w.watchedAddresses(wallet.address)
+		if err != nil {
+			w.logger.Info("wallet watcher skipped wallet", "wallet", wallet.address, "error", err.Error())
+			continue
+		}
+
+		for _, address := range addresses {
+			if ctx.Err() != nil {
+				return
+			}
+
+			if err = w.syncAddress(ctx, wallet, address); err != nil {
+				w.logger.Info("wallet watcher failed to sync address",
+					"wallet", wallet.address,
+					"address", address.String(),
+					"error", err.Error(),
+				)
+			}
+		}
+	}
+}
+
+func (w *Watcher) watchedAddresses(address string) ([]solana.PublicKey, error) {
+	owner, err := solana.PublicKeyFromBase58(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid wallet address: %w", err)
+	}
+
+	addresses := []solana.PublicKey{owner}
+	for _, mint := range w.mints.Tokens() {
+		for _, program := range []solana.PublicKey{solana.TokenProgramID, solana.Token2022ProgramID} {
+			tokenAccount, err := txverifier.AssociatedTokenAddress(owner, mint.Address, program)
+			if err != nil {
+				return nil, err
+			}
+			addresses = append(addresses, tokenAccount)
+		}
+	}
+
+	return addresses, nil
+}
+
+func (w *Watcher) syncAddress(ctx context.Context, wallet wallet, address solana.PublicKey) error {
+	last, err := w.checkpoint(address)
+	if err != nil {
+		return err
+	}
+
+	if !last.found {
+		return w.startWatching(ctx, wallet, address)
+	}
+
+	signatures, err := w.newSignatures(ctx, address, last.signature)
+	if err != nil {
+		return err
+	}
+
+	recipients, err := w.recipients(wallet)
+	if err != nil {
+		return err
+	}
+
+	tokenMints := make([]string, 0)
+	for _, mint := range w.mints.Tokens() {
+		tokenMints = append(tokenMints, mint.Address.String())
+	}
+
+	for _, signature := range signatures {
+		if signature.Err == nil {
+			if signature.BlockTime == nil || time.Since(signature.BlockTime.Time()) < w.gracePeriod {
+				return nil
+			}
+		}
+
+		var incoming []txverifier.Incoming
+		if signature.Err == nil {
+			incoming, err = w.detector.IncomingTransfers(ctx, signature.Signature, recipients, tokenMints)
+			if err != nil {
+				return err
+			}
+
+			incoming, err = w.unrequested(incoming)
+			if err != nil {
+				return err
+			}
+		}
+
+		recorded, err := w.record(ctx, wallet, address, signature, incoming)
+		if err != nil {
+			return err
+		}
+
+		for _, d := range recorded {
+			if d.alert {
+				state, reason := donations.StateAlertDelivered, "overlay alert delivered"
+				if err = w.sendAlert(wallet, d); err != nil {
+					state, reason = donations.StateAlertFailed, err.Error()
+				}
+
+				if err = donations.Transition(w.db, d.id, state, reason); err != nil {
+					w.logger.Info("wallet watcher failed to record alert state", "donation", d.id, "error", err.Error())
+				}
+			}
+
+			if d.goal != nil {
+				if err = w.goals.Notify(*d.goal, &d.sender); err != nil {
+					w.logger.Info("wallet watcher failed to send goal events", "donation", d.id, "error", err.Error())
+				}
+			}
+
+			if d.extension != nil {
+				err = w.timers.Notify(wallet.address, &d.extension.Timer, d.extension.Seconds, &d.sender)
+				if err != nil {
+					w.logger.Info("wallet watcher failed to send timer update", "donation", d.id, "error", err.Error())
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// recipients lists the wallets a donation to wallet pays: the streamer, every
+// revenue split share and the platform fee wallet.
+func (w *Watcher) recipients(wallet wallet) ([]string, error) {
+	shares, err := w.splits.Shares(wallet.address)
+	if err != nil {
+		return nil, err
+	}
+
+	recipients := []string{wallet.address}
+	for _, share := range shares {
+		recipients = append(recipients, share.Recipient)
+	}
+
+	if feeWallet := w.splits.FeeWallet(); feeWallet != "" {
+		recipients = append(recipients, feeWallet)
+	}
+
+	return recipients, nil
+}
+
+// unrequested drops the transfers of a transaction that pays a payment
+// request; the resolver settles those.
+func (w *Watcher) unrequested(incoming []txverifier.Incoming) ([]txverifier.Incoming, error) {
+	if len(incoming) == 0 {
+		return nil, nil
+	}
+
+	requested, err := w.requested(incoming[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if requested {
+		return nil, nil
+	}
+
+	return incoming, nil
+}
+
+// requested reports whether the transaction pays a payment request: it carries
+// the request's reference key or its donation ID as a memo.
+func (w *Watcher) requested(in txverifier.Incoming) (bool, error) {
+	const query = `
+		SELECT EXISTS (
+			SELECT 1 FROM payment_requests
+			WHERE reference = ANY($1) OR donation_id = ANY($2)
+		);
+	`
+
+	var exists bool
+	if err := w.db.QueryRow(query, pq.Array(in.Accounts), pq.Array(in.Memos)).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to look up payment requests: %w", err)
+	}
+
+	return exists, nil
+}
+
+func (w *Watcher) startWatching(ctx context.Context, wallet wallet, address solana.PublicKey) error {
+	latest, err := w.rpcClient.GetSignaturesForAddressWithOpts(ctx, address, &rpc.GetSignaturesForAddressOpts{
+		Limit:      pointer.ToInt(1),
+		Commitment: rpc.CommitmentConfirmed,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to fetch latest signature: %w", err)
+	}
+
+	var signature *string
+	var slot *uint64
+	if len(latest) > 0 {
+		signature = pointer.ToString(latest[0].Signature.String())
+		slot = pointer.ToUint64(latest[0].Slot)
+	}
+
+	const insertQuery = `
+		INSERT INTO wallet_checkpoints (address, wallet, last_signature, last_slot)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (address) DO NOTHING;
+	`
+
+	if _, err = w.db.Exec(insertQuery, address.String(), wallet.address, signature, slot); err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+
+	return nil
+}
+
+func (w *Watcher) newSignatures(ctx context.Context, address solana.PublicKey, until solana.Signature) ([]*rpc.TransactionSignature, error) {
+	signatures := make([]*rpc.TransactionSignature, 0)
+
+	var before solana.Signature
+	for {
+		page, err := w.rpcClient.GetSignaturesForAddressWithOpts(ctx, address, &rpc.GetSignaturesForAddressOpts{
+			Limit:      pointer.ToInt(w.pageSize),
+			Before:     before,
+			Until:      until,
+			Commitment: rpc.CommitmentConfirmed,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch signatures: %w", err)
+		}
+
+		signatures = append(signatures, page...)
+		if len(page) < w.pageSize {
+			break
+		}
+
+		before = page[len(page)-1].Signature
+	}
+
+	slices.Reverse(signatures)
+
+	return signatures, nil
+}
+
+func (w *Watcher) record(
+	ctx context.Context,
+	wallet wallet,
+	address solana.PublicKey,
+	signature *rpc.TransactionSignature,
+	incoming []txverifier.Incoming,
+) ([]donation, error) {
+	detected, err := w.detect(ctx, wallet, incoming)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := w.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if len(detected) > 0 {
+		claimed, err := w.claimSignature(tx, wallet, signature.Signature, detected[0].sender)
+		if err != nil {
+			return nil, err
+		}
+
+		if !claimed {
+			detected = nil
+		}
+	}
+
+	confirmed := make([]donation, 0, len(detected))
+	for i := range detected {
+		d := &detected[i]
+
+		reason := "incoming transfer detected by wallet watcher"
+		if d.flag != "" {
+			reason = fmt.Sprintf("incoming transfer flagged by wallet watcher: %s", d.flag)
+		}
+
+		err = donations.Create(tx, donations.Donation{
+			ID:             d.id,
+			Receiver:       wallet.address,
+			SenderAddress:  &d.sender,
+			SenderUsername: d.sender,
+			Amount:         d.amount,
+			Decimals:       d.mint.Decimals,
+			Currency:       d.mint.Symbol,
+			TxSignature:    pointer.ToString(signature.Signature.String()),
+			TxHeight:       signature.Slot,
+			TxBlockhash:    d.blockhash,
+		}, donations.StatePaymentSeen, reason)
+		if err != nil {
+			return nil, err
+		}
+
+		// A flagged transfer stays in payment_seen: it is kept for the
+		// streamer to review but never counts or alerts.
+		if d.flag != "" {
+			continue
+		}
+
+		if err = w.saveDonation(tx, wallet, signature.Signature, *d); err != nil {
+			return nil, err
+		}
+
+		if err = splits.Save(tx, d.id, d.legs); err != nil {
+			return nil, err
+		}
+
+		err = donations.Transition(tx, d.id, donations.StateConfirmed, "transfer verified at confirmed commitment")
+		if err != nil {
+			return nil, err
+		}
+
+		if d.valuation != nil {
+			if err = donations.AttachValuation(tx, d.id, *d.valuation); err != nil {
+				return nil, err
+			}
+		}
+
+		d.goal, err = w.goals.Contribute(tx, goals.Contribution{
+			DonationID: d.id,
+			Receiver:   wallet.address,
+			Currency:   d.mint.Symbol,
+			Units:      d.amount,
+			Decimals:   d.mint.Decimals,
+			Valuation:  d.valuation,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		d.extension, err = w.timers.Extend(tx, subathon.Donation{
+			DonationID: d.id,
+			Receiver:   wallet.address,
+			Currency:   d.mint.Symbol,
+			Units:      d.amount,
+			Decimals:   d.mint.Decimals,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		confirmed = append(confirmed, *d)
+	}
+
+	const updateQuery = `
+		UPDATE wallet_checkpoints
+		SET last_signature = $2, last_slot = $3, updated_at = NOW()
+		WHERE address = $1;
+	`
+
+	if _, err = tx.Exec(updateQuery, address.String(), signature.Signature.String(), signature.Slot); err != nil {
+		return nil, fmt.Errorf("failed to update checkpoint: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return confirmed, nil
+}
+
+// detect turns the transfers of a transaction into donations to wallet, one per
+// sender and currency. A donation's amount is everything its sender paid the
+// streamer, the split shares and the fee wallet; it must cover every leg
+// splits.Plan expects for that amount, or the donation is flagged. Transfers
+// that pay the streamer nothing or fall below the watcher minimum are dropped.
+func (w *Watcher) detect(ctx context.Context, wallet wallet, incoming []txverifier.Incoming) ([]donation, error) {
+	type key struct {
+		sender string
+		mint   string
+	}
+
+	paid := make(map[key]map[string]uint64)
+	order := make([]key, 0, 1)
+	for _, in := range incoming {
+		k := key{sender: in.Sender, mint: in.Mint}
+		if _, exists := paid[k]; !exists {
+			paid[k] = make(map[string]uint64)
+			order = append(order, k)
+		}
+		paid[k][in.Recipient] += in.Amount
+	}
+
+	detected := make([]donation, 0, len(order))
+	for _, k := range order {
+		received := paid[k]
+
+		mint, ok := w.mints.ByAddress(k.mint)
+		if !ok || received[wallet.address] == 0 {
+			continue
+		}
+
+		var gross uint64
+		for _, value := range received {
+			gross += value
+		}
+
+		if gross < w.minAmounts[mint.Symbol] {
+			continue
+		}
+
+		d := donation{id: uuid.NewString(), sender: k.sender, amount: gross, mint: mint, blockhash: incoming[0].Blockhash}
+
+		legs, err := w.splits.Plan(wallet.address, gross)
+		switch {
+		case errors.Is(err, splits.ErrInvalidSplit):
+			d.flag = err.Error()
+		case err != nil:
+			return nil, err
+		default:
+			d.legs = legs
+			d.flag = missingLeg(legs, received)
+		}
+
+		if d.flag == "" {
+			allowed, err := w.rules.Allow(wallet.address, mint.Symbol, gross, mint.Decimals, donationrules.Features{Alert: true})
+			if err != nil {
+				return nil, err
+			}
+
+			d.alert = allowed.Alert
+			d.message = txverifier.Message(incoming[0].Memos, d.id, solanapay.TransferMemo)
+			d.valuation = w.valuer.Value(ctx, mint.Symbol, gross, mint.Decimals)
+		}
+
+		detected = append(detected, d)
+	}
+
+	return detected, nil
+}
+
+// missingLeg describes the first leg of the plan its recipient was not paid in
+// full, or returns an empty string when every leg is covered.
+func missingLeg(legs []splits.Leg, received map[string]uint64) string {
+	owed := make(map[string]uint64)
+	for _, leg := range legs {
+		owed[leg.Recipient] += leg.Amount
+	}
+
+	for _, leg := range legs {
+		if received[leg.Recipient] < owed[leg.Recipient] {
+			return fmt.Sprintf("transfer does not pay the %s leg of %d to %s", leg.Kind, leg.Amount, leg.Recipient)
+		}
+	}
+
+	return ""
+}
+
+func (w *Watcher) claimSignature(tx *sql.Tx, wallet wallet, signature solana.Signature, sender string) (bool, error) {
+	const insertQuery = `
+		INSERT INTO used_signatures (signature, sender_address, receiver)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (signature) DO NOTHING;
+	`
+
+	result, err := tx.Exec(insertQuery, signature.String(), sender, wallet.address)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim transaction signature: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim transaction signature: %w", err)
+	}
+
+	return affected == 1, nil
+}
+
+func (w *Watcher) saveDonation(tx *sql.Tx, wallet wallet, signature solana.Signature, d donation) error {
+	const insertQuery = `
+		INSERT INTO donations_history
+		(receiver, amount, decimals, sender_username, currency, text, layout, channel, sender_address, tx_signature, donation_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11);
+	`
+
+	_, err := tx.Exec(insertQuery,
+		wallet.address, strconv.FormatUint(d.amount, 10), d.mint.Decimals,
+		d.sender, d.mint.Symbol, d.message,
+		"alert", wallet.channel,
+		d.sender, signature.String(),
+		d.id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save donation history: %w", err)
+	}
+
+	return nil
+}
+
+func (w *Watcher) sendAlert(wallet wallet, d donation) error {
+	value, err := strconv.ParseFloat(amount.Format(d.amount, d.mint.Decimals), 64)
+	if err != nil {
+		return fmt.Errorf("failed to format amount: %w", err)
+	}
+
+	_, _, err = w.obsService.WebhookAlert(wallet.address, obsservice.AlertEvent{
+		Username: pointer.ToString(d.sender),
+		Amount:   pointer.ToFloat64(value),
+		Currency: pointer.ToString(d.mint.Symbol),
+		Message:  d.message,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send alert: %w", err)
+	}
+
+	return nil
+}
+
+func (w *Watcher) checkpoint(address solana.PublicKey) (checkpoint, error) {
+	const query = `SELECT last_signature FROM wallet_checkpoints WHERE address = $1;`
+
+	var signature sql.NullString
+	err := w.db.QueryRow(query, address.String()).Scan(&signature)
+	if errors.Is(err, sql.ErrNoRows) {
+		return checkpoint{}, nil
+	}
+
+	if err != nil {
+		return checkpoint{}, fmt.Errorf("failed to load checkpoint: %w", err)
+	}
+
+	if !signature.Valid {
+		return checkpoint{found: true}, nil
+	}
+
+	sig, err := solana.SignatureFromBase58(signature.String)
+	if err != nil {
+		return checkpoint{}, fmt.Errorf("invalid checkpoint signature: %w", err)
+	}
+
+	return checkpoint{signature: sig, found: true}, nil
+}
+
+func (w *Watcher) registeredWallets() ([]wallet, error) {
+	const query = `SELECT wallet, channel FROM users WHERE channel IS NOT NULL;`
+
+	rows, err := w.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	wallets := make([]wallet, 0)
+	for rows.Next() {
+		var wl wallet
+		if err = rows.Scan(&wl.address, &wl.channel); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		wallets = append(wallets, wl)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return wallets, nil
+}
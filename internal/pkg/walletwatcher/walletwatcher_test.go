@@ -0,0 +1,672 @@
+package walletwatcher
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"twitch-crypto-donations/internal/pkg/donationrules"
+	"twitch-crypto-donations/internal/pkg/environment"
+	"twitch-crypto-donations/internal/pkg/goals"
+	"twitch-crypto-donations/internal/pkg/mints"
+	"twitch-crypto-donations/internal/pkg/obsservice"
+	"twitch-crypto-donations/internal/pkg/pricing"
+	"twitch-crypto-donations/internal/pkg/splits"
+	"twitch-crypto-donations/internal/pkg/subathon"
+	"twitch-crypto-donations/internal/pkg/txverifier"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+func TestPollResumesFromCheckpoint(t *testing.T) {
+	h := newHarness(t)
+
+	h.transfer(h.donor(), 2*solana.LAMPORTS_PER_SOL)
+	h.poll(h.watcher())
+
+	if alerts := h.obs.count(); alerts != 0 {
+		t.Fatalf("first poll sent %d alerts, want history before the checkpoint to be skipped", alerts)
+	}
+
+	first := h.transfer(h.donor(), solana.LAMPORTS_PER_SOL/2)
+	h.transfer(h.donor(), solana.LAMPORTS_PER_SOL/1000)
+	third := h.transfer(h.donor(), solana.LAMPORTS_PER_SOL)
+	h.poll(h.watcher())
+
+	h.expectAlerts(first, third)
+	h.expectCheckpoint(third)
+
+	h.poll(h.watcher())
+	h.expectAlerts(first, third)
+
+	fourth := h.transfer(h.donor(), solana.LAMPORTS_PER_SOL)
+	h.poll(h.watcher())
+
+	h.expectAlerts(first, third, fourth)
+	h.expectCheckpoint(fourth)
+}
+
+func TestPollSkipsClaimedAndRequestedSignatures(t *testing.T) {
+	h := newHarness(t)
+	h.poll(h.watcher())
+
+	claimed := h.transfer(h.donor(), solana.LAMPORTS_PER_SOL)
+	h.db.claim(claimed)
+
+	reference := solana.NewWallet().PublicKey()
+	h.db.request(reference.String())
+	requested := h.transfer(h.donor(), solana.LAMPORTS_PER_SOL, reference)
+
+	plain := h.transfer(h.donor(), solana.LAMPORTS_PER_SOL)
+	h.poll(h.watcher())
+
+	h.expectAlerts(plain)
+	h.expectCheckpoint(plain)
+
+	if h.db.claimed(requested) {
+		t.Fatalf("signature %s of a payment request was claimed by the watcher", requested)
+	}
+}
+
+func TestPollFlagsTransfersMissingSplitLegs(t *testing.T) {
+	h := newHarness(t)
+	h.feeWallet = solana.NewWallet().PublicKey()
+	h.poll(h.watcher())
+
+	// A 5% platform fee: the fee wallet is owed 0.05 of a 1 SOL donation.
+	unpaid := h.transfer(h.donor(), solana.LAMPORTS_PER_SOL)
+	paid := h.pay(h.donor(), nil,
+		payment{to: h.wallet, lamports: solana.LAMPORTS_PER_SOL * 95 / 100},
+		payment{to: h.feeWallet, lamports: solana.LAMPORTS_PER_SOL * 5 / 100},
+	)
+	h.poll(h.watcher())
+
+	h.expectAlerts(paid)
+
+	if state := h.db.state(unpaid); state != "payment_seen" {
+		t.Fatalf("transfer without the fee leg is in state %q, want payment_seen", state)
+	}
+
+	if !h.db.claimed(unpaid) {
+		t.Fatalf("signature %s of the flagged transfer was not claimed", unpaid)
+	}
+}
+
+func TestPollSkipsAlertsBelowTheAlertMinimum(t *testing.T) {
+	h := newHarness(t)
+	h.db.minAlert("SOL", solana.LAMPORTS_PER_SOL)
+	h.poll(h.watcher())
+
+	small := h.transfer(h.donor(), solana.LAMPORTS_PER_SOL/2)
+	large := h.transfer(h.donor(), solana.LAMPORTS_PER_SOL)
+	h.poll(h.watcher())
+
+	if history := h.db.recorded(); len(history) != 2 || history[0] != small || history[1] != large {
+		t.Fatalf("recorded donations %v, want %v", history, []string{small, large})
+	}
+
+	if alerts := h.obs.count(); alerts != 1 {
+		t.Fatalf("sent %d alerts, want only the donation at the alert minimum", alerts)
+	}
+}
+
+type harness struct {
+	t         *testing.T
+	wallet    solana.PublicKey
+	feeWallet solana.PublicKey
+	chain     *fakeChain
+	db        *fakeStore
+	obs       *fakeObs
+	rpc       *rpc.Client
+}
+
+type payment struct {
+	to       solana.PublicKey
+	lamports uint64
+}
+
+func newHarness(t *testing.T) *harness {
+	chain := &fakeChain{transactions: make(map[string]string)}
+
+	server := httptest.NewServer(chain)
+	t.Cleanup(server.Close)
+
+	wallet := solana.NewWallet().PublicKey()
+
+	return &harness{
+		t:      t,
+		wallet: wallet,
+		chain:  chain,
+		db:     newFakeStore(wallet.String()),
+		obs:    &fakeObs{},
+		rpc:    rpc.New(server.URL),
+	}
+}
+
+// watcher builds a new watcher over the shared database and chain, as after a
+// restart of the service.
+func (h *harness) watcher() *Watcher {
+	registry, err := mints.New("")
+	if err != nil {
+		h.t.Fatal(err)
+	}
+
+	db := sql.OpenDB(fakeConnector{store: h.db})
+
+	var feeWallet string
+	if !h.feeWallet.IsZero() {
+		feeWallet = h.feeWallet.String()
+	}
+
+	policy, err := splits.New(db, 500, environment.PlatformFeeWallet(feeWallet))
+	if err != nil {
+		h.t.Fatal(err)
+	}
+
+	w, err := New(
+		db,
+		h.rpc,
+		txverifier.New(h.rpc),
+		registry,
+		policy,
+		h.obs,
+		fakeValuer{},
+		fakeGoals{},
+		fakeTimers{},
+		donationrules.New(db),
+		fakeLogger{t: h.t},
+		1,
+		"SOL:0.01",
+	)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+
+	w.pageSize = 2
+
+	return w
+}
+
+func (h *harness) poll(w *Watcher) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	w.Poll(ctx)
+}
+
+func (h *harness) donor() solana.PrivateKey {
+	key, err := solana.NewRandomPrivateKey()
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	return key
+}
+
+// transfer lands a system transfer to the watched wallet and returns its
+// signature. Extra accounts are added read-only, as Solana Pay references.
+func (h *harness) transfer(donor solana.PrivateKey, lamports uint64, extra ...solana.PublicKey) string {
+	return h.pay(donor, extra, payment{to: h.wallet, lamports: lamports})
+}
+
+// pay lands a transaction with a system transfer for each payment and returns
+// its signature. References are added read-only to the first transfer.
+func (h *harness) pay(donor solana.PrivateKey, references []solana.PublicKey, payments ...payment) string {
+	instructions := make([]solana.Instruction, 0, len(payments))
+	for i, p := range payments {
+		data := make([]byte, 12)
+		binary.LittleEndian.PutUint32(data[:4], 2)
+		binary.LittleEndian.PutUint64(data[4:], p.lamports)
+
+		accounts := solana.AccountMetaSlice{
+			solana.Meta(donor.PublicKey()).WRITE().SIGNER(),
+			solana.Meta(p.to).WRITE(),
+		}
+		if i == 0 {
+			for _, reference := range references {
+				accounts = append(accounts, solana.Meta(reference))
+			}
+		}
+
+		instructions = append(instructions, solana.NewInstruction(solana.SystemProgramID, accounts, data))
+	}
+
+	tx, err := solana.NewTransaction(instructions, solana.Hash{}, solana.TransactionPayer(donor.PublicKey()))
+	if err != nil {
+		h.t.Fatal(err)
+	}
+
+	_, err = tx.Sign(func(key solana.PublicKey) *solana.PrivateKey {
+		if key.Equals(donor.PublicKey()) {
+			return &donor
+		}
+		return nil
+	})
+	if err != nil {
+		h.t.Fatal(err)
+	}
+
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		h.t.Fatal(err)
+	}
+
+	return h.chain.land(tx.Signatures[0].String(), base64.StdEncoding.EncodeToString(raw))
+}
+
+func (h *harness) expectAlerts(signatures ...string) {
+	h.t.Helper()
+
+	history := h.db.recorded()
+	if len(history) != len(signatures) {
+		h.t.Fatalf("recorded donations %v, want %v", history, signatures)
+	}
+
+	for i, signature := range signatures {
+		if history[i] != signature {
+			h.t.Fatalf("recorded donations %v, want %v", history, signatures)
+		}
+	}
+
+	if alerts := h.obs.count(); alerts != len(signatures) {
+		h.t.Fatalf("sent %d alerts, want %d", alerts, len(signatures))
+	}
+}
+
+func (h *harness) expectCheckpoint(signature string) {
+	h.t.Helper()
+
+	if last := h.db.checkpoint(h.wallet.String()); last != signature {
+		h.t.Fatalf("checkpoint is %s, want %s", last, signature)
+	}
+}
+
+// fakeChain is a Solana JSON-RPC stand-in serving getSignaturesForAddress and
+// getTransaction for the transactions landed on it.
+type fakeChain struct {
+	mu           sync.Mutex
+	signatures   []string
+	transactions map[string]string
+}
+
+func (c *fakeChain) land(signature, transaction string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.signatures = append(c.signatures, signature)
+	c.transactions[signature] = transaction
+
+	return signature
+}
+
+func (c *fakeChain) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var result any
+	switch request.Method {
+	case "getSignaturesForAddress":
+		result = c.signaturesFor(request.Params)
+	case "getTransaction":
+		result = c.transaction(request.Params)
+	default:
+		http.Error(w, "unexpected method "+request.Method, http.StatusBadRequest)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": request.ID, "result": result})
+}
+
+func (c *fakeChain) signaturesFor(params []json.RawMessage) []map[string]any {
+	var opts struct {
+		Limit  int    `json:"limit"`
+		Before string `json:"before"`
+		Until  string `json:"until"`
+	}
+
+	if len(params) > 1 {
+		_ = json.Unmarshal(params[1], &opts)
+	}
+
+	blockTime := time.Now().Add(-time.Hour).Unix()
+
+	page := make([]map[string]any, 0)
+	skipping := opts.Before != ""
+	for i := len(c.signatures) - 1; i >= 0; i-- {
+		signature := c.signatures[i]
+		if skipping {
+			skipping = signature != opts.Before
+			continue
+		}
+
+		if signature == opts.Until || (opts.Limit > 0 && len(page) == opts.Limit) {
+			break
+		}
+
+		page = append(page, map[string]any{
+			"signature":          signature,
+			"slot":               i + 1,
+			"err":                nil,
+			"memo":               nil,
+			"blockTime":          blockTime,
+			"confirmationStatus": "confirmed",
+		})
+	}
+
+	return page
+}
+
+func (c *fakeChain) transaction(params []json.RawMessage) any {
+	var signature string
+	_ = json.Unmarshal(params[0], &signature)
+
+	transaction, ok := c.transactions[signature]
+	if !ok {
+		return nil
+	}
+
+	return map[string]any{
+		"slot":        1,
+		"blockTime":   time.Now().Add(-time.Hour).Unix(),
+		"transaction": []string{transaction, "base64"},
+		"meta": map[string]any{
+			"err":               nil,
+			"fee":               5000,
+			"preBalances":       []uint64{},
+			"postBalances":      []uint64{},
+			"innerInstructions": []any{},
+			"preTokenBalances":  []any{},
+			"postTokenBalances": []any{},
+			"logMessages":       []string{},
+		},
+	}
+}
+
+// fakeStore keeps the tables the watcher reads and writes. Statements are told
+// apart by the table they touch; the rest succeed without effect.
+type fakeStore struct {
+	mu          sync.Mutex
+	wallet      string
+	checkpoints map[string]string
+	used        map[string]bool
+	references  []string
+	history     []string
+	states      map[string]string
+	rules       map[string]uint64
+}
+
+func newFakeStore(wallet string) *fakeStore {
+	return &fakeStore{
+		wallet:      wallet,
+		checkpoints: make(map[string]string),
+		used:        make(map[string]bool),
+		states:      make(map[string]string),
+		rules:       make(map[string]uint64),
+	}
+}
+
+func (s *fakeStore) claim(signature string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.used[signature] = true
+}
+
+func (s *fakeStore) claimed(signature string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.used[signature]
+}
+
+func (s *fakeStore) request(reference string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.references = append(s.references, reference)
+}
+
+// minAlert sets the streamer's alert minimum for currency, in base units.
+func (s *fakeStore) minAlert(currency string, units uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rules[currency] = units
+}
+
+func (s *fakeStore) recorded() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.history...)
+}
+
+// state returns the state a donation for signature was created in.
+func (s *fakeStore) state(signature string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.states[signature]
+}
+
+func (s *fakeStore) checkpoint(address string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.checkpoints[address]
+}
+
+func (s *fakeStore) exec(query string, args []driver.NamedValue) (driver.Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case strings.Contains(query, "INSERT INTO wallet_checkpoints"):
+		address := args[0].Value.(string)
+		if _, exists := s.checkpoints[address]; !exists {
+			signature, _ := args[2].Value.(string)
+			s.checkpoints[address] = signature
+		}
+	case strings.Contains(query, "UPDATE wallet_checkpoints"):
+		s.checkpoints[args[0].Value.(string)] = args[1].Value.(string)
+	case strings.Contains(query, "INSERT INTO used_signatures"):
+		signature := args[0].Value.(string)
+		if s.used[signature] {
+			return driver.RowsAffected(0), nil
+		}
+		s.used[signature] = true
+	case strings.Contains(query, "INSERT INTO donations_history"):
+		s.history = append(s.history, args[9].Value.(string))
+	case strings.Contains(query, "INSERT INTO donations "):
+		s.states[args[7].Value.(string)] = args[9].Value.(string)
+	}
+
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStore) query(query string, args []driver.NamedValue) (driver.Rows, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case strings.Contains(query, "FROM users"):
+		return &fakeRows{columns: []string{"wallet", "channel"}, values: [][]driver.Value{{s.wallet, "channel"}}}, nil
+	case strings.Contains(query, "FROM wallet_checkpoints"):
+		signature, ok := s.checkpoints[args[0].Value.(string)]
+		if !ok {
+			return &fakeRows{columns: []string{"last_signature"}}, nil
+		}
+
+		var value driver.Value
+		if signature != "" {
+			value = signature
+		}
+		return &fakeRows{columns: []string{"last_signature"}, values: [][]driver.Value{{value}}}, nil
+	case strings.Contains(query, "FROM donation_rules"):
+		columns := []string{"currency", "min_alert", "min_voice", "min_media", "media_price_per_second"}
+		currency := args[1].Value.(string)
+		minimum, ok := s.rules[currency]
+		if !ok {
+			return &fakeRows{columns: columns}, nil
+		}
+		return &fakeRows{columns: columns, values: [][]driver.Value{{currency, fmt.Sprint(minimum), "0", "0", "0"}}}, nil
+	case strings.Contains(query, "FROM revenue_splits"):
+		return &fakeRows{columns: []string{"recipient", "bps", "label"}}, nil
+	case strings.Contains(query, "FROM payment_requests"):
+		accounts := args[0].Value.(string)
+		requested := false
+		for _, reference := range s.references {
+			requested = requested || strings.Contains(accounts, reference)
+		}
+		return &fakeRows{columns: []string{"exists"}, values: [][]driver.Value{{requested}}}, nil
+	}
+
+	return nil, fmt.Errorf("unexpected query: %s", query)
+}
+
+type fakeConnector struct {
+	store *fakeStore
+}
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) {
+	return &fakeConn{store: c.store}, nil
+}
+
+func (c fakeConnector) Driver() driver.Driver {
+	return fakeDriver{store: c.store}
+}
+
+type fakeDriver struct {
+	store *fakeStore
+}
+
+func (d fakeDriver) Open(string) (driver.Conn, error) {
+	return &fakeConn{store: d.store}, nil
+}
+
+type fakeConn struct {
+	store *fakeStore
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("prepared statements are not supported")
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return c, nil
+}
+
+func (c *fakeConn) Commit() error {
+	return nil
+}
+
+func (c *fakeConn) Rollback() error {
+	return nil
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return c.store.exec(query, args)
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return c.store.query(query, args)
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+	next    int
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.values) {
+		return io.EOF
+	}
+
+	copy(dest, r.values[r.next])
+	r.next++
+
+	return nil
+}
+
+type fakeObs struct {
+	mu     sync.Mutex
+	alerts []obsservice.AlertEvent
+}
+
+func (o *fakeObs) WebhookAlert(_ string, request obsservice.AlertEvent) (any, string, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.alerts = append(o.alerts, request)
+
+	return nil, "", nil
+}
+
+func (o *fakeObs) count() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.alerts)
+}
+
+type fakeValuer struct{}
+
+func (fakeValuer) Value(context.Context, string, uint64, uint8) *pricing.Valuation {
+	return nil
+}
+
+type fakeGoals struct{}
+
+func (fakeGoals) Contribute(goals.Executor, goals.Contribution) (*goals.Progress, error) {
+	return nil, nil
+}
+
+func (fakeGoals) Notify(goals.Progress, *string) error {
+	return nil
+}
+
+type fakeTimers struct{}
+
+func (fakeTimers) Extend(subathon.Executor, subathon.Donation) (*subathon.Extension, error) {
+	return nil, nil
+}
+
+func (fakeTimers) Notify(string, *subathon.Timer, int64, *string) error {
+	return nil
+}
+
+// fakeLogger fails the test on any log line: the watcher only logs failures.
+type fakeLogger struct {
+	t *testing.T
+}
+
+func (l fakeLogger) Info(msg string, ctx ...interface{}) {
+	l.t.Errorf("%s %v", msg, ctx)
+}
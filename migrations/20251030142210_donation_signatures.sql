@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE used_signatures (
+    signature TEXT PRIMARY KEY,
+    sender_address TEXT NOT NULL,
+    receiver TEXT NOT NULL,
+    created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE donations_history
+    ADD COLUMN sender_address TEXT,
+    ADD COLUMN tx_signature TEXT;
+
+CREATE INDEX idx_donations_history_tx_signature ON donations_history(tx_signature);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_donations_history_tx_signature;
+
+ALTER TABLE donations_history
+    DROP COLUMN IF EXISTS tx_signature,
+    DROP COLUMN IF EXISTS sender_address;
+
+DROP TABLE IF EXISTS used_signatures;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE wallet_checkpoints (
+    address TEXT PRIMARY KEY,
+    wallet TEXT NOT NULL,
+    last_signature TEXT,
+    last_slot BIGINT,
+    updated_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_wallet_checkpoints_wallet ON wallet_checkpoints(wallet);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_wallet_checkpoints_wallet;
+
+DROP TABLE IF EXISTS wallet_checkpoints;
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE payment_requests (
+    reference TEXT PRIMARY KEY,
+    receiver TEXT NOT NULL,
+    sender_username TEXT NOT NULL,
+    amount TEXT NOT NULL,
+    currency TEXT NOT NULL,
+    mint TEXT,
+    message TEXT,
+    duration_ms BIGINT,
+    alert_event JSONB,
+    media_event JSONB,
+    status TEXT NOT NULL DEFAULT 'pending',
+    tx_signature TEXT,
+    expires_at TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+    settled_at TIMESTAMP WITHOUT TIME ZONE,
+    created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_payment_requests_status ON payment_requests(status);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_payment_requests_status;
+
+DROP TABLE IF EXISTS payment_requests;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE payment_requests
+    ADD COLUMN donation_id TEXT UNIQUE,
+    ADD COLUMN sender_address TEXT,
+    ADD COLUMN fee_amount TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE payment_requests
+    DROP COLUMN IF EXISTS fee_amount,
+    DROP COLUMN IF EXISTS sender_address,
+    DROP COLUMN IF EXISTS donation_id;
+-- +goose StatementEnd
@@ -0,0 +1,46 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE donations (
+    id TEXT PRIMARY KEY,
+    receiver TEXT NOT NULL,
+    sender_address TEXT,
+    sender_username TEXT NOT NULL,
+    amount TEXT NOT NULL,
+    currency TEXT NOT NULL,
+    tx_signature TEXT,
+    state TEXT NOT NULL,
+    overlay JSONB,
+    created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_donations_receiver_state ON donations(receiver, state);
+CREATE INDEX idx_donations_tx_signature ON donations(tx_signature);
+
+CREATE TABLE donation_transitions (
+    id SERIAL PRIMARY KEY,
+    donation_id TEXT NOT NULL REFERENCES donations(id) ON DELETE CASCADE,
+    from_state TEXT,
+    to_state TEXT NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_donation_transitions_donation_id ON donation_transitions(donation_id);
+
+ALTER TABLE donations_history
+    ADD COLUMN donation_id TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE donations_history
+    DROP COLUMN IF EXISTS donation_id;
+
+DROP INDEX IF EXISTS idx_donation_transitions_donation_id;
+DROP TABLE IF EXISTS donation_transitions;
+
+DROP INDEX IF EXISTS idx_donations_tx_signature;
+DROP INDEX IF EXISTS idx_donations_receiver_state;
+DROP TABLE IF EXISTS donations;
+-- +goose StatementEnd
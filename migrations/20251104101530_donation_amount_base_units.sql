@@ -0,0 +1,97 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Donations sent without an amount were recorded with an empty amount. They
+-- moved no funds and have no base-unit amount to convert to, so those rows are
+-- deleted rather than kept with a made-up zero amount.
+DELETE FROM donations_history
+WHERE donation_amount IS NULL OR TRIM(donation_amount) = '';
+
+DELETE FROM donations
+WHERE amount IS NULL OR TRIM(amount) = '';
+
+-- Any other amount that does not parse as a decimal number stops the migration
+-- instead of being converted to zero.
+DO $$
+DECLARE
+    bad_history BIGINT;
+    bad_donations BIGINT;
+BEGIN
+    SELECT COUNT(*) INTO bad_history
+    FROM donations_history
+    WHERE TRIM(donation_amount) !~ '^[0-9]*\.?[0-9]+$';
+
+    SELECT COUNT(*) INTO bad_donations
+    FROM donations
+    WHERE TRIM(amount) !~ '^[0-9]*\.?[0-9]+$';
+
+    IF bad_history > 0 OR bad_donations > 0 THEN
+        RAISE EXCEPTION 'cannot convert amounts to base units: % donations_history and % donations rows have unparseable amounts',
+            bad_history, bad_donations;
+    END IF;
+END
+$$;
+
+ALTER TABLE donations_history
+    ADD COLUMN amount NUMERIC(39, 0),
+    ADD COLUMN decimals SMALLINT;
+
+UPDATE donations_history h
+SET decimals = parsed.decimals,
+    amount = ROUND(parsed.value * POWER(10::NUMERIC, parsed.decimals))
+FROM (
+    SELECT id, value,
+           CASE
+               WHEN UPPER(currency) = 'SOL' THEN 9
+               WHEN UPPER(currency) IN ('USDC', 'USDT') THEN 6
+               ELSE SCALE(value)
+           END AS decimals
+    FROM (
+        SELECT id, currency, TRIM(donation_amount)::NUMERIC AS value
+        FROM donations_history
+    ) raw
+) parsed
+WHERE h.id = parsed.id;
+
+ALTER TABLE donations_history
+    ALTER COLUMN amount SET NOT NULL,
+    ALTER COLUMN decimals SET NOT NULL,
+    DROP COLUMN donation_amount;
+
+CREATE INDEX idx_donations_history_receiver_amount ON donations_history(receiver, currency, amount DESC);
+
+ALTER TABLE donations
+    ADD COLUMN decimals SMALLINT;
+
+UPDATE donations
+SET decimals = CASE
+    WHEN UPPER(currency) = 'SOL' THEN 9
+    WHEN UPPER(currency) IN ('USDC', 'USDT') THEN 6
+    ELSE SCALE(TRIM(amount)::NUMERIC)
+END;
+
+ALTER TABLE donations
+    ALTER COLUMN decimals SET NOT NULL,
+    ALTER COLUMN amount TYPE NUMERIC(39, 0) USING ROUND(TRIM(amount)::NUMERIC * POWER(10::NUMERIC, decimals));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE donations
+    ALTER COLUMN amount TYPE TEXT USING ROUND(amount / POWER(10::NUMERIC, decimals), decimals)::TEXT;
+
+ALTER TABLE donations
+    DROP COLUMN decimals;
+
+DROP INDEX IF EXISTS idx_donations_history_receiver_amount;
+
+ALTER TABLE donations_history
+    ADD COLUMN donation_amount TEXT;
+
+UPDATE donations_history
+SET donation_amount = ROUND(amount / POWER(10::NUMERIC, decimals), decimals)::TEXT;
+
+ALTER TABLE donations_history
+    ALTER COLUMN donation_amount SET NOT NULL,
+    DROP COLUMN decimals,
+    DROP COLUMN amount;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE donations
+    ADD COLUMN usd_value NUMERIC(24, 2),
+    ADD COLUMN fiat_value NUMERIC(24, 2),
+    ADD COLUMN fiat_currency TEXT,
+    ADD COLUMN priced_at TIMESTAMP WITHOUT TIME ZONE;
+
+CREATE INDEX idx_donations_receiver_usd_value ON donations(receiver, usd_value DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_donations_receiver_usd_value;
+
+ALTER TABLE donations
+    DROP COLUMN IF EXISTS priced_at,
+    DROP COLUMN IF EXISTS fiat_currency,
+    DROP COLUMN IF EXISTS fiat_value,
+    DROP COLUMN IF EXISTS usd_value;
+-- +goose StatementEnd
@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE donations
+    ADD COLUMN finalized_at TIMESTAMP WITHOUT TIME ZONE,
+    ADD COLUMN tx_height BIGINT,
+    ADD COLUMN tx_blockhash TEXT,
+    ADD COLUMN finality_misses INT NOT NULL DEFAULT 0;
+
+CREATE INDEX idx_donations_unfinalized ON donations(created_at) WHERE finalized_at IS NULL AND tx_signature IS NOT NULL;
+
+CREATE TABLE streamer_events (
+    id SERIAL PRIMARY KEY,
+    receiver TEXT NOT NULL,
+    type TEXT NOT NULL,
+    donation_id TEXT REFERENCES donations(id) ON DELETE CASCADE,
+    message TEXT NOT NULL,
+    created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_streamer_events_receiver ON streamer_events(receiver, created_at DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_streamer_events_receiver;
+DROP TABLE IF EXISTS streamer_events;
+
+DROP INDEX IF EXISTS idx_donations_unfinalized;
+
+ALTER TABLE donations
+    DROP COLUMN IF EXISTS finality_misses,
+    DROP COLUMN IF EXISTS tx_blockhash,
+    DROP COLUMN IF EXISTS tx_height,
+    DROP COLUMN IF EXISTS finalized_at;
+-- +goose StatementEnd
@@ -0,0 +1,55 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE revenue_splits (
+    id SERIAL PRIMARY KEY,
+    receiver TEXT NOT NULL,
+    recipient TEXT NOT NULL,
+    bps INTEGER NOT NULL CHECK (bps > 0 AND bps < 10000),
+    label TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (receiver, recipient)
+);
+
+CREATE INDEX idx_revenue_splits_receiver ON revenue_splits(receiver);
+
+CREATE TABLE donation_legs (
+    id SERIAL PRIMARY KEY,
+    donation_id TEXT NOT NULL REFERENCES donations(id) ON DELETE CASCADE,
+    recipient TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    label TEXT NOT NULL DEFAULT '',
+    amount NUMERIC(39, 0) NOT NULL,
+    created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_donation_legs_donation_id ON donation_legs(donation_id);
+CREATE INDEX idx_donation_legs_recipient ON donation_legs(recipient);
+
+INSERT INTO donation_legs (donation_id, recipient, kind, label, amount)
+SELECT d.id, d.receiver, 'streamer', '',
+       d.amount - COALESCE(ROUND(p.fee_amount::NUMERIC * POWER(10::NUMERIC, d.decimals)), 0)
+FROM donations d
+LEFT JOIN payment_requests p ON p.donation_id = d.id
+ORDER BY d.created_at;
+
+-- The treasury wallet of historical fees is not recorded, so fee legs are only
+-- backfilled for settled donations where they feed analytics, not verification.
+INSERT INTO donation_legs (donation_id, recipient, kind, label, amount)
+SELECT d.id, '', 'platform_fee', 'platform fee',
+       ROUND(p.fee_amount::NUMERIC * POWER(10::NUMERIC, d.decimals))
+FROM donations d
+JOIN payment_requests p ON p.donation_id = d.id
+WHERE p.fee_amount IS NOT NULL AND p.fee_amount::NUMERIC > 0
+  AND d.state NOT IN ('intent_created', 'payment_seen', 'expired')
+ORDER BY d.created_at;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_donation_legs_recipient;
+DROP INDEX IF EXISTS idx_donation_legs_donation_id;
+DROP TABLE IF EXISTS donation_legs;
+
+DROP INDEX IF EXISTS idx_revenue_splits_receiver;
+DROP TABLE IF EXISTS revenue_splits;
+-- +goose StatementEnd
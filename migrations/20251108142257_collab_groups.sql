@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE collab_groups (
+    id TEXT PRIMARY KEY,
+    owner TEXT NOT NULL,
+    name TEXT NOT NULL,
+    created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_collab_groups_owner ON collab_groups(owner);
+
+CREATE TABLE collab_members (
+    id SERIAL PRIMARY KEY,
+    group_id TEXT NOT NULL REFERENCES collab_groups(id) ON DELETE CASCADE,
+    wallet TEXT NOT NULL,
+    bps INTEGER NOT NULL CHECK (bps > 0 AND bps <= 10000),
+    status TEXT NOT NULL DEFAULT 'invited',
+    joined_at TIMESTAMP WITHOUT TIME ZONE,
+    created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (group_id, wallet)
+);
+
+CREATE INDEX idx_collab_members_wallet ON collab_members(wallet);
+
+ALTER TABLE donations
+    ADD COLUMN collab_group_id TEXT REFERENCES collab_groups(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_donations_collab_group_id ON donations(collab_group_id) WHERE collab_group_id IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_donations_collab_group_id;
+
+ALTER TABLE donations
+    DROP COLUMN IF EXISTS collab_group_id;
+
+DROP INDEX IF EXISTS idx_collab_members_wallet;
+DROP TABLE IF EXISTS collab_members;
+
+DROP INDEX IF EXISTS idx_collab_groups_owner;
+DROP TABLE IF EXISTS collab_groups;
+-- +goose StatementEnd
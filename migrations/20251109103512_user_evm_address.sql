@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users
+    ADD COLUMN evm_address TEXT;
+
+CREATE INDEX idx_users_evm_address ON users(LOWER(evm_address)) WHERE evm_address IS NOT NULL;
+
+ALTER TABLE donations
+    ADD COLUMN chain TEXT NOT NULL DEFAULT 'solana';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE donations
+    DROP COLUMN IF EXISTS chain;
+
+DROP INDEX IF EXISTS idx_users_evm_address;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS evm_address;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE donation_rules (
+    id SERIAL PRIMARY KEY,
+    receiver TEXT NOT NULL,
+    currency TEXT NOT NULL,
+    min_alert NUMERIC(39, 0) NOT NULL DEFAULT 0 CHECK (min_alert >= 0),
+    min_voice NUMERIC(39, 0) NOT NULL DEFAULT 0 CHECK (min_voice >= 0),
+    min_media NUMERIC(39, 0) NOT NULL DEFAULT 0 CHECK (min_media >= 0),
+    media_price_per_second NUMERIC(39, 0) NOT NULL DEFAULT 0 CHECK (media_price_per_second >= 0),
+    updated_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (receiver, currency)
+);
+
+CREATE INDEX idx_donation_rules_receiver ON donation_rules(receiver);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_donation_rules_receiver;
+DROP TABLE IF EXISTS donation_rules;
+-- +goose StatementEnd
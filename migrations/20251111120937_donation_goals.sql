@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE donation_goals (
+    id TEXT PRIMARY KEY,
+    owner TEXT NOT NULL,
+    title TEXT NOT NULL,
+    currency TEXT,
+    fiat_currency TEXT,
+    target NUMERIC NOT NULL CHECK (target > 0),
+    deadline TIMESTAMP WITHOUT TIME ZONE,
+    active BOOLEAN NOT NULL DEFAULT FALSE,
+    reached_at TIMESTAMP WITHOUT TIME ZONE,
+    created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+    CHECK ((currency IS NULL) <> (fiat_currency IS NULL))
+);
+
+CREATE INDEX idx_donation_goals_owner ON donation_goals(owner);
+CREATE UNIQUE INDEX idx_donation_goals_active_owner ON donation_goals(owner) WHERE active;
+
+CREATE TABLE donation_goal_contributions (
+    id SERIAL PRIMARY KEY,
+    goal_id TEXT NOT NULL REFERENCES donation_goals(id) ON DELETE CASCADE,
+    donation_id TEXT NOT NULL REFERENCES donations(id) ON DELETE CASCADE,
+    amount NUMERIC NOT NULL CHECK (amount >= 0),
+    created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (goal_id, donation_id)
+);
+
+CREATE INDEX idx_donation_goal_contributions_goal_id ON donation_goal_contributions(goal_id);
+CREATE INDEX idx_donation_goal_contributions_donation_id ON donation_goal_contributions(donation_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_donation_goal_contributions_donation_id;
+DROP INDEX IF EXISTS idx_donation_goal_contributions_goal_id;
+DROP TABLE IF EXISTS donation_goal_contributions;
+
+DROP INDEX IF EXISTS idx_donation_goals_active_owner;
+DROP INDEX IF EXISTS idx_donation_goals_owner;
+DROP TABLE IF EXISTS donation_goals;
+-- +goose StatementEnd
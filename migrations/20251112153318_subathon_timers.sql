@@ -0,0 +1,49 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE subathon_timers (
+    id TEXT PRIMARY KEY,
+    owner TEXT NOT NULL UNIQUE,
+    initial_seconds BIGINT NOT NULL CHECK (initial_seconds > 0),
+    added_seconds BIGINT NOT NULL DEFAULT 0 CHECK (added_seconds >= 0),
+    cap_seconds BIGINT CHECK (cap_seconds > 0),
+    ends_at TIMESTAMP WITHOUT TIME ZONE,
+    paused_remaining BIGINT CHECK (paused_remaining >= 0),
+    started_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+    CHECK ((ends_at IS NULL) <> (paused_remaining IS NULL))
+);
+
+CREATE TABLE subathon_rates (
+    id SERIAL PRIMARY KEY,
+    timer_id TEXT NOT NULL REFERENCES subathon_timers(id) ON DELETE CASCADE,
+    currency TEXT NOT NULL,
+    seconds_per_unit NUMERIC NOT NULL CHECK (seconds_per_unit > 0),
+    UNIQUE (timer_id, currency)
+);
+
+CREATE INDEX idx_subathon_rates_timer_id ON subathon_rates(timer_id);
+
+CREATE TABLE subathon_extensions (
+    id SERIAL PRIMARY KEY,
+    timer_id TEXT NOT NULL REFERENCES subathon_timers(id) ON DELETE CASCADE,
+    donation_id TEXT NOT NULL REFERENCES donations(id) ON DELETE CASCADE,
+    seconds BIGINT NOT NULL CHECK (seconds > 0),
+    created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (timer_id, donation_id)
+);
+
+CREATE INDEX idx_subathon_extensions_timer_id ON subathon_extensions(timer_id);
+CREATE INDEX idx_subathon_extensions_donation_id ON subathon_extensions(donation_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_subathon_extensions_donation_id;
+DROP INDEX IF EXISTS idx_subathon_extensions_timer_id;
+DROP TABLE IF EXISTS subathon_extensions;
+
+DROP INDEX IF EXISTS idx_subathon_rates_timer_id;
+DROP TABLE IF EXISTS subathon_rates;
+
+DROP TABLE IF EXISTS subathon_timers;
+-- +goose StatementEnd
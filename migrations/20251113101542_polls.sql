@@ -0,0 +1,52 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE polls (
+    id TEXT PRIMARY KEY,
+    owner TEXT NOT NULL,
+    title TEXT NOT NULL,
+    currency TEXT,
+    fiat_currency TEXT,
+    closes_at TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+    results_sent_at TIMESTAMP WITHOUT TIME ZONE,
+    created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+    CHECK ((currency IS NULL) <> (fiat_currency IS NULL))
+);
+
+CREATE INDEX idx_polls_owner ON polls(owner);
+CREATE INDEX idx_polls_pending_results ON polls(closes_at) WHERE results_sent_at IS NULL;
+
+CREATE TABLE poll_options (
+    id TEXT PRIMARY KEY,
+    poll_id TEXT NOT NULL REFERENCES polls(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    label TEXT NOT NULL,
+    UNIQUE (poll_id, position)
+);
+
+CREATE TABLE poll_votes (
+    id SERIAL PRIMARY KEY,
+    poll_id TEXT NOT NULL REFERENCES polls(id) ON DELETE CASCADE,
+    option_id TEXT NOT NULL REFERENCES poll_options(id) ON DELETE CASCADE,
+    donation_id TEXT NOT NULL REFERENCES donations(id) ON DELETE CASCADE,
+    amount NUMERIC NOT NULL CHECK (amount >= 0),
+    created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (poll_id, donation_id)
+);
+
+CREATE INDEX idx_poll_votes_option_id ON poll_votes(option_id);
+CREATE INDEX idx_poll_votes_donation_id ON poll_votes(donation_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_poll_votes_donation_id;
+DROP INDEX IF EXISTS idx_poll_votes_option_id;
+DROP TABLE IF EXISTS poll_votes;
+
+DROP TABLE IF EXISTS poll_options;
+
+DROP INDEX IF EXISTS idx_polls_pending_results;
+DROP INDEX IF EXISTS idx_polls_owner;
+DROP TABLE IF EXISTS polls;
+-- +goose StatementEnd
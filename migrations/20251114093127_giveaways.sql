@@ -0,0 +1,53 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE giveaways (
+    id TEXT PRIMARY KEY,
+    owner TEXT NOT NULL,
+    title TEXT NOT NULL,
+    mode TEXT NOT NULL CHECK (mode IN ('weighted', 'equal')),
+    currency TEXT,
+    window_start TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+    window_end TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+    winner_count INTEGER NOT NULL CHECK (winner_count > 0),
+    status TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'committed', 'drawn')),
+    commitment TEXT,
+    target_slot BIGINT,
+    seed_slot BIGINT,
+    seed TEXT,
+    committed_at TIMESTAMP WITHOUT TIME ZONE,
+    drawn_at TIMESTAMP WITHOUT TIME ZONE,
+    created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+    CHECK (window_end > window_start),
+    CHECK (mode <> 'weighted' OR currency IS NOT NULL)
+);
+
+CREATE INDEX idx_giveaways_owner ON giveaways(owner);
+CREATE INDEX idx_giveaways_pending ON giveaways(status) WHERE status <> 'drawn';
+
+CREATE TABLE giveaway_entries (
+    giveaway_id TEXT NOT NULL REFERENCES giveaways(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    address TEXT NOT NULL,
+    weight NUMERIC(39, 0) NOT NULL CHECK (weight > 0),
+    PRIMARY KEY (giveaway_id, position),
+    UNIQUE (giveaway_id, address)
+);
+
+CREATE TABLE giveaway_winners (
+    giveaway_id TEXT NOT NULL REFERENCES giveaways(id) ON DELETE CASCADE,
+    rank INTEGER NOT NULL,
+    address TEXT NOT NULL,
+    PRIMARY KEY (giveaway_id, rank)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS giveaway_winners;
+DROP TABLE IF EXISTS giveaway_entries;
+
+DROP INDEX IF EXISTS idx_giveaways_pending;
+DROP INDEX IF EXISTS idx_giveaways_owner;
+DROP TABLE IF EXISTS giveaways;
+-- +goose StatementEnd
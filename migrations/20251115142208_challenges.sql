@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE challenges (
+    id TEXT PRIMARY KEY,
+    owner TEXT NOT NULL,
+    title TEXT NOT NULL,
+    description TEXT,
+    currency TEXT,
+    fiat_currency TEXT,
+    threshold NUMERIC NOT NULL CHECK (threshold > 0),
+    status TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'completed', 'failed')),
+    funded_at TIMESTAMP WITHOUT TIME ZONE,
+    resolved_at TIMESTAMP WITHOUT TIME ZONE,
+    created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+    CHECK ((currency IS NULL) <> (fiat_currency IS NULL))
+);
+
+CREATE INDEX idx_challenges_owner_status ON challenges(owner, status);
+
+CREATE TABLE challenge_pledges (
+    id SERIAL PRIMARY KEY,
+    challenge_id TEXT NOT NULL REFERENCES challenges(id) ON DELETE CASCADE,
+    donation_id TEXT NOT NULL REFERENCES donations(id) ON DELETE CASCADE,
+    amount NUMERIC NOT NULL CHECK (amount >= 0),
+    created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (challenge_id, donation_id)
+);
+
+CREATE INDEX idx_challenge_pledges_challenge_id ON challenge_pledges(challenge_id);
+CREATE INDEX idx_challenge_pledges_donation_id ON challenge_pledges(donation_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_challenge_pledges_donation_id;
+DROP INDEX IF EXISTS idx_challenge_pledges_challenge_id;
+DROP TABLE IF EXISTS challenge_pledges;
+
+DROP INDEX IF EXISTS idx_challenges_owner_status;
+DROP TABLE IF EXISTS challenges;
+-- +goose StatementEnd
@@ -91,9 +91,31 @@ SWAGGER_PATH=$SWAGGER_PATH,\
 OBS_SERVICE_DOMAIN=$OBS_SERVICE_DOMAIN,\
 HTTP_LISTEN_PORT=$HTTP_LISTEN_PORT,\
 ROUTE_PREFIX=$ROUTE_PREFIX, \
+JWT_ALGORITHM=$JWT_ALGORITHM, \
 JWT_SECRET=$JWT_SECRET, \
-JWT_TOKEN_EXPIRATION_HOURS=$JWT_TOKEN_EXPIRATION_HOURS, \
-RPC_ENDPOINT=$RPC_ENDPOINT" \
+JWT_SIGNING_KEY_FILE=$JWT_SIGNING_KEY_FILE, \
+JWT_VERIFICATION_KEY_FILES=$JWT_VERIFICATION_KEY_FILES, \
+JWT_ACCESS_TOKEN_TTL_MINUTES=$JWT_ACCESS_TOKEN_TTL_MINUTES, \
+REFRESH_TOKEN_TTL_HOURS=$REFRESH_TOKEN_TTL_HOURS, \
+SIWS_DOMAIN=$SIWS_DOMAIN, \
+SIWS_URI=$SIWS_URI, \
+SIWS_CHAIN_ID=$SIWS_CHAIN_ID, \
+RPC_ENDPOINT=$RPC_ENDPOINT, \
+ACCEPTED_MINTS=$ACCEPTED_MINTS, \
+EVM_RPC_URLS=$EVM_RPC_URLS, \
+EVM_TOKENS=$EVM_TOKENS, \
+WATCHER_POLL_INTERVAL_SECONDS=$WATCHER_POLL_INTERVAL_SECONDS, \
+WATCHER_MIN_AMOUNTS=$WATCHER_MIN_AMOUNTS, \
+PAYMENT_REQUEST_TTL_MINUTES=$PAYMENT_REQUEST_TTL_MINUTES, \
+FINALITY_CHECK_DELAY_SECONDS=$FINALITY_CHECK_DELAY_SECONDS, \
+PLATFORM_FEE_BPS=$PLATFORM_FEE_BPS, \
+PLATFORM_FEE_WALLET=$PLATFORM_FEE_WALLET, \
+PRICE_SOURCE=$PRICE_SOURCE, \
+PRICE_API_URL=$PRICE_API_URL, \
+PRICE_COIN_IDS=$PRICE_COIN_IDS, \
+STATIC_PRICES=$STATIC_PRICES, \
+PRICE_CACHE_TTL_SECONDS=$PRICE_CACHE_TTL_SECONDS, \
+FIAT_CURRENCY=$FIAT_CURRENCY" \
     --project=$GOOGLE_CLOUD_PROJECT
 
 # Get service URL